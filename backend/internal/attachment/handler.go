@@ -0,0 +1,125 @@
+package attachment
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func actorFrom(c *fiber.Ctx) (Actor, bool) {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return Actor{}, false
+	}
+	roles, _ := c.Locals("roles").([]string)
+	return Actor{UserID: userID, Roles: roles}, true
+}
+
+func toFiberError(err error, fallback string) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, ErrForbidden):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, ErrTooLarge):
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, ErrInvalidFile):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, fallback)
+}
+
+// POST /api/attachments (multipart: owner_type, owner_id, file)
+func (h *Handler) Upload(c *fiber.Ctx) error {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+
+	ownerType := strings.ToUpper(strings.TrimSpace(c.FormValue("owner_type")))
+	ownerID, err := strconv.ParseInt(c.FormValue("owner_id"), 10, 64)
+	if err != nil || ownerID <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid owner_id")
+	}
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "file is required")
+	}
+
+	a, err := h.svc.Upload(c.Context(), actor, ownerType, ownerID, fh)
+	if err != nil {
+		return toFiberError(err, "failed to upload attachment")
+	}
+	return c.Status(fiber.StatusCreated).JSON(a)
+}
+
+// GET /api/attachments?owner_type=REQUEST&owner_id=1
+func (h *Handler) List(c *fiber.Ctx) error {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+
+	ownerType := strings.ToUpper(strings.TrimSpace(c.Query("owner_type")))
+	ownerID, err := strconv.ParseInt(c.Query("owner_id"), 10, 64)
+	if err != nil || ownerID <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid owner_id")
+	}
+
+	list, err := h.svc.List(c.Context(), actor, ownerType, ownerID)
+	if err != nil {
+		return toFiberError(err, "failed to fetch attachments")
+	}
+	return c.JSON(list)
+}
+
+// GET /api/attachments/:id/download
+func (h *Handler) Download(c *fiber.Ctx) error {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+
+	a, rc, err := h.svc.Open(c.Context(), actor, id)
+	if err != nil {
+		return toFiberError(err, "failed to download attachment")
+	}
+
+	c.Set("Content-Type", a.MimeType)
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", a.FileName))
+	c.Set("X-Checksum-SHA256", a.Checksum)
+	// fasthttp menutup stream setelah selesai dikirim.
+	return c.SendStream(rc, int(a.SizeBytes))
+}
+
+// DELETE /api/attachments/:id
+func (h *Handler) Delete(c *fiber.Ctx) error {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+
+	if err := h.svc.Delete(c.Context(), actor, id); err != nil {
+		return toFiberError(err, "failed to delete attachment")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,38 @@
+package attachment
+
+import "time"
+
+// Jenis objek yang bisa punya lampiran.
+const (
+	OwnerMessage      = "MESSAGE"
+	OwnerRequest      = "REQUEST"
+	OwnerAnnouncement = "ANNOUNCEMENT"
+)
+
+func validOwnerType(t string) bool {
+	switch t {
+	case OwnerMessage, OwnerRequest, OwnerAnnouncement:
+		return true
+	}
+	return false
+}
+
+// Attachment adalah representasi row di tabel "attachments".
+type Attachment struct {
+	ID         int64     `json:"id"`
+	OwnerType  string    `json:"owner_type"`
+	OwnerID    int64     `json:"owner_id"`
+	FileName   string    `json:"file_name"`
+	MimeType   string    `json:"mime_type"`
+	SizeBytes  int64     `json:"size_bytes"`
+	Checksum   string    `json:"checksum_sha256"`
+	StorageKey string    `json:"-"`
+	UploadedBy int64     `json:"uploaded_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Actor is the authenticated caller.
+type Actor struct {
+	UserID int64
+	Roles  []string
+}
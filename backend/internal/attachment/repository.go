@@ -0,0 +1,111 @@
+package attachment
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+const attachmentSelectColumns = `
+	id, owner_type, owner_id, file_name, mime_type, size_bytes,
+	checksum_sha256, storage_key, uploaded_by, created_at
+`
+
+func scanAttachment(row interface{ Scan(dest ...any) error }) (*Attachment, error) {
+	var a Attachment
+	err := row.Scan(
+		&a.ID, &a.OwnerType, &a.OwnerID, &a.FileName, &a.MimeType, &a.SizeBytes,
+		&a.Checksum, &a.StorageKey, &a.UploadedBy, &a.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *Repository) Create(ctx context.Context, a *Attachment) error {
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO attachments (owner_type, owner_id, file_name, mime_type, size_bytes, checksum_sha256, storage_key, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, a.OwnerType, a.OwnerID, a.FileName, a.MimeType, a.SizeBytes, a.Checksum, a.StorageKey, a.UploadedBy,
+	).Scan(&a.ID, &a.CreatedAt)
+}
+
+func (r *Repository) FindByID(ctx context.Context, id int64) (*Attachment, error) {
+	q := `SELECT ` + attachmentSelectColumns + ` FROM attachments WHERE id = $1`
+	return scanAttachment(r.db.QueryRowContext(ctx, q, id))
+}
+
+func (r *Repository) ListByOwner(ctx context.Context, ownerType string, ownerID int64) ([]*Attachment, error) {
+	q := `SELECT ` + attachmentSelectColumns + ` FROM attachments WHERE owner_type = $1 AND owner_id = $2 ORDER BY id`
+	rows, err := r.db.QueryContext(ctx, q, ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM attachments WHERE id = $1`, id)
+	return err
+}
+
+// ==========================
+// Owner lookups untuk access check
+// ==========================
+
+// messageParticipants returns sender and receiver of a message.
+func (r *Repository) messageParticipants(ctx context.Context, id int64) (sender, receiver int64, err error) {
+	var s, rc sql.NullInt64
+	err = r.db.QueryRowContext(ctx, `SELECT sender_id, receiver_id FROM messages WHERE id = $1`, id).Scan(&s, &rc)
+	return s.Int64, rc.Int64, err
+}
+
+// requestOwner returns the user who submitted a request.
+func (r *Repository) requestOwner(ctx context.Context, id int64) (int64, error) {
+	var owner sql.NullInt64
+	err := r.db.QueryRowContext(ctx, `SELECT user_id FROM requests WHERE id = $1`, id).Scan(&owner)
+	return owner.Int64, err
+}
+
+// announcementVisible reports whether the announcement exists and whether it
+// is targeted at the user (same rule as messaging.GetAnnouncements).
+func (r *Repository) announcementVisible(ctx context.Context, id, userID int64, roles []string) (exists, visible bool, err error) {
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(
+			a.is_active AND (
+				(COALESCE(array_length(a.target_departments, 1), 0) = 0 AND COALESCE(array_length(a.target_roles, 1), 0) = 0)
+				OR (SELECT department FROM users WHERE id = $2) = ANY(a.target_departments)
+				OR ($3 && a.target_roles)
+			), FALSE)
+		FROM announcements a
+		WHERE a.id = $1
+	`, id, userID, pq.Array(roles)).Scan(&visible)
+	if err == sql.ErrNoRows {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	return true, visible, nil
+}
@@ -0,0 +1,206 @@
+package attachment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+
+	"hr-portal-backend/pkg/storage"
+)
+
+var (
+	ErrNotFound    = errors.New("attachment not found")
+	ErrForbidden   = errors.New("not allowed to access this attachment")
+	ErrInvalidFile = storage.ErrInvalidFile
+	ErrTooLarge    = storage.ErrTooLarge
+)
+
+// PermissionChecker is satisfied by *rbac.Repository.
+type PermissionChecker interface {
+	HasAnyPermission(ctx context.Context, roles []string, codes ...string) (bool, error)
+}
+
+// RequestReviewers is satisfied by *requests.Service: approver dengan
+// permission sesuai jenis pengajuan atau atasan pemohon.
+type RequestReviewers interface {
+	CanReview(ctx context.Context, approverID int64, roles []string, id int64) (bool, error)
+}
+
+// allowedTypes: dokumen, gambar dan file Office.
+var allowedTypes = storage.Types(".pdf", ".jpg", ".jpeg", ".png", ".docx", ".xlsx")
+
+type Service struct {
+	repo      *Repository
+	store     storage.Storage
+	perms     PermissionChecker
+	reviewers RequestReviewers
+	maxBytes  int64
+}
+
+func NewService(repo *Repository, store storage.Storage, perms PermissionChecker, reviewers RequestReviewers, maxBytes int64) *Service {
+	return &Service{repo: repo, store: store, perms: perms, reviewers: reviewers, maxBytes: maxBytes}
+}
+
+// Upload validates the file, streams it to storage while computing its
+// checksum and records it against the owner.
+func (s *Service) Upload(ctx context.Context, actor Actor, ownerType string, ownerID int64, fh *multipart.FileHeader) (*Attachment, error) {
+	if !validOwnerType(ownerType) {
+		return nil, fmt.Errorf("%w: unknown owner_type %q", ErrInvalidFile, ownerType)
+	}
+	if err := s.authorize(ctx, actor, ownerType, ownerID, true); err != nil {
+		return nil, err
+	}
+	up, err := storage.PutUpload(ctx, s.store, "attachments", fh, allowedTypes, s.maxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Attachment{
+		OwnerType:  ownerType,
+		OwnerID:    ownerID,
+		FileName:   up.FileName,
+		MimeType:   up.MimeType,
+		SizeBytes:  up.SizeBytes,
+		Checksum:   up.Checksum,
+		StorageKey: up.Key,
+		UploadedBy: actor.UserID,
+	}
+	if err := s.repo.Create(ctx, a); err != nil {
+		_ = s.store.Delete(ctx, up.Key)
+		return nil, err
+	}
+	return a, nil
+}
+
+// List returns the attachments of an owner the actor can see.
+func (s *Service) List(ctx context.Context, actor Actor, ownerType string, ownerID int64) ([]*Attachment, error) {
+	if !validOwnerType(ownerType) {
+		return nil, fmt.Errorf("%w: unknown owner_type %q", ErrInvalidFile, ownerType)
+	}
+	if err := s.authorize(ctx, actor, ownerType, ownerID, false); err != nil {
+		return nil, err
+	}
+	return s.repo.ListByOwner(ctx, ownerType, ownerID)
+}
+
+// Open returns the attachment metadata and its content stream.
+// Caller wajib menutup reader.
+func (s *Service) Open(ctx context.Context, actor Actor, id int64) (*Attachment, io.ReadCloser, error) {
+	a, err := s.find(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.authorize(ctx, actor, a.OwnerType, a.OwnerID, false); err != nil {
+		return nil, nil, err
+	}
+	rc, err := s.store.Open(ctx, a.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+	return a, rc, nil
+}
+
+// Delete removes an attachment. Only the uploader may delete it, and only
+// while they still have write access to the owner.
+func (s *Service) Delete(ctx context.Context, actor Actor, id int64) error {
+	a, err := s.find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if a.UploadedBy != actor.UserID {
+		return ErrForbidden
+	}
+	if err := s.authorize(ctx, actor, a.OwnerType, a.OwnerID, true); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	return s.store.Delete(ctx, a.StorageKey)
+}
+
+func (s *Service) find(ctx context.Context, id int64) (*Attachment, error) {
+	a, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return a, nil
+}
+
+// authorize follows the owning object:
+//   - MESSAGE: sender (write) or sender/receiver (read)
+//   - REQUEST: submitter, or whoever may review the request (read)
+//   - ANNOUNCEMENT: CREATE_ANNOUNCEMENTS (write) or users the announcement targets (read)
+func (s *Service) authorize(ctx context.Context, actor Actor, ownerType string, ownerID int64, write bool) error {
+	switch ownerType {
+	case OwnerMessage:
+		sender, receiver, err := s.repo.messageParticipants(ctx, ownerID)
+		if err != nil {
+			return notFoundOr(err)
+		}
+		if actor.UserID == sender || (!write && actor.UserID == receiver) {
+			return nil
+		}
+		return ErrForbidden
+
+	case OwnerRequest:
+		owner, err := s.repo.requestOwner(ctx, ownerID)
+		if err != nil {
+			return notFoundOr(err)
+		}
+		if actor.UserID == owner {
+			return nil
+		}
+		if !write {
+			ok, err := s.reviewers.CanReview(ctx, actor.UserID, actor.Roles, ownerID)
+			if err != nil {
+				return notFoundOr(err)
+			}
+			if ok {
+				return nil
+			}
+		}
+		return ErrForbidden
+
+	case OwnerAnnouncement:
+		exists, visible, err := s.repo.announcementVisible(ctx, ownerID, actor.UserID, actor.Roles)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrNotFound
+		}
+		if !write && visible {
+			return nil
+		}
+		return s.requirePermission(ctx, actor, "CREATE_ANNOUNCEMENTS")
+	}
+	return ErrNotFound
+}
+
+func (s *Service) requirePermission(ctx context.Context, actor Actor, codes ...string) error {
+	ok, err := s.perms.HasAnyPermission(ctx, actor.Roles, codes...)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func notFoundOr(err error) error {
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,26 @@
+package audit
+
+import "github.com/gofiber/fiber/v2"
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// GET /api/audit-log?action=EMPLOYEE_ANONYMIZED&entity=user&entity_id=12&actor_id=1&limit=100
+func (h *Handler) List(c *fiber.Ctx) error {
+	list, err := h.svc.List(c.Context(), Filter{
+		Action:   c.Query("action"),
+		Entity:   c.Query("entity"),
+		EntityID: c.Query("entity_id"),
+		ActorID:  int64(c.QueryInt("actor_id")),
+		Limit:    c.QueryInt("limit"),
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch audit log")
+	}
+	return c.JSON(list)
+}
@@ -0,0 +1,27 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Entry adalah row di tabel "audit_log".
+type Entry struct {
+	ID        int64           `json:"id"`
+	ActorID   *int64          `json:"actor_id,omitempty"`
+	ActorName string          `json:"actor_name,omitempty"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  string          `json:"entity_id,omitempty"`
+	Details   json.RawMessage `json:"details"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Filter is the parsed query of GET /api/audit-log.
+type Filter struct {
+	Action   string
+	Entity   string
+	EntityID string
+	ActorID  int64
+	Limit    int
+}
@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// dbtx is satisfied by both *sql.DB and *sql.Tx.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type Repository struct {
+	db dbtx
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx.
+func (r *Repository) WithTx(tx *sql.Tx) *Repository {
+	return &Repository{db: tx}
+}
+
+func (r *Repository) Insert(ctx context.Context, e *Entry) error {
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO audit_log (actor_id, action, entity, entity_id, details)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		RETURNING id, created_at
+	`, e.ActorID, e.Action, e.Entity, e.EntityID, []byte(e.Details)).Scan(&e.ID, &e.CreatedAt)
+}
+
+// List returns the newest entries matching the filter.
+func (r *Repository) List(ctx context.Context, f Filter) ([]Entry, error) {
+	where := []string{"TRUE"}
+	var args []any
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.Action != "" {
+		add("a.action = $%d", f.Action)
+	}
+	if f.Entity != "" {
+		add("a.entity = $%d", f.Entity)
+	}
+	if f.EntityID != "" {
+		add("a.entity_id = $%d", f.EntityID)
+	}
+	if f.ActorID > 0 {
+		add("a.actor_id = $%d", f.ActorID)
+	}
+	args = append(args, f.Limit)
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT a.id, a.actor_id, COALESCE(u.name, ''), a.action, a.entity, COALESCE(a.entity_id, ''), a.details, a.created_at
+		FROM audit_log a
+		LEFT JOIN users u ON u.id = a.actor_id
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY a.id DESC
+		LIMIT $`+fmt.Sprint(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []Entry{}
+	for rows.Next() {
+		var e Entry
+		var actorID sql.NullInt64
+		var details []byte
+		if err := rows.Scan(&e.ID, &actorID, &e.ActorName, &e.Action, &e.Entity, &e.EntityID, &details, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if actorID.Valid {
+			id := actorID.Int64
+			e.ActorID = &id
+		}
+		e.Details = details
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
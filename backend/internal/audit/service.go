@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+)
+
+// Default dan batas jumlah entry per halaman.
+const (
+	DefaultLimit = 100
+	MaxLimit     = 500
+)
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// LogTx writes an audit entry inside the caller's transaction so the entry
+// only exists when the audited change is committed. details di-encode JSON.
+func (s *Service) LogTx(ctx context.Context, tx *sql.Tx, actorID int64, action, entity, entityID string, details any) error {
+	raw := []byte("{}")
+	if details != nil {
+		var err error
+		if raw, err = json.Marshal(details); err != nil {
+			return err
+		}
+	}
+	e := &Entry{Action: action, Entity: entity, EntityID: entityID, Details: raw}
+	if actorID > 0 {
+		e.ActorID = &actorID
+	}
+	return s.repo.WithTx(tx).Insert(ctx, e)
+}
+
+func (s *Service) List(ctx context.Context, f Filter) ([]Entry, error) {
+	f.Action = strings.ToUpper(strings.TrimSpace(f.Action))
+	f.Entity = strings.ToLower(strings.TrimSpace(f.Entity))
+	f.EntityID = strings.TrimSpace(f.EntityID)
+	if f.Limit <= 0 {
+		f.Limit = DefaultLimit
+	}
+	f.Limit = min(f.Limit, MaxLimit)
+	return s.repo.List(ctx, f)
+}
@@ -52,6 +52,9 @@ func (h *Handler) Login(c *fiber.Ctx) error {
 		if errors.Is(err, user.ErrInvalidCredentials) {
 			return fiber.NewError(fiber.StatusUnauthorized, "invalid credentials")
 		}
+		if errors.Is(err, user.ErrAccountInactive) {
+			return fiber.NewError(fiber.StatusForbidden, "account is not active")
+		}
 
 		// Error lain (DB, dll) -> 500
 		log.Printf("failed to login for %s: %v", req.Email, err)
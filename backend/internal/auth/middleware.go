@@ -1,10 +1,20 @@
 package auth
 
 import (
+	"context"
+	"log"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 )
 
-func JWTMiddleware(jwtMgr Manager) fiber.Handler {
+// SessionChecker tells whether a token is still usable (user ACTIVE, token
+// not revoked). Dipenuhi oleh *user.Service.
+type SessionChecker interface {
+	SessionActive(ctx context.Context, userID int64, issuedAt time.Time) (bool, error)
+}
+
+func JWTMiddleware(jwtMgr Manager, sessions SessionChecker) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		token := c.Cookies("access_token")
 		if token == "" {
@@ -16,6 +26,19 @@ func JWTMiddleware(jwtMgr Manager) fiber.Handler {
 			return fiber.NewError(fiber.StatusUnauthorized, "invalid token")
 		}
 
+		var issuedAt time.Time
+		if claims.IssuedAt != nil {
+			issuedAt = claims.IssuedAt.Time
+		}
+		active, err := sessions.SessionActive(c.Context(), claims.UserID, issuedAt)
+		if err != nil {
+			log.Printf("failed to check session of user %d: %v", claims.UserID, err)
+			return fiber.NewError(fiber.StatusInternalServerError, "failed to check session")
+		}
+		if !active {
+			return fiber.NewError(fiber.StatusUnauthorized, "session revoked")
+		}
+
 		c.Locals("userID", claims.UserID)
 		c.Locals("roles", claims.Roles)
 
@@ -0,0 +1,73 @@
+package celebration
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func toFiberError(err error, fallback string) error {
+	var vErr *ValidationError
+	switch {
+	case errors.As(err, &vErr):
+		return fiber.NewError(fiber.StatusBadRequest, vErr.Message)
+	case errors.Is(err, ErrUserNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, fallback)
+}
+
+func sessionUser(c *fiber.Ctx) (int64, error) {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return 0, fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	return userID, nil
+}
+
+// GET /api/celebrations?days=14&department=IT - ulang tahun & anniversary kerja
+func (h *Handler) Feed(c *fiber.Ctx) error {
+	list, err := h.svc.Feed(c.Context(), c.QueryInt("days"), c.Query("department"))
+	if err != nil {
+		return toFiberError(err, "failed to fetch celebrations")
+	}
+	return c.JSON(list)
+}
+
+// GET /api/me/celebration-preferences
+func (h *Handler) GetPreferences(c *fiber.Ctx) error {
+	userID, err := sessionUser(c)
+	if err != nil {
+		return err
+	}
+	p, err := h.svc.Preferences(c.Context(), userID)
+	if err != nil {
+		return toFiberError(err, "failed to fetch celebration preferences")
+	}
+	return c.JSON(p)
+}
+
+// PUT /api/me/celebration-preferences  body: {"hide_birthday": true, "hide_anniversary": false}
+func (h *Handler) UpdatePreferences(c *fiber.Ctx) error {
+	userID, err := sessionUser(c)
+	if err != nil {
+		return err
+	}
+	var in Preferences
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	p, err := h.svc.SavePreferences(c.Context(), userID, &in)
+	if err != nil {
+		return toFiberError(err, "failed to save celebration preferences")
+	}
+	return c.JSON(p)
+}
@@ -0,0 +1,44 @@
+package celebration
+
+import "time"
+
+// Jenis perayaan.
+const (
+	KindBirthday    = "BIRTHDAY"
+	KindAnniversary = "ANNIVERSARY"
+)
+
+// Event is one upcoming birthday or work anniversary in the feed. Umur
+// sengaja tidak ditampilkan, hanya tanggal.
+type Event struct {
+	Kind         string    `json:"kind"` // BIRTHDAY, ANNIVERSARY
+	UserID       int64     `json:"user_id"`
+	EmployeeCode string    `json:"employee_code"`
+	Name         string    `json:"name"`
+	Department   string    `json:"department,omitempty"`
+	JobTitle     string    `json:"job_title,omitempty"`
+	PhotoURL     string    `json:"photo_url,omitempty"`
+	Date         time.Time `json:"date"`            // tanggal perayaan berikutnya
+	DaysUntil    int       `json:"days_until"`      // 0 = hari ini
+	Years        int       `json:"years,omitempty"` // lama bekerja, hanya ANNIVERSARY
+}
+
+// Preferences is the employee's privacy choice for the feed.
+type Preferences struct {
+	HideBirthday    bool `json:"hide_birthday"`
+	HideAnniversary bool `json:"hide_anniversary"`
+}
+
+// person is an active employee with the dates the feed is built from.
+type person struct {
+	UserID          int64
+	EmployeeCode    string
+	Name            string
+	Department      string
+	JobTitle        string
+	PhotoURL        string
+	BirthDate       *time.Time
+	JoinDate        *time.Time
+	HideBirthday    bool
+	HideAnniversary bool
+}
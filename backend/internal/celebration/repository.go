@@ -0,0 +1,154 @@
+package celebration
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// dbtx is satisfied by both *sql.DB and *sql.Tx.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type Repository struct {
+	db   dbtx
+	conn *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db, conn: db}
+}
+
+func (r *Repository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return r.conn.BeginTx(ctx, nil)
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx.
+func (r *Repository) WithTx(tx *sql.Tx) *Repository {
+	return &Repository{db: tx, conn: r.conn}
+}
+
+func (r *Repository) UserExists(ctx context.Context, userID int64) (bool, error) {
+	var ok bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&ok)
+	return ok, err
+}
+
+// ListPeople returns active, non-anonymized employees that have a birth or
+// join date, with their opt-out flags. department "" = semua.
+func (r *Repository) ListPeople(ctx context.Context, department string) ([]person, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT u.id, u.employee_code, u.name, COALESCE(u.department, ''), COALESCE(u.job_title, ''),
+			COALESCE(u.photo_url, ''), u.birth_date, u.join_date,
+			COALESCE(p.hide_birthday, FALSE), COALESCE(p.hide_anniversary, FALSE)
+		FROM users u
+		LEFT JOIN celebration_preferences p ON p.user_id = u.id
+		WHERE u.status = 'ACTIVE' AND u.anonymized_at IS NULL
+			AND (u.birth_date IS NOT NULL OR u.join_date IS NOT NULL)
+			AND ($1 = '' OR u.department = $1)
+		ORDER BY u.name
+	`, department)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []person
+	for rows.Next() {
+		var p person
+		if err := rows.Scan(&p.UserID, &p.EmployeeCode, &p.Name, &p.Department, &p.JobTitle,
+			&p.PhotoURL, &p.BirthDate, &p.JoinDate, &p.HideBirthday, &p.HideAnniversary); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// ==========================
+// Preferensi
+// ==========================
+
+func (r *Repository) GetPreferences(ctx context.Context, userID int64) (*Preferences, error) {
+	var p Preferences
+	err := r.db.QueryRowContext(ctx, `
+		SELECT hide_birthday, hide_anniversary FROM celebration_preferences WHERE user_id = $1
+	`, userID).Scan(&p.HideBirthday, &p.HideAnniversary)
+	if err == sql.ErrNoRows {
+		return &Preferences{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *Repository) SavePreferences(ctx context.Context, userID int64, p *Preferences) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO celebration_preferences (user_id, hide_birthday, hide_anniversary)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			hide_birthday = EXCLUDED.hide_birthday,
+			hide_anniversary = EXCLUDED.hide_anniversary,
+			updated_at = NOW()
+	`, userID, p.HideBirthday, p.HideAnniversary)
+	return err
+}
+
+// ==========================
+// Pengumuman otomatis
+// ==========================
+
+// ClaimAnnouncement reserves the announcement slot for one celebration.
+// false = sudah dibuat sebelumnya (atau sedang dibuat instance lain).
+func (r *Repository) ClaimAnnouncement(ctx context.Context, userID int64, kind string, on time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO celebration_announcements (user_id, kind, occurs_on)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`, userID, kind, on.Format("2006-01-02"))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// CreateAnnouncement inserts a system announcement (tanpa created_by) for
+// the given departments; nil = semua karyawan.
+func (r *Repository) CreateAnnouncement(ctx context.Context, title, content string, departments []string) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO announcements (title, content, target_departments)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, title, content, pq.Array(departments)).Scan(&id)
+	return id, err
+}
+
+func (r *Repository) LinkAnnouncement(ctx context.Context, userID int64, kind string, on time.Time, announcementID int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE celebration_announcements SET announcement_id = $4
+		WHERE user_id = $1 AND kind = $2 AND occurs_on = $3
+	`, userID, kind, on.Format("2006-01-02"), announcementID)
+	return err
+}
+
+// ExpireAnnouncements deactivates auto announcements for celebrations
+// before the given date.
+func (r *Repository) ExpireAnnouncements(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE announcements SET is_active = FALSE
+		WHERE is_active AND id IN (
+			SELECT announcement_id FROM celebration_announcements WHERE occurs_on < $1
+		)
+	`, before.Format("2006-01-02"))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
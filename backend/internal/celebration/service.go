@@ -0,0 +1,210 @@
+package celebration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var ErrUserNotFound = errors.New("employee not found")
+
+// ValidationError is returned for invalid input; handler mengembalikan 400.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string { return e.Message }
+
+func invalid(msg string) error { return &ValidationError{Message: msg} }
+
+// Rentang feed dalam hari ke depan.
+const (
+	DefaultFeedDays = 14
+	MaxFeedDays     = 90
+)
+
+type Service struct {
+	repo     *Repository
+	announce bool
+}
+
+// NewService; announce = buat pengumuman otomatis ke departemen karyawan
+// pada hari perayaannya.
+func NewService(repo *Repository, announce bool) *Service {
+	return &Service{repo: repo, announce: announce}
+}
+
+// today returns the current date at midnight UTC, matching how DATE columns
+// are scanned.
+func today() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// occurrence returns the date's anniversary in the given year. 29 Februari
+// dirayakan 28 Februari di tahun non-kabisat.
+func occurrence(d time.Time, year int) time.Time {
+	m, day := d.Month(), d.Day()
+	if m == time.February && day == 29 && !isLeap(year) {
+		day = 28
+	}
+	return time.Date(year, m, day, 0, 0, 0, 0, time.UTC)
+}
+
+func isLeap(y int) bool {
+	return y%4 == 0 && (y%100 != 0 || y%400 == 0)
+}
+
+// nextOccurrence returns the first anniversary of d on or after from.
+func nextOccurrence(d, from time.Time) time.Time {
+	next := occurrence(d, from.Year())
+	if next.Before(from) {
+		next = occurrence(d, from.Year()+1)
+	}
+	return next
+}
+
+// events builds the celebrations between from and from+days (inklusif),
+// tanpa karyawan yang opt-out.
+func events(people []person, from time.Time, days int) []Event {
+	until := from.AddDate(0, 0, days)
+	out := []Event{}
+	add := func(p *person, kind string, on time.Time, years int) {
+		out = append(out, Event{
+			Kind:         kind,
+			UserID:       p.UserID,
+			EmployeeCode: p.EmployeeCode,
+			Name:         p.Name,
+			Department:   p.Department,
+			JobTitle:     p.JobTitle,
+			PhotoURL:     p.PhotoURL,
+			Date:         on,
+			DaysUntil:    int(on.Sub(from).Hours() / 24),
+			Years:        years,
+		})
+	}
+	for i := range people {
+		p := &people[i]
+		if p.BirthDate != nil && !p.HideBirthday {
+			if on := nextOccurrence(*p.BirthDate, from); !on.After(until) {
+				add(p, KindBirthday, on, 0)
+			}
+		}
+		if p.JoinDate != nil && !p.HideAnniversary {
+			on := nextOccurrence(*p.JoinDate, from)
+			// Tahun pertama belum dihitung anniversary.
+			if years := on.Year() - p.JoinDate.Year(); years > 0 && !on.After(until) {
+				add(p, KindAnniversary, on, years)
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].Date.Equal(out[j].Date) {
+			return out[i].Date.Before(out[j].Date)
+		}
+		return out[i].Kind < out[j].Kind
+	})
+	return out
+}
+
+// Feed lists upcoming birthdays and work anniversaries, mulai hari ini.
+func (s *Service) Feed(ctx context.Context, days int, department string) ([]Event, error) {
+	if days < 0 {
+		return nil, invalid("days must not be negative")
+	}
+	if days == 0 {
+		days = DefaultFeedDays
+	}
+	days = min(days, MaxFeedDays)
+	people, err := s.repo.ListPeople(ctx, department)
+	if err != nil {
+		return nil, err
+	}
+	return events(people, today(), days), nil
+}
+
+func (s *Service) Preferences(ctx context.Context, userID int64) (*Preferences, error) {
+	ok, err := s.repo.UserExists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return s.repo.GetPreferences(ctx, userID)
+}
+
+func (s *Service) SavePreferences(ctx context.Context, userID int64, p *Preferences) (*Preferences, error) {
+	ok, err := s.repo.UserExists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	if err := s.repo.SavePreferences(ctx, userID, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// announcement returns the title and content of the auto announcement.
+func announcement(e *Event) (title, content string) {
+	if e.Kind == KindBirthday {
+		return fmt.Sprintf("Selamat ulang tahun, %s!", e.Name),
+			fmt.Sprintf("Hari ini %s berulang tahun. Jangan lupa sampaikan ucapan selamat!", e.Name)
+	}
+	return fmt.Sprintf("Selamat %d tahun, %s!", e.Years, e.Name),
+		fmt.Sprintf("Hari ini %s genap %d tahun bergabung bersama kita. Terima kasih atas dedikasinya!", e.Name, e.Years)
+}
+
+// Announce is the scheduler entry point: membuat pengumuman untuk perayaan
+// hari ini ke departemen karyawan (semua karyawan jika tanpa departemen) dan
+// menonaktifkan pengumuman perayaan yang sudah lewat. Aman dijalankan
+// berulang kali.
+func (s *Service) Announce(ctx context.Context) error {
+	if !s.announce {
+		return nil
+	}
+	people, err := s.repo.ListPeople(ctx, "")
+	if err != nil {
+		return err
+	}
+	now := today()
+	for _, e := range events(people, now, 0) {
+		if err := s.announceOne(ctx, &e); err != nil {
+			return err
+		}
+	}
+	_, err = s.repo.ExpireAnnouncements(ctx, now)
+	return err
+}
+
+func (s *Service) announceOne(ctx context.Context, e *Event) error {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	claimed, err := repo.ClaimAnnouncement(ctx, e.UserID, e.Kind, e.Date)
+	if err != nil || !claimed {
+		return err
+	}
+	var departments []string
+	if e.Department != "" {
+		departments = []string{e.Department}
+	}
+	title, content := announcement(e)
+	id, err := repo.CreateAnnouncement(ctx, title, content, departments)
+	if err != nil {
+		return err
+	}
+	if err := repo.LinkAnnouncement(ctx, e.UserID, e.Kind, e.Date, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
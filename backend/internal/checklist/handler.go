@@ -0,0 +1,205 @@
+package checklist
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func actorFrom(c *fiber.Ctx) (Actor, bool) {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return Actor{}, false
+	}
+	roles, _ := c.Locals("roles").([]string)
+	return Actor{UserID: userID, Roles: roles}, true
+}
+
+func toFiberError(err error, fallback string) error {
+	var vErr *ValidationError
+	switch {
+	case errors.As(err, &vErr):
+		return fiber.NewError(fiber.StatusBadRequest, vErr.Message)
+	case errors.Is(err, ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, ErrForbidden):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, ErrAlreadyCompleted):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, fallback)
+}
+
+func parseID(c *fiber.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	return id, nil
+}
+
+// ==========================
+// Templates
+// ==========================
+
+// GET /api/checklist-templates?kind=ONBOARDING&include_inactive=true
+func (h *Handler) ListTemplates(c *fiber.Ctx) error {
+	list, err := h.svc.ListTemplates(c.Context(), c.Query("kind"), c.QueryBool("include_inactive"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch checklist templates")
+	}
+	return c.JSON(list)
+}
+
+// GET /api/checklist-templates/:id
+func (h *Handler) GetTemplate(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	t, err := h.svc.GetTemplate(c.Context(), id)
+	if err != nil {
+		return toFiberError(err, "failed to fetch checklist template")
+	}
+	return c.JSON(t)
+}
+
+// POST /api/checklist-templates
+// body: {"kind":"ONBOARDING","department":"IT","name":"IT onboarding","tasks":[{"title":"...","owner_role":"IT_ADMIN","due_offset_days":1}]}
+func (h *Handler) CreateTemplate(c *fiber.Ctx) error {
+	var in Template
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	t, err := h.svc.CreateTemplate(c.Context(), in)
+	if err != nil {
+		return toFiberError(err, "failed to create checklist template")
+	}
+	return c.Status(fiber.StatusCreated).JSON(t)
+}
+
+// PUT /api/checklist-templates/:id - task list diganti seluruhnya
+func (h *Handler) UpdateTemplate(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	var in struct {
+		Template
+		IsActive *bool `json:"is_active"` // tidak dikirim = tidak berubah
+	}
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	t, err := h.svc.UpdateTemplate(c.Context(), id, in.Template, in.IsActive)
+	if err != nil {
+		return toFiberError(err, "failed to update checklist template")
+	}
+	return c.JSON(t)
+}
+
+// DELETE /api/checklist-templates/:id - nonaktifkan
+func (h *Handler) DeleteTemplate(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	if err := h.svc.DeactivateTemplate(c.Context(), id); err != nil {
+		return toFiberError(err, "failed to delete checklist template")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ==========================
+// Checklists & tasks
+// ==========================
+
+// GET /api/employees/:id/checklists
+func (h *Handler) ListForEmployee(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	list, err := h.svc.ListForUser(c.Context(), id)
+	if err != nil {
+		return toFiberError(err, "failed to fetch checklists")
+	}
+	return c.JSON(list)
+}
+
+// GET /api/me/checklist-tasks - task terbuka milik role saya
+func (h *Handler) MyTasks(c *fiber.Ctx) error {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	list, err := h.svc.MyTasks(c.Context(), actor)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch tasks")
+	}
+	return c.JSON(list)
+}
+
+// GET /api/checklist-tasks/overdue?role=IT_ADMIN&department=IT&kind=OFFBOARDING
+func (h *Handler) Overdue(c *fiber.Ctx) error {
+	f := TaskFilter{Department: c.Query("department"), Kind: c.Query("kind")}
+	if role := c.Query("role"); role != "" {
+		f.Roles = []string{role}
+	}
+	list, err := h.svc.Overdue(c.Context(), f)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch overdue tasks")
+	}
+	return c.JSON(list)
+}
+
+// POST /api/checklist-tasks/:id/complete  body: {"note":"..."}
+func (h *Handler) CompleteTask(c *fiber.Ctx) error {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	var body struct {
+		Note string `json:"note"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+		}
+	}
+	t, err := h.svc.CompleteTask(c.Context(), actor, id, body.Note)
+	if err != nil {
+		return toFiberError(err, "failed to complete task")
+	}
+	return c.JSON(t)
+}
+
+// POST /api/checklist-tasks/:id/reopen
+func (h *Handler) ReopenTask(c *fiber.Ctx) error {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	t, err := h.svc.ReopenTask(c.Context(), actor, id)
+	if err != nil {
+		return toFiberError(err, "failed to reopen task")
+	}
+	return c.JSON(t)
+}
@@ -0,0 +1,70 @@
+package checklist
+
+import "time"
+
+// Jenis checklist.
+const (
+	KindOnboarding  = "ONBOARDING"
+	KindOffboarding = "OFFBOARDING"
+)
+
+// Template adalah row di tabel "checklist_templates" beserta task-nya.
+type Template struct {
+	ID         int64          `json:"id"`
+	Kind       string         `json:"kind"`
+	Department string         `json:"department,omitempty"` // kosong = semua department
+	Name       string         `json:"name"`
+	IsActive   bool           `json:"is_active"`
+	CreatedAt  time.Time      `json:"created_at"`
+	Tasks      []TemplateTask `json:"tasks"`
+}
+
+// TemplateTask is one task of a template; the due date of the instantiated
+// task is the checklist start date + DueOffsetDays.
+type TemplateTask struct {
+	ID            int64  `json:"id"`
+	Title         string `json:"title"`
+	Description   string `json:"description,omitempty"`
+	OwnerRole     string `json:"owner_role"`
+	DueOffsetDays int    `json:"due_offset_days"`
+}
+
+// Checklist is a template instantiated for one employee.
+type Checklist struct {
+	ID           int64     `json:"id"`
+	UserID       int64     `json:"user_id"`
+	EmployeeName string    `json:"employee_name"`
+	EmployeeCode string    `json:"employee_code"`
+	TemplateID   *int64    `json:"template_id,omitempty"`
+	Kind         string    `json:"kind"`
+	Name         string    `json:"name"`
+	CreatedAt    time.Time `json:"created_at"`
+	Tasks        []Task    `json:"tasks"`
+}
+
+// Task is one task of an employee checklist.
+type Task struct {
+	ID           int64      `json:"id"`
+	ChecklistID  int64      `json:"checklist_id"`
+	UserID       int64      `json:"user_id"`
+	EmployeeName string     `json:"employee_name"`
+	EmployeeCode string     `json:"employee_code"`
+	Department   string     `json:"department"`
+	Kind         string     `json:"kind"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description,omitempty"`
+	OwnerRole    string     `json:"owner_role"`
+	DueDate      time.Time  `json:"due_date"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	CompletedBy  *int64     `json:"completed_by,omitempty"`
+	Note         string     `json:"note,omitempty"`
+	Overdue      bool       `json:"overdue"`
+}
+
+// TaskFilter filters the task lists (open tasks per role, overdue report).
+type TaskFilter struct {
+	Roles       []string // kosong = semua role
+	Department  string
+	Kind        string
+	OverdueOnly bool
+}
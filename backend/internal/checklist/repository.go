@@ -0,0 +1,343 @@
+package checklist
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// dbtx is satisfied by both *sql.DB and *sql.Tx.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type Repository struct {
+	db   dbtx
+	conn *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db, conn: db}
+}
+
+func (r *Repository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return r.conn.BeginTx(ctx, nil)
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx.
+func (r *Repository) WithTx(tx *sql.Tx) *Repository {
+	return &Repository{db: tx, conn: r.conn}
+}
+
+// ==========================
+// Templates
+// ==========================
+
+func (r *Repository) queryTemplates(ctx context.Context, where string, args ...any) ([]Template, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, kind, COALESCE(department, ''), name, is_active, created_at
+		FROM checklist_templates
+		WHERE `+where+`
+		ORDER BY kind, department NULLS FIRST, name, id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	out := []Template{}
+	for rows.Next() {
+		var t Template
+		if err := rows.Scan(&t.ID, &t.Kind, &t.Department, &t.Name, &t.IsActive, &t.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range out {
+		if out[i].Tasks, err = r.templateTasks(ctx, out[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (r *Repository) templateTasks(ctx context.Context, templateID int64) ([]TemplateTask, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, title, COALESCE(description, ''), owner_role, due_offset_days
+		FROM checklist_template_tasks
+		WHERE template_id = $1
+		ORDER BY sort_order, id
+	`, templateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []TemplateTask{}
+	for rows.Next() {
+		var t TemplateTask
+		if err := rows.Scan(&t.ID, &t.Title, &t.Description, &t.OwnerRole, &t.DueOffsetDays); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// ListTemplates returns templates of a kind (kosong = semua).
+func (r *Repository) ListTemplates(ctx context.Context, kind string, includeInactive bool) ([]Template, error) {
+	return r.queryTemplates(ctx, `($1 = '' OR kind = $1) AND (is_active OR $2)`, kind, includeInactive)
+}
+
+func (r *Repository) FindTemplate(ctx context.Context, id int64) (*Template, error) {
+	list, err := r.queryTemplates(ctx, `id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &list[0], nil
+}
+
+// MatchingTemplates returns the active templates of a kind that apply to a
+// department: the department's own plus the company-wide ones.
+func (r *Repository) MatchingTemplates(ctx context.Context, kind, department string) ([]Template, error) {
+	return r.queryTemplates(ctx, `is_active AND kind = $1 AND (department IS NULL OR department = $2)`, kind, department)
+}
+
+func (r *Repository) CreateTemplate(ctx context.Context, t *Template) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO checklist_templates (kind, department, name, is_active)
+		VALUES ($1, NULLIF($2, ''), $3, $4)
+		RETURNING id, created_at
+	`, t.Kind, t.Department, t.Name, t.IsActive).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return r.insertTemplateTasks(ctx, t)
+}
+
+// UpdateTemplate saves the template and replaces its task list. active nil
+// = is_active tidak berubah.
+func (r *Repository) UpdateTemplate(ctx context.Context, t *Template, active *bool) error {
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE checklist_templates
+		SET kind = $1, department = NULLIF($2, ''), name = $3, is_active = COALESCE($4, is_active)
+		WHERE id = $5
+		RETURNING is_active
+	`, t.Kind, t.Department, t.Name, active, t.ID).Scan(&t.IsActive)
+	if err != nil {
+		return err
+	}
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM checklist_template_tasks WHERE template_id = $1`, t.ID); err != nil {
+		return err
+	}
+	return r.insertTemplateTasks(ctx, t)
+}
+
+func (r *Repository) insertTemplateTasks(ctx context.Context, t *Template) error {
+	for i := range t.Tasks {
+		task := &t.Tasks[i]
+		err := r.db.QueryRowContext(ctx, `
+			INSERT INTO checklist_template_tasks (template_id, title, description, owner_role, due_offset_days, sort_order)
+			VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6)
+			RETURNING id
+		`, t.ID, task.Title, task.Description, task.OwnerRole, task.DueOffsetDays, i+1).Scan(&task.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) DeactivateTemplate(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE checklist_templates SET is_active = FALSE WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ==========================
+// Employee checklists
+// ==========================
+
+// CreateChecklist copies a template into a checklist for the employee; due
+// dates are counted from start.
+func (r *Repository) CreateChecklist(ctx context.Context, userID int64, t *Template, start time.Time) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO employee_checklists (user_id, template_id, kind, name)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, userID, t.ID, t.Kind, t.Name).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO employee_checklist_tasks (checklist_id, title, description, owner_role, due_date, sort_order)
+		SELECT $1, title, description, owner_role, $2::date + due_offset_days, sort_order
+		FROM checklist_template_tasks
+		WHERE template_id = $3
+	`, id, start, t.ID)
+	return id, err
+}
+
+func (r *Repository) ListChecklists(ctx context.Context, userID int64) ([]Checklist, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT c.id, c.user_id, u.name, u.employee_code, c.template_id, c.kind, c.name, c.created_at
+		FROM employee_checklists c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.user_id = $1
+		ORDER BY c.created_at DESC, c.id DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := []Checklist{}
+	for rows.Next() {
+		var c Checklist
+		var templateID sql.NullInt64
+		if err := rows.Scan(&c.ID, &c.UserID, &c.EmployeeName, &c.EmployeeCode, &templateID, &c.Kind, &c.Name, &c.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if templateID.Valid {
+			id := templateID.Int64
+			c.TemplateID = &id
+		}
+		out = append(out, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range out {
+		if out[i].Tasks, err = r.queryTasks(ctx, `t.checklist_id = $1`, out[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// ==========================
+// Tasks
+// ==========================
+
+const taskSelect = `
+	SELECT t.id, t.checklist_id, c.user_id, u.name, u.employee_code, COALESCE(u.department, ''), c.kind,
+		t.title, COALESCE(t.description, ''), t.owner_role, t.due_date, t.completed_at, t.completed_by,
+		COALESCE(t.note, ''), (t.completed_at IS NULL AND t.due_date < CURRENT_DATE)
+	FROM employee_checklist_tasks t
+	JOIN employee_checklists c ON c.id = t.checklist_id
+	JOIN users u ON u.id = c.user_id
+`
+
+func (r *Repository) queryTasks(ctx context.Context, where string, args ...any) ([]Task, error) {
+	rows, err := r.db.QueryContext(ctx, taskSelect+` WHERE `+where+` ORDER BY t.due_date, t.sort_order, t.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []Task{}
+	for rows.Next() {
+		var t Task
+		var completedAt sql.NullTime
+		var completedBy sql.NullInt64
+		err := rows.Scan(&t.ID, &t.ChecklistID, &t.UserID, &t.EmployeeName, &t.EmployeeCode, &t.Department, &t.Kind,
+			&t.Title, &t.Description, &t.OwnerRole, &t.DueDate, &completedAt, &completedBy,
+			&t.Note, &t.Overdue)
+		if err != nil {
+			return nil, err
+		}
+		if completedAt.Valid {
+			t.CompletedAt = &completedAt.Time
+		}
+		if completedBy.Valid {
+			id := completedBy.Int64
+			t.CompletedBy = &id
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) FindTask(ctx context.Context, id int64) (*Task, error) {
+	list, err := r.queryTasks(ctx, `t.id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &list[0], nil
+}
+
+// ListOpenTasks returns uncompleted tasks matching the filter.
+func (r *Repository) ListOpenTasks(ctx context.Context, f TaskFilter) ([]Task, error) {
+	where := []string{"t.completed_at IS NULL"}
+	var args []any
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if len(f.Roles) > 0 {
+		add("t.owner_role = ANY($%d)", pq.Array(f.Roles))
+	}
+	if f.Department != "" {
+		add("u.department = $%d", f.Department)
+	}
+	if f.Kind != "" {
+		add("c.kind = $%d", f.Kind)
+	}
+	if f.OverdueOnly {
+		where = append(where, "t.due_date < CURRENT_DATE")
+	}
+	return r.queryTasks(ctx, strings.Join(where, " AND "), args...)
+}
+
+// CompleteTask marks an open task done; sql.ErrNoRows if it is already done.
+func (r *Repository) CompleteTask(ctx context.Context, id, userID int64, note string) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE employee_checklist_tasks
+		SET completed_at = NOW(), completed_by = $1, note = NULLIF($2, '')
+		WHERE id = $3 AND completed_at IS NULL
+	`, userID, note, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *Repository) ReopenTask(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE employee_checklist_tasks SET completed_at = NULL, completed_by = NULL WHERE id = $1
+	`, id)
+	return err
+}
+
+// UserExists reports whether an employee id exists.
+func (r *Repository) UserExists(ctx context.Context, userID int64) (bool, error) {
+	var ok bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&ok)
+	return ok, err
+}
@@ -0,0 +1,278 @@
+package checklist
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrNotFound         = errors.New("checklist not found")
+	ErrForbidden        = errors.New("task is owned by another role")
+	ErrAlreadyCompleted = errors.New("task is already completed")
+)
+
+// ValidationError is returned for invalid input; handler mengembalikan 400.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string { return e.Message }
+
+func invalid(msg string) error { return &ValidationError{Message: msg} }
+
+// ManagePermission lets HR complete any task and see every checklist.
+const ManagePermission = "MANAGE_EMPLOYEES"
+
+// PermissionChecker is satisfied by *rbac.Repository.
+type PermissionChecker interface {
+	HasAnyPermission(ctx context.Context, roles []string, codes ...string) (bool, error)
+}
+
+// Actor is the authenticated caller.
+type Actor struct {
+	UserID int64
+	Roles  []string
+}
+
+type Service struct {
+	repo  *Repository
+	perms PermissionChecker
+}
+
+func NewService(repo *Repository, perms PermissionChecker) *Service {
+	return &Service{repo: repo, perms: perms}
+}
+
+// mapError menerjemahkan error database ke error paket ini.
+func mapError(err error) error {
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		switch pqErr.Constraint {
+		case "checklist_templates_department_fkey":
+			return invalid("unknown department")
+		case "checklist_template_tasks_owner_role_fkey":
+			return invalid("unknown owner_role")
+		}
+	}
+	return err
+}
+
+// today returns the current date at midnight UTC, matching how DATE columns
+// are scanned.
+func today() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// ==========================
+// Lifecycle hooks (dipanggil dari user.Service dalam transaksinya)
+// ==========================
+
+// OnboardTx instantiates the onboarding templates for a new employee.
+func (s *Service) OnboardTx(ctx context.Context, tx *sql.Tx, userID int64, department string) error {
+	return s.instantiate(ctx, tx, KindOnboarding, userID, department)
+}
+
+// OffboardTx instantiates the offboarding templates for a leaving employee.
+func (s *Service) OffboardTx(ctx context.Context, tx *sql.Tx, userID int64, department string) error {
+	return s.instantiate(ctx, tx, KindOffboarding, userID, department)
+}
+
+func (s *Service) instantiate(ctx context.Context, tx *sql.Tx, kind string, userID int64, department string) error {
+	repo := s.repo.WithTx(tx)
+	templates, err := repo.MatchingTemplates(ctx, kind, department)
+	if err != nil {
+		return err
+	}
+	start := today()
+	for i := range templates {
+		if _, err := repo.CreateChecklist(ctx, userID, &templates[i], start); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ==========================
+// Templates
+// ==========================
+
+func (t *Template) sanitize() error {
+	t.Kind = strings.ToUpper(strings.TrimSpace(t.Kind))
+	t.Department = strings.ToUpper(strings.TrimSpace(t.Department))
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Kind != KindOnboarding && t.Kind != KindOffboarding {
+		return invalid("kind must be ONBOARDING or OFFBOARDING")
+	}
+	if t.Name == "" {
+		return invalid("name is required")
+	}
+	if len(t.Tasks) == 0 {
+		return invalid("at least one task is required")
+	}
+	for i := range t.Tasks {
+		task := &t.Tasks[i]
+		task.Title = strings.TrimSpace(task.Title)
+		task.Description = strings.TrimSpace(task.Description)
+		task.OwnerRole = strings.ToUpper(strings.TrimSpace(task.OwnerRole))
+		if task.Title == "" {
+			return invalid(fmt.Sprintf("task %d: title is required", i+1))
+		}
+		if task.OwnerRole == "" {
+			return invalid(fmt.Sprintf("task %d: owner_role is required", i+1))
+		}
+		if task.DueOffsetDays < -365 || task.DueOffsetDays > 365 {
+			return invalid(fmt.Sprintf("task %d: due_offset_days must be between -365 and 365", i+1))
+		}
+	}
+	return nil
+}
+
+func (s *Service) ListTemplates(ctx context.Context, kind string, includeInactive bool) ([]Template, error) {
+	return s.repo.ListTemplates(ctx, strings.ToUpper(strings.TrimSpace(kind)), includeInactive)
+}
+
+func (s *Service) GetTemplate(ctx context.Context, id int64) (*Template, error) {
+	t, err := s.repo.FindTemplate(ctx, id)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return t, nil
+}
+
+func (s *Service) CreateTemplate(ctx context.Context, t Template) (*Template, error) {
+	t.IsActive = true
+	if err := t.sanitize(); err != nil {
+		return nil, err
+	}
+	if err := s.saveTemplate(ctx, &t, false, nil); err != nil {
+		return nil, err
+	}
+	return s.GetTemplate(ctx, t.ID)
+}
+
+// UpdateTemplate replaces the template and its tasks; active nil = status
+// aktif tidak berubah. Checklist yang sudah dibuat tidak ikut berubah.
+func (s *Service) UpdateTemplate(ctx context.Context, id int64, t Template, active *bool) (*Template, error) {
+	t.ID = id
+	if err := t.sanitize(); err != nil {
+		return nil, err
+	}
+	if err := s.saveTemplate(ctx, &t, true, active); err != nil {
+		return nil, err
+	}
+	return s.GetTemplate(ctx, id)
+}
+
+func (s *Service) saveTemplate(ctx context.Context, t *Template, update bool, active *bool) error {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	if update {
+		err = repo.UpdateTemplate(ctx, t, active)
+	} else {
+		err = repo.CreateTemplate(ctx, t)
+	}
+	if err != nil {
+		return mapError(err)
+	}
+	return tx.Commit()
+}
+
+func (s *Service) DeactivateTemplate(ctx context.Context, id int64) error {
+	return mapError(s.repo.DeactivateTemplate(ctx, id))
+}
+
+// ==========================
+// Checklists & tasks
+// ==========================
+
+// ListForUser returns the onboarding/offboarding checklists of an employee.
+func (s *Service) ListForUser(ctx context.Context, userID int64) ([]Checklist, error) {
+	exists, err := s.repo.UserExists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return s.repo.ListChecklists(ctx, userID)
+}
+
+// MyTasks returns the open tasks owned by one of the actor's roles.
+func (s *Service) MyTasks(ctx context.Context, actor Actor) ([]Task, error) {
+	if len(actor.Roles) == 0 {
+		return []Task{}, nil
+	}
+	return s.repo.ListOpenTasks(ctx, TaskFilter{Roles: actor.Roles})
+}
+
+// Overdue is the overdue report: open tasks past their due date.
+func (s *Service) Overdue(ctx context.Context, f TaskFilter) ([]Task, error) {
+	f.OverdueOnly = true
+	f.Department = strings.ToUpper(strings.TrimSpace(f.Department))
+	f.Kind = strings.ToUpper(strings.TrimSpace(f.Kind))
+	return s.repo.ListOpenTasks(ctx, f)
+}
+
+// canWork: role pemilik task atau HR.
+func (s *Service) canWork(ctx context.Context, actor Actor, t *Task) error {
+	if slices.Contains(actor.Roles, t.OwnerRole) {
+		return nil
+	}
+	ok, err := s.perms.HasAnyPermission(ctx, actor.Roles, ManagePermission)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// CompleteTask marks a task done by the actor.
+func (s *Service) CompleteTask(ctx context.Context, actor Actor, id int64, note string) (*Task, error) {
+	t, err := s.repo.FindTask(ctx, id)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	if err := s.canWork(ctx, actor, t); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CompleteTask(ctx, id, actor.UserID, strings.TrimSpace(note)); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrAlreadyCompleted
+		}
+		return nil, err
+	}
+	return s.repo.FindTask(ctx, id)
+}
+
+// ReopenTask undoes a completion.
+func (s *Service) ReopenTask(ctx context.Context, actor Actor, id int64) (*Task, error) {
+	t, err := s.repo.FindTask(ctx, id)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	if err := s.canWork(ctx, actor, t); err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReopenTask(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.FindTask(ctx, id)
+}
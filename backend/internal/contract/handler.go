@@ -0,0 +1,195 @@
+package contract
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func toFiberError(err error, fallback string) error {
+	var vErr *ValidationError
+	switch {
+	case errors.As(err, &vErr):
+		return fiber.NewError(fiber.StatusBadRequest, vErr.Message)
+	case errors.Is(err, ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, ErrActiveContract), errors.Is(err, ErrNotActive), errors.Is(err, ErrNoProbation):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, fallback)
+}
+
+func parseID(c *fiber.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	return id, nil
+}
+
+func parseDays(c *fiber.Ctx) (int, error) {
+	days := c.QueryInt("days", 30)
+	if days < 0 || days > 366 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "days must be between 0 and 366")
+	}
+	return days, nil
+}
+
+// GET /api/me/contracts
+func (h *Handler) ListMine(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	list, err := h.svc.ListForUser(c.Context(), userID)
+	if err != nil {
+		return toFiberError(err, "failed to fetch contracts")
+	}
+	return c.JSON(list)
+}
+
+// GET /api/employees/:id/contracts
+func (h *Handler) ListForEmployee(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	list, err := h.svc.ListForUser(c.Context(), id)
+	if err != nil {
+		return toFiberError(err, "failed to fetch contracts")
+	}
+	return c.JSON(list)
+}
+
+// POST /api/employees/:id/contracts
+// body: {"contract_type":"PKWT","contract_number":"001/HR/2025","start_date":"2025-01-01","end_date":"2025-12-31","probation_end_date":"2025-03-31"}
+func (h *Handler) Create(c *fiber.Ctx) error {
+	actorID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	var in ContractInput
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	ct, err := h.svc.Create(c.Context(), actorID, id, in)
+	if err != nil {
+		return toFiberError(err, "failed to create contract")
+	}
+	return c.Status(fiber.StatusCreated).JSON(ct)
+}
+
+// GET /api/contracts/:id
+func (h *Handler) Get(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	ct, err := h.svc.Get(c.Context(), id)
+	if err != nil {
+		return toFiberError(err, "failed to fetch contract")
+	}
+	return c.JSON(ct)
+}
+
+// POST /api/contracts/:id/renew - body sama dengan create
+func (h *Handler) Renew(c *fiber.Ctx) error {
+	actorID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	var in ContractInput
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	ct, err := h.svc.Renew(c.Context(), actorID, id, in)
+	if err != nil {
+		return toFiberError(err, "failed to renew contract")
+	}
+	return c.Status(fiber.StatusCreated).JSON(ct)
+}
+
+// POST /api/contracts/:id/extend  body: {"end_date":"2026-06-30","reason":"..."}
+func (h *Handler) Extend(c *fiber.Ctx) error {
+	actorID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	var in ExtendInput
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	ct, err := h.svc.Extend(c.Context(), actorID, id, in)
+	if err != nil {
+		return toFiberError(err, "failed to extend contract")
+	}
+	return c.JSON(ct)
+}
+
+// POST /api/contracts/:id/probation  body: {"result":"PASSED|EXTENDED|FAILED","extend_to":"2025-05-31","notes":"..."}
+func (h *Handler) EvaluateProbation(c *fiber.Ctx) error {
+	actorID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	var in EvaluationInput
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	ct, err := h.svc.EvaluateProbation(c.Context(), actorID, id, in)
+	if err != nil {
+		return toFiberError(err, "failed to evaluate probation")
+	}
+	return c.JSON(ct)
+}
+
+// GET /api/contracts/ending?days=30
+func (h *Handler) ListEnding(c *fiber.Ctx) error {
+	days, err := parseDays(c)
+	if err != nil {
+		return err
+	}
+	list, err := h.svc.ListEnding(c.Context(), days)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch contracts")
+	}
+	return c.JSON(list)
+}
+
+// GET /api/contracts/probation-due?days=14
+func (h *Handler) ListProbationDue(c *fiber.Ctx) error {
+	days, err := parseDays(c)
+	if err != nil {
+		return err
+	}
+	list, err := h.svc.ListProbationDue(c.Context(), days)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch contracts")
+	}
+	return c.JSON(list)
+}
@@ -0,0 +1,88 @@
+package contract
+
+import "time"
+
+// Jenis kontrak kerja.
+const (
+	TypePKWT   = "PKWT"   // perjanjian kerja waktu tertentu
+	TypePKWTT  = "PKWTT"  // karyawan tetap, tanpa end date
+	TypeIntern = "INTERN" // magang
+)
+
+// Hasil evaluasi masa percobaan.
+const (
+	ProbationPending  = "PENDING"
+	ProbationPassed   = "PASSED"
+	ProbationExtended = "EXTENDED"
+	ProbationFailed   = "FAILED"
+)
+
+// Contract adalah row di tabel "employment_contracts".
+type Contract struct {
+	ID                 int64      `json:"id"`
+	UserID             int64      `json:"user_id"`
+	EmployeeName       string     `json:"employee_name"`
+	EmployeeCode       string     `json:"employee_code"`
+	ContractType       string     `json:"contract_type"`
+	ContractNumber     string     `json:"contract_number,omitempty"`
+	StartDate          time.Time  `json:"start_date"`
+	EndDate            *time.Time `json:"end_date,omitempty"`
+	ProbationEndDate   *time.Time `json:"probation_end_date,omitempty"`
+	ProbationStatus    string     `json:"probation_status,omitempty"` // PENDING, PASSED, FAILED
+	Status             string     `json:"status"`                     // ACTIVE, RENEWED, ENDED, TERMINATED
+	PreviousContractID *int64     `json:"previous_contract_id,omitempty"`
+	Notes              string     `json:"notes,omitempty"`
+	CreatedBy          *int64     `json:"created_by,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+
+	Extensions  []Extension  `json:"extensions,omitempty"`
+	Evaluations []Evaluation `json:"evaluations,omitempty"`
+}
+
+// Extension records one extension of a contract's end date.
+type Extension struct {
+	ID         int64     `json:"id"`
+	ContractID int64     `json:"contract_id"`
+	OldEndDate time.Time `json:"old_end_date"`
+	NewEndDate time.Time `json:"new_end_date"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedBy  *int64    `json:"created_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Evaluation is one probation review.
+type Evaluation struct {
+	ID          int64      `json:"id"`
+	ContractID  int64      `json:"contract_id"`
+	Result      string     `json:"result"`
+	OldEndDate  time.Time  `json:"old_end_date"`
+	NewEndDate  *time.Time `json:"new_end_date,omitempty"`
+	Notes       string     `json:"notes,omitempty"`
+	EvaluatedBy *int64     `json:"evaluated_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// ContractInput is used for a new contract and for a renewal.
+type ContractInput struct {
+	ContractType     string `json:"contract_type"`
+	ContractNumber   string `json:"contract_number"`
+	StartDate        string `json:"start_date"`         // YYYY-MM-DD
+	EndDate          string `json:"end_date"`           // wajib untuk PKWT/INTERN, kosong untuk PKWTT
+	ProbationEndDate string `json:"probation_end_date"` // opsional
+	Notes            string `json:"notes"`
+}
+
+// ExtendInput moves the end date of an active contract.
+type ExtendInput struct {
+	EndDate string `json:"end_date"`
+	Reason  string `json:"reason"`
+}
+
+// EvaluationInput is the outcome of a probation review. ExtendTo wajib
+// kalau Result = EXTENDED.
+type EvaluationInput struct {
+	Result   string `json:"result"`
+	ExtendTo string `json:"extend_to"`
+	Notes    string `json:"notes"`
+}
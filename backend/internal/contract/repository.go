@@ -0,0 +1,306 @@
+package contract
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// dbtx is satisfied by both *sql.DB and *sql.Tx.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type Repository struct {
+	db   dbtx
+	conn *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db, conn: db}
+}
+
+func (r *Repository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return r.conn.BeginTx(ctx, nil)
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx.
+func (r *Repository) WithTx(tx *sql.Tx) *Repository {
+	return &Repository{db: tx, conn: r.conn}
+}
+
+// ==========================
+// Contracts
+// ==========================
+
+const contractSelect = `
+	SELECT c.id, c.user_id, u.name, u.employee_code, c.contract_type, COALESCE(c.contract_number, ''),
+		c.start_date, c.end_date, c.probation_end_date, COALESCE(c.probation_status, ''), c.status,
+		c.previous_contract_id, COALESCE(c.notes, ''), c.created_by, c.created_at, c.updated_at
+	FROM employment_contracts c
+	JOIN users u ON u.id = c.user_id
+`
+
+func scanContract(row interface{ Scan(dest ...any) error }) (*Contract, error) {
+	var c Contract
+	var endDate, probationEnd sql.NullTime
+	var previousID, createdBy sql.NullInt64
+	err := row.Scan(&c.ID, &c.UserID, &c.EmployeeName, &c.EmployeeCode, &c.ContractType, &c.ContractNumber,
+		&c.StartDate, &endDate, &probationEnd, &c.ProbationStatus, &c.Status,
+		&previousID, &c.Notes, &createdBy, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if endDate.Valid {
+		c.EndDate = &endDate.Time
+	}
+	if probationEnd.Valid {
+		c.ProbationEndDate = &probationEnd.Time
+	}
+	if previousID.Valid {
+		id := previousID.Int64
+		c.PreviousContractID = &id
+	}
+	if createdBy.Valid {
+		id := createdBy.Int64
+		c.CreatedBy = &id
+	}
+	return &c, nil
+}
+
+func (r *Repository) queryContracts(ctx context.Context, tail string, args ...any) ([]Contract, error) {
+	rows, err := r.db.QueryContext(ctx, contractSelect+tail, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []Contract{}
+	for rows.Next() {
+		c, err := scanContract(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *c)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) Create(ctx context.Context, c *Contract) error {
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO employment_contracts
+			(user_id, contract_type, contract_number, start_date, end_date, probation_end_date,
+			 probation_status, previous_contract_id, notes, created_by)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, NULLIF($7, ''), $8, NULLIF($9, ''), $10)
+		RETURNING id
+	`, c.UserID, c.ContractType, c.ContractNumber, c.StartDate, c.EndDate, c.ProbationEndDate,
+		c.ProbationStatus, c.PreviousContractID, c.Notes, c.CreatedBy).Scan(&c.ID)
+}
+
+func (r *Repository) Find(ctx context.Context, id int64) (*Contract, error) {
+	return scanContract(r.db.QueryRowContext(ctx, contractSelect+` WHERE c.id = $1`, id))
+}
+
+// Lock locks the contract row for the rest of the transaction.
+func (r *Repository) Lock(ctx context.Context, id int64) error {
+	var x int64
+	return r.db.QueryRowContext(ctx, `SELECT id FROM employment_contracts WHERE id = $1 FOR UPDATE`, id).Scan(&x)
+}
+
+// ListByUser returns all contracts of an employee, newest first.
+func (r *Repository) ListByUser(ctx context.Context, userID int64) ([]Contract, error) {
+	return r.queryContracts(ctx, ` WHERE c.user_id = $1 ORDER BY c.start_date DESC, c.id DESC`, userID)
+}
+
+func (r *Repository) SetStatus(ctx context.Context, id int64, status string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE employment_contracts SET status = $1, updated_at = NOW() WHERE id = $2
+	`, status, id)
+	return err
+}
+
+// Terminate ends an active contract early on endDate.
+func (r *Repository) Terminate(ctx context.Context, id int64, endDate time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE employment_contracts SET status = 'TERMINATED', end_date = $1, updated_at = NOW() WHERE id = $2
+	`, endDate, id)
+	return err
+}
+
+// EndExpired marks active contracts whose end date has passed as ENDED.
+func (r *Repository) EndExpired(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE employment_contracts SET status = 'ENDED', updated_at = NOW()
+		WHERE status = 'ACTIVE' AND end_date < CURRENT_DATE
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ==========================
+// Extensions
+// ==========================
+
+// Extend moves the end date and records the extension.
+func (r *Repository) Extend(ctx context.Context, e *Extension) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE employment_contracts SET end_date = $1, updated_at = NOW() WHERE id = $2
+	`, e.NewEndDate, e.ContractID)
+	if err != nil {
+		return err
+	}
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO contract_extensions (contract_id, old_end_date, new_end_date, reason, created_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		RETURNING id, created_at
+	`, e.ContractID, e.OldEndDate, e.NewEndDate, e.Reason, e.CreatedBy).Scan(&e.ID, &e.CreatedAt)
+}
+
+func (r *Repository) ListExtensions(ctx context.Context, contractID int64) ([]Extension, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, contract_id, old_end_date, new_end_date, COALESCE(reason, ''), created_by, created_at
+		FROM contract_extensions
+		WHERE contract_id = $1
+		ORDER BY created_at, id
+	`, contractID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []Extension{}
+	for rows.Next() {
+		var e Extension
+		var createdBy sql.NullInt64
+		if err := rows.Scan(&e.ID, &e.ContractID, &e.OldEndDate, &e.NewEndDate, &e.Reason, &createdBy, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if createdBy.Valid {
+			id := createdBy.Int64
+			e.CreatedBy = &id
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// ==========================
+// Probation
+// ==========================
+
+// SetProbation updates the probation status and, when endDate is not nil,
+// the probation end date.
+func (r *Repository) SetProbation(ctx context.Context, id int64, status string, endDate *time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE employment_contracts
+		SET probation_status = $1, probation_end_date = COALESCE($2, probation_end_date), updated_at = NOW()
+		WHERE id = $3
+	`, status, endDate, id)
+	return err
+}
+
+func (r *Repository) InsertEvaluation(ctx context.Context, e *Evaluation) error {
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO probation_evaluations (contract_id, result, old_end_date, new_end_date, notes, evaluated_by)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		RETURNING id, created_at
+	`, e.ContractID, e.Result, e.OldEndDate, e.NewEndDate, e.Notes, e.EvaluatedBy).Scan(&e.ID, &e.CreatedAt)
+}
+
+func (r *Repository) ListEvaluations(ctx context.Context, contractID int64) ([]Evaluation, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, contract_id, result, old_end_date, new_end_date, COALESCE(notes, ''), evaluated_by, created_at
+		FROM probation_evaluations
+		WHERE contract_id = $1
+		ORDER BY created_at, id
+	`, contractID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []Evaluation{}
+	for rows.Next() {
+		var e Evaluation
+		var newEnd sql.NullTime
+		var by sql.NullInt64
+		if err := rows.Scan(&e.ID, &e.ContractID, &e.Result, &e.OldEndDate, &newEnd, &e.Notes, &by, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if newEnd.Valid {
+			e.NewEndDate = &newEnd.Time
+		}
+		if by.Valid {
+			id := by.Int64
+			e.EvaluatedBy = &id
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// ==========================
+// Due lists & reminders
+// ==========================
+
+// ListEnding returns active contracts ending within days.
+func (r *Repository) ListEnding(ctx context.Context, days int) ([]Contract, error) {
+	return r.queryContracts(ctx, `
+		WHERE c.status = 'ACTIVE' AND c.end_date <= CURRENT_DATE + $1::int
+		ORDER BY c.end_date, c.id
+	`, days)
+}
+
+// ListProbationDue returns active contracts whose probation ends within days
+// and has not been evaluated yet (overdue ones included).
+func (r *Repository) ListProbationDue(ctx context.Context, days int) ([]Contract, error) {
+	return r.queryContracts(ctx, `
+		WHERE c.status = 'ACTIVE' AND c.probation_status = 'PENDING'
+		  AND c.probation_end_date <= CURRENT_DATE + $1::int
+		ORDER BY c.probation_end_date, c.id
+	`, days)
+}
+
+// ClaimEndingUnalerted is ListEnding limited to contracts not yet reminded
+// for their current end date, locked for the transaction.
+func (r *Repository) ClaimEndingUnalerted(ctx context.Context, days int) ([]Contract, error) {
+	return r.queryContracts(ctx, `
+		WHERE c.status = 'ACTIVE' AND c.end_date <= CURRENT_DATE + $1::int
+		  AND c.end_alerted_for IS DISTINCT FROM c.end_date
+		ORDER BY c.end_date, c.id
+		FOR UPDATE OF c SKIP LOCKED
+	`, days)
+}
+
+// ClaimProbationUnalerted is ListProbationDue limited to contracts not yet
+// reminded for their current probation end date.
+func (r *Repository) ClaimProbationUnalerted(ctx context.Context, days int) ([]Contract, error) {
+	return r.queryContracts(ctx, `
+		WHERE c.status = 'ACTIVE' AND c.probation_status = 'PENDING'
+		  AND c.probation_end_date <= CURRENT_DATE + $1::int
+		  AND c.probation_alerted_for IS DISTINCT FROM c.probation_end_date
+		ORDER BY c.probation_end_date, c.id
+		FOR UPDATE OF c SKIP LOCKED
+	`, days)
+}
+
+func (r *Repository) MarkEndAlerted(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE employment_contracts SET end_alerted_for = end_date WHERE id = $1`, id)
+	return err
+}
+
+func (r *Repository) MarkProbationAlerted(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE employment_contracts SET probation_alerted_for = probation_end_date WHERE id = $1`, id)
+	return err
+}
+
+// UserExists reports whether an employee id exists.
+func (r *Repository) UserExists(ctx context.Context, userID int64) (bool, error) {
+	var ok bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&ok)
+	return ok, err
+}
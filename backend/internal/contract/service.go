@@ -0,0 +1,471 @@
+package contract
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"hr-portal-backend/internal/mail"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrNotFound       = errors.New("contract not found")
+	ErrActiveContract = errors.New("employee already has an active contract")
+	ErrNotActive      = errors.New("contract is not active")
+	ErrNoProbation    = errors.New("contract has no pending probation")
+)
+
+// ValidationError is returned for invalid input; handler mengembalikan 400.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string { return e.Message }
+
+func invalid(msg string) error { return &ValidationError{Message: msg} }
+
+// Employees updates the employee record from a probation outcome inside the
+// caller's transaction. Diimplementasikan oleh *user.Service.
+type Employees interface {
+	SetEmploymentStatusTx(ctx context.Context, tx *sql.Tx, actorID, userID int64, employmentStatus, reason string, deactivate bool) error
+}
+
+// Notifier queues an email inside the caller's transaction.
+type Notifier interface {
+	NotifyPermissionTx(ctx context.Context, tx *sql.Tx, n mail.Notification, permissions ...string) (int, error)
+}
+
+// Nilai users.employment_status.
+const (
+	EmploymentProbation = "PROBATION"
+	EmploymentConfirmed = "CONFIRMED"
+)
+
+// reminderPermission: penerima reminder kontrak & masa percobaan.
+const reminderPermission = "MANAGE_EMPLOYEES"
+
+type Service struct {
+	repo      *Repository
+	employees Employees
+	notifier  Notifier
+}
+
+func NewService(repo *Repository, employees Employees, notifier Notifier) *Service {
+	return &Service{repo: repo, employees: employees, notifier: notifier}
+}
+
+// mapError menerjemahkan error database ke error paket ini.
+func mapError(err error) error {
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrActiveContract
+	}
+	return err
+}
+
+// today returns the current date at midnight UTC, matching how DATE columns
+// are scanned.
+func today() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func parseDate(field, v string, required bool) (*time.Time, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		if required {
+			return nil, invalid(field + " is required")
+		}
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return nil, invalid("invalid " + field + " (YYYY-MM-DD)")
+	}
+	return &t, nil
+}
+
+// build validates the input and returns an unsaved contract.
+func build(userID int64, in ContractInput) (*Contract, error) {
+	c := &Contract{
+		UserID:         userID,
+		ContractType:   strings.ToUpper(strings.TrimSpace(in.ContractType)),
+		ContractNumber: strings.TrimSpace(in.ContractNumber),
+		Notes:          strings.TrimSpace(in.Notes),
+		Status:         "ACTIVE",
+	}
+	switch c.ContractType {
+	case TypePKWT, TypePKWTT, TypeIntern:
+	default:
+		return nil, invalid("contract_type must be PKWT, PKWTT or INTERN")
+	}
+
+	start, err := parseDate("start_date", in.StartDate, true)
+	if err != nil {
+		return nil, err
+	}
+	c.StartDate = *start
+	// PKWTT tidak punya tanggal berakhir; PKWT dan magang wajib.
+	if c.EndDate, err = parseDate("end_date", in.EndDate, c.ContractType != TypePKWTT); err != nil {
+		return nil, err
+	}
+	if c.ContractType == TypePKWTT && c.EndDate != nil {
+		return nil, invalid("end_date must be empty for PKWTT")
+	}
+	if c.EndDate != nil && !c.EndDate.After(c.StartDate) {
+		return nil, invalid("end_date must be after start_date")
+	}
+
+	if c.ProbationEndDate, err = parseDate("probation_end_date", in.ProbationEndDate, false); err != nil {
+		return nil, err
+	}
+	if p := c.ProbationEndDate; p != nil {
+		if !p.After(c.StartDate) {
+			return nil, invalid("probation_end_date must be after start_date")
+		}
+		if c.EndDate != nil && p.After(*c.EndDate) {
+			return nil, invalid("probation_end_date must not be after end_date")
+		}
+		c.ProbationStatus = ProbationPending
+	}
+	return c, nil
+}
+
+// Get returns a contract with its extension and probation history.
+func (s *Service) Get(ctx context.Context, id int64) (*Contract, error) {
+	c, err := s.repo.Find(ctx, id)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	if c.Extensions, err = s.repo.ListExtensions(ctx, id); err != nil {
+		return nil, err
+	}
+	if c.Evaluations, err = s.repo.ListEvaluations(ctx, id); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ListForUser returns all contracts of an employee, newest first; renewals
+// link to the previous contract via previous_contract_id.
+func (s *Service) ListForUser(ctx context.Context, userID int64) ([]Contract, error) {
+	exists, err := s.repo.UserExists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+	list, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if list[i].Extensions, err = s.repo.ListExtensions(ctx, list[i].ID); err != nil {
+			return nil, err
+		}
+		if list[i].Evaluations, err = s.repo.ListEvaluations(ctx, list[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// Create adds the first (or a fresh) contract of an employee. Karyawan yang
+// masih punya kontrak aktif harus lewat Renew.
+func (s *Service) Create(ctx context.Context, actorID, userID int64, in ContractInput) (*Contract, error) {
+	c, err := build(userID, in)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := s.repo.UserExists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+	c.CreatedBy = &actorID
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.insert(ctx, tx, actorID, c); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, c.ID)
+}
+
+// insert saves c and syncs the employee's employment status.
+func (s *Service) insert(ctx context.Context, tx *sql.Tx, actorID int64, c *Contract) error {
+	if err := s.repo.WithTx(tx).Create(ctx, c); err != nil {
+		return mapError(err)
+	}
+	status := EmploymentConfirmed
+	if c.ProbationStatus == ProbationPending {
+		status = EmploymentProbation
+	}
+	return s.employees.SetEmploymentStatusTx(ctx, tx, actorID, c.UserID, status, "", false)
+}
+
+// Renew replaces an active contract with a new one (mis. PKWT kedua, atau
+// diangkat menjadi PKWTT). The old contract becomes RENEWED.
+func (s *Service) Renew(ctx context.Context, actorID, id int64, in ContractInput) (*Contract, error) {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	if err := repo.Lock(ctx, id); err != nil {
+		return nil, mapError(err)
+	}
+	old, err := repo.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if old.Status != "ACTIVE" {
+		return nil, ErrNotActive
+	}
+
+	c, err := build(old.UserID, in)
+	if err != nil {
+		return nil, err
+	}
+	if !c.StartDate.After(old.StartDate) {
+		return nil, invalid("start_date must be after the start of the previous contract")
+	}
+	c.PreviousContractID = &old.ID
+	c.CreatedBy = &actorID
+
+	if err := repo.SetStatus(ctx, old.ID, "RENEWED"); err != nil {
+		return nil, err
+	}
+	if err := s.insert(ctx, tx, actorID, c); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, c.ID)
+}
+
+// Extend moves the end date of an active fixed-term contract.
+func (s *Service) Extend(ctx context.Context, actorID, id int64, in ExtendInput) (*Contract, error) {
+	newEnd, err := parseDate("end_date", in.EndDate, true)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	if err := repo.Lock(ctx, id); err != nil {
+		return nil, mapError(err)
+	}
+	c, err := repo.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.Status != "ACTIVE" {
+		return nil, ErrNotActive
+	}
+	if c.EndDate == nil {
+		return nil, invalid("contract has no end date to extend")
+	}
+	if !newEnd.After(*c.EndDate) {
+		return nil, invalid("end_date must be after the current end date")
+	}
+
+	e := &Extension{
+		ContractID: id,
+		OldEndDate: *c.EndDate,
+		NewEndDate: *newEnd,
+		Reason:     strings.TrimSpace(in.Reason),
+		CreatedBy:  &actorID,
+	}
+	if err := repo.Extend(ctx, e); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+// EvaluateProbation records the probation outcome:
+//   - PASSED: employee becomes CONFIRMED
+//   - EXTENDED: probation end moves to ExtendTo, tetap PROBATION
+//   - FAILED: contract is terminated today and the employee set TERMINATED
+func (s *Service) EvaluateProbation(ctx context.Context, actorID, id int64, in EvaluationInput) (*Contract, error) {
+	result := strings.ToUpper(strings.TrimSpace(in.Result))
+	var extendTo *time.Time
+	switch result {
+	case ProbationPassed, ProbationFailed:
+	case ProbationExtended:
+		var err error
+		if extendTo, err = parseDate("extend_to", in.ExtendTo, true); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, invalid("result must be PASSED, EXTENDED or FAILED")
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	if err := repo.Lock(ctx, id); err != nil {
+		return nil, mapError(err)
+	}
+	c, err := repo.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.Status != "ACTIVE" {
+		return nil, ErrNotActive
+	}
+	if c.ProbationStatus != ProbationPending || c.ProbationEndDate == nil {
+		return nil, ErrNoProbation
+	}
+	if extendTo != nil {
+		if !extendTo.After(*c.ProbationEndDate) {
+			return nil, invalid("extend_to must be after the current probation end date")
+		}
+		if c.EndDate != nil && extendTo.After(*c.EndDate) {
+			return nil, invalid("extend_to must not be after the contract end date")
+		}
+	}
+
+	e := &Evaluation{
+		ContractID:  id,
+		Result:      result,
+		OldEndDate:  *c.ProbationEndDate,
+		NewEndDate:  extendTo,
+		Notes:       strings.TrimSpace(in.Notes),
+		EvaluatedBy: &actorID,
+	}
+	if err := repo.InsertEvaluation(ctx, e); err != nil {
+		return nil, err
+	}
+
+	switch result {
+	case ProbationPassed:
+		err = repo.SetProbation(ctx, id, ProbationPassed, nil)
+		if err == nil {
+			err = s.employees.SetEmploymentStatusTx(ctx, tx, actorID, c.UserID, EmploymentConfirmed, "", false)
+		}
+	case ProbationExtended:
+		err = repo.SetProbation(ctx, id, ProbationPending, extendTo)
+	case ProbationFailed:
+		err = repo.SetProbation(ctx, id, ProbationFailed, nil)
+		if err == nil {
+			err = repo.Terminate(ctx, id, today())
+		}
+		if err == nil {
+			err = s.employees.SetEmploymentStatusTx(ctx, tx, actorID, c.UserID, "", "Tidak lulus masa percobaan", true)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+// ListEnding returns active contracts ending within days (already past
+// included until the daily job marks them ENDED).
+func (s *Service) ListEnding(ctx context.Context, days int) ([]Contract, error) {
+	return s.repo.ListEnding(ctx, days)
+}
+
+// ListProbationDue returns probations ending within days that still need
+// an evaluation.
+func (s *Service) ListProbationDue(ctx context.Context, days int) ([]Contract, error) {
+	return s.repo.ListProbationDue(ctx, days)
+}
+
+// RunDaily marks expired contracts as ENDED and emails HR one digest of
+// contracts ending and probations due within days that were not reminded
+// yet. Dijalankan harian lewat scheduler.Every.
+func (s *Service) RunDaily(ctx context.Context, days int) error {
+	if _, err := s.repo.EndExpired(ctx); err != nil {
+		return err
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	ending, err := repo.ClaimEndingUnalerted(ctx, days)
+	if err != nil {
+		return err
+	}
+	probations, err := repo.ClaimProbationUnalerted(ctx, days)
+	if err != nil {
+		return err
+	}
+	if len(ending) == 0 && len(probations) == 0 {
+		return nil
+	}
+	data := map[string]any{
+		"Days":       days,
+		"Contracts":  digestRows(ending, func(c Contract) *time.Time { return c.EndDate }),
+		"Probations": digestRows(probations, func(c Contract) *time.Time { return c.ProbationEndDate }),
+	}
+	sent, err := s.notifier.NotifyPermissionTx(ctx, tx, mail.Notification{Event: mail.EventContractsDue, Data: data}, reminderPermission)
+	if err != nil || sent == 0 {
+		return err // belum ada penerima: jangan tandai, dicoba lagi besok
+	}
+	for _, c := range ending {
+		if err := repo.MarkEndAlerted(ctx, c.ID); err != nil {
+			return err
+		}
+	}
+	for _, c := range probations {
+		if err := repo.MarkProbationAlerted(ctx, c.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func digestRows(list []Contract, date func(Contract) *time.Time) []map[string]any {
+	rows := make([]map[string]any, 0, len(list))
+	for _, c := range list {
+		rows = append(rows, map[string]any{
+			"EmployeeCode":   c.EmployeeCode,
+			"EmployeeName":   c.EmployeeName,
+			"ContractType":   c.ContractType,
+			"ContractNumber": c.ContractNumber,
+			"Date":           date(c).Format("2006-01-02"),
+		})
+	}
+	return rows
+}
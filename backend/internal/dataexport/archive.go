@@ -0,0 +1,121 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// buildArchive writes the sections as <name>.json plus one personal_data.xlsx
+// into a ZIP.
+func buildArchive(sections []Section, generatedAt time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	for _, s := range sections {
+		data, err := sectionJSON(s)
+		if err != nil {
+			return nil, err
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: s.Name + ".json", Method: zip.Deflate, Modified: generatedAt})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+	}
+
+	xlsx, err := sectionsXLSX(sections)
+	if err != nil {
+		return nil, err
+	}
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "personal_data.xlsx", Method: zip.Deflate, Modified: generatedAt})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(xlsx); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sectionJSON renders rows as objects keyed by column; profile jadi satu
+// object, bukan array.
+func sectionJSON(s Section) ([]byte, error) {
+	objects := make([]map[string]any, len(s.Rows))
+	for i, row := range s.Rows {
+		obj := make(map[string]any, len(s.Columns))
+		for j, col := range s.Columns {
+			obj[col] = row[j]
+		}
+		objects[i] = obj
+	}
+	if s.Name == "profile" && len(objects) == 1 {
+		return json.MarshalIndent(objects[0], "", "  ")
+	}
+	return json.MarshalIndent(objects, "", "  ")
+}
+
+func cellValue(v any) any {
+	t, ok := v.(time.Time)
+	if !ok {
+		return v
+	}
+	// Kolom DATE di-scan sebagai tengah malam UTC.
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+func sectionsXLSX(sections []Section) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Size: 11},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#E5E7EB"}, Pattern: 1},
+	})
+
+	for _, s := range sections {
+		if _, err := f.NewSheet(s.Name); err != nil {
+			return nil, err
+		}
+		for i, col := range s.Columns {
+			cell, err := excelize.CoordinatesToCellName(i+1, 1)
+			if err != nil {
+				return nil, err
+			}
+			f.SetCellValue(s.Name, cell, col)
+			f.SetCellStyle(s.Name, cell, cell, headerStyle)
+		}
+		for r, row := range s.Rows {
+			for i, v := range row {
+				cell, err := excelize.CoordinatesToCellName(i+1, r+2)
+				if err != nil {
+					return nil, err
+				}
+				f.SetCellValue(s.Name, cell, cellValue(v))
+			}
+		}
+		if len(s.Columns) > 0 {
+			last, _ := excelize.ColumnNumberToName(len(s.Columns))
+			f.SetColWidth(s.Name, "A", last, 20)
+		}
+	}
+	f.DeleteSheet("Sheet1")
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
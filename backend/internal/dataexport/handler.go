@@ -0,0 +1,102 @@
+package dataexport
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func toFiberError(err error, fallback string) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, ErrNotReady):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, ErrExpired):
+		return fiber.NewError(fiber.StatusGone, err.Error())
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, fallback)
+}
+
+func parseID(c *fiber.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	return id, nil
+}
+
+// POST /api/me/export - antrikan export data pribadi (202, diproses di background)
+func (h *Handler) Request(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	j, created, err := h.svc.Request(c.Context(), userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to queue export")
+	}
+	if !created {
+		return c.JSON(j)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(j)
+}
+
+// GET /api/me/export - riwayat export saya
+func (h *Handler) List(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	list, err := h.svc.List(c.Context(), userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch exports")
+	}
+	return c.JSON(list)
+}
+
+// GET /api/me/export/:id - status job; download_url terisi saat DONE
+func (h *Handler) Get(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	j, err := h.svc.Get(c.Context(), userID, id)
+	if err != nil {
+		return toFiberError(err, "failed to fetch export")
+	}
+	return c.JSON(j)
+}
+
+// GET /api/me/export/:id/download
+func (h *Handler) Download(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	rc, j, err := h.svc.Open(c.Context(), userID, id)
+	if err != nil {
+		return toFiberError(err, "failed to download export")
+	}
+	c.Set("Content-Type", "application/zip")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("personal_data_%s.zip", j.CreatedAt.Format("20060102"))))
+	return c.SendStream(rc, int(j.FileSize))
+}
@@ -0,0 +1,36 @@
+package dataexport
+
+import "time"
+
+// Status job export.
+const (
+	StatusPending = "PENDING"
+	StatusRunning = "RUNNING"
+	StatusDone    = "DONE"
+	StatusFailed  = "FAILED"
+	StatusExpired = "EXPIRED" // file sudah dihapus setelah masa berlaku
+)
+
+// Job adalah row di tabel "data_export_jobs".
+type Job struct {
+	ID          int64      `json:"id"`
+	UserID      int64      `json:"user_id"`
+	Status      string     `json:"status"`
+	FileSize    int64      `json:"file_size,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"`
+
+	storageKey string
+}
+
+// Section is one part of the export, written as <name>.json and as a sheet
+// of personal_data.xlsx.
+type Section struct {
+	Name    string
+	Columns []string
+	Rows    [][]any
+}
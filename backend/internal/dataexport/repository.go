@@ -0,0 +1,264 @@
+package dataexport
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+const jobSelectColumns = `id, user_id, status, COALESCE(file_size, 0), COALESCE(error, ''),
+	created_at, started_at, finished_at, expires_at, COALESCE(storage_key, '')`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanJob(row scanner) (*Job, error) {
+	var j Job
+	var started, finished, expires sql.NullTime
+	err := row.Scan(&j.ID, &j.UserID, &j.Status, &j.FileSize, &j.Error,
+		&j.CreatedAt, &started, &finished, &expires, &j.storageKey)
+	if err != nil {
+		return nil, err
+	}
+	if started.Valid {
+		j.StartedAt = &started.Time
+	}
+	if finished.Valid {
+		j.FinishedAt = &finished.Time
+	}
+	if expires.Valid {
+		j.ExpiresAt = &expires.Time
+	}
+	return &j, nil
+}
+
+func (r *Repository) queryJobs(ctx context.Context, q string, args ...any) ([]*Job, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, j)
+	}
+	return out, rows.Err()
+}
+
+// CreateJob queues a new export unless the user already has one pending or
+// running; in that case the existing job is returned with created = false.
+func (r *Repository) CreateJob(ctx context.Context, userID int64) (j *Job, created bool, err error) {
+	j, err = scanJob(r.db.QueryRowContext(ctx, `
+		INSERT INTO data_export_jobs (user_id) VALUES ($1)
+		ON CONFLICT (user_id) WHERE status IN ('PENDING', 'RUNNING') DO NOTHING
+		RETURNING `+jobSelectColumns, userID))
+	if err != sql.ErrNoRows {
+		return j, err == nil, err
+	}
+	j, err = scanJob(r.db.QueryRowContext(ctx, `
+		SELECT `+jobSelectColumns+` FROM data_export_jobs
+		WHERE user_id = $1 AND status IN ('PENDING', 'RUNNING')
+	`, userID))
+	return j, false, err
+}
+
+func (r *Repository) FindJob(ctx context.Context, id int64) (*Job, error) {
+	return scanJob(r.db.QueryRowContext(ctx, `SELECT `+jobSelectColumns+` FROM data_export_jobs WHERE id = $1`, id))
+}
+
+func (r *Repository) ListJobs(ctx context.Context, userID int64) ([]*Job, error) {
+	return r.queryJobs(ctx, `
+		SELECT `+jobSelectColumns+` FROM data_export_jobs
+		WHERE user_id = $1
+		ORDER BY id DESC
+		LIMIT 20
+	`, userID)
+}
+
+// ClaimNext moves the oldest pending job to RUNNING. Job RUNNING yang lewat
+// lease dianggap macet (server restart) dan diambil ulang.
+func (r *Repository) ClaimNext(ctx context.Context, lease time.Duration) (*Job, error) {
+	return scanJob(r.db.QueryRowContext(ctx, `
+		UPDATE data_export_jobs
+		SET status = 'RUNNING', started_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM data_export_jobs
+			WHERE status = 'PENDING'
+			   OR (status = 'RUNNING' AND started_at < CURRENT_TIMESTAMP - ($1 * INTERVAL '1 second'))
+			ORDER BY id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobSelectColumns, int(lease.Seconds())))
+}
+
+func (r *Repository) MarkDone(ctx context.Context, id int64, key string, size int64, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE data_export_jobs
+		SET status = 'DONE', storage_key = $1, file_size = $2, expires_at = $3,
+			finished_at = CURRENT_TIMESTAMP, error = NULL
+		WHERE id = $4
+	`, key, size, expiresAt, id)
+	return err
+}
+
+func (r *Repository) MarkFailed(ctx context.Context, id int64, jobErr string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE data_export_jobs
+		SET status = 'FAILED', error = $1, finished_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`, jobErr, id)
+	return err
+}
+
+// ListExpired returns finished exports whose download window has passed.
+func (r *Repository) ListExpired(ctx context.Context) ([]*Job, error) {
+	return r.queryJobs(ctx, `
+		SELECT `+jobSelectColumns+` FROM data_export_jobs
+		WHERE status = 'DONE' AND expires_at < CURRENT_TIMESTAMP
+		ORDER BY id
+	`)
+}
+
+func (r *Repository) MarkExpired(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE data_export_jobs SET status = 'EXPIRED', storage_key = NULL WHERE id = $1
+	`, id)
+	return err
+}
+
+// ==========================
+// Data karyawan
+// ==========================
+
+// sectionQueries lists what goes into the export, in order. Setiap query
+// menerima $1 = user id. profile.custom_fields disaring service sesuai
+// visible_roles sebelum ditulis.
+var sectionQueries = []struct {
+	name  string
+	query string
+}{
+	{"profile", `
+		SELECT u.employee_code, u.name, u.email, u.department, u.branch, u.job_title, u.status,
+			u.employment_status, u.join_date, u.birth_date, u.gender, u.phone, u.address,
+			u.emergency_contact, u.emergency_phone, m.name AS manager,
+			array_to_string(u.roles, ', ') AS roles, u.custom_fields::text AS custom_fields
+		FROM users u
+		LEFT JOIN users m ON m.id = u.manager_id
+		WHERE u.id = $1`},
+	{"employment_history", `
+		SELECT h.effective_date, h.department, h.job_title, h.branch, h.status, h.reason,
+			h.applied_at, c.name AS changed_by
+		FROM employment_history h
+		LEFT JOIN users c ON c.id = h.changed_by
+		WHERE h.user_id = $1
+		ORDER BY h.effective_date, h.id`},
+	// NIK tanggungan terenkripsi, tidak ikut diekspor (sama seperti data payroll).
+	{"dependents", `
+		SELECT full_name, relationship, gender, birth_date, health_coverage, tax_dependent
+		FROM employee_dependents
+		WHERE user_id = $1
+		ORDER BY id`},
+	{"skills", `
+		SELECT s.name AS skill, s.category, es.level, es.years_experience, es.notes, es.updated_at
+		FROM employee_skills es
+		JOIN skills s ON s.id = es.skill_id
+		WHERE es.user_id = $1
+		ORDER BY s.name`},
+	{"certifications", `
+		SELECT c.kind, c.name, c.issuer, c.credential_number, s.name AS skill, c.issue_date, c.expiry_date
+		FROM employee_certifications c
+		LEFT JOIN skills s ON s.id = c.skill_id
+		WHERE c.user_id = $1
+		ORDER BY c.id`},
+	{"celebration_preferences", `
+		SELECT hide_birthday, hide_anniversary, updated_at
+		FROM celebration_preferences
+		WHERE user_id = $1`},
+	{"attendance", `
+		SELECT date, checkin_time, checkout_time, status
+		FROM attendance
+		WHERE user_id = $1
+		ORDER BY date`},
+	{"requests", `
+		SELECT r.id, r.type, r.start_date, r.end_date, r.reason, r.status,
+			a.name AS approver, r.rejection_reason, r.created_at, r.updated_at
+		FROM requests r
+		LEFT JOIN users a ON a.id = r.approver_id
+		WHERE r.user_id = $1
+		ORDER BY r.id`},
+	{"messages", `
+		SELECT m.id,
+			CASE WHEN m.sender_id = $1 THEN 'SENT' ELSE 'RECEIVED' END AS direction,
+			CASE WHEN m.sender_id = $1 THEN r.name ELSE s.name END AS counterpart,
+			m.subject, m.body, m.is_read, m.created_at
+		FROM messages m
+		LEFT JOIN users s ON s.id = m.sender_id
+		LEFT JOIN users r ON r.id = m.receiver_id
+		WHERE m.sender_id = $1 OR m.receiver_id = $1
+		ORDER BY m.id`},
+	{"announcement_reads", `
+		SELECT a.id AS announcement_id, a.title, ar.read_at
+		FROM announcement_reads ar
+		JOIN announcements a ON a.id = ar.announcement_id
+		WHERE ar.user_id = $1
+		ORDER BY ar.read_at`},
+}
+
+// Sections runs every section query for the user.
+func (r *Repository) Sections(ctx context.Context, userID int64) ([]Section, error) {
+	out := make([]Section, 0, len(sectionQueries))
+	for _, sq := range sectionQueries {
+		s, err := r.section(ctx, sq.name, sq.query, userID)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *s)
+	}
+	return out, nil
+}
+
+func (r *Repository) section(ctx context.Context, name, query string, userID int64) (*Section, error) {
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	s := &Section{Name: name, Columns: cols, Rows: [][]any{}}
+	for rows.Next() {
+		vals := make([]any, len(cols))
+		ptrs := make([]any, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		for i, v := range vals {
+			if b, ok := v.([]byte); ok {
+				vals[i] = string(b)
+			}
+		}
+		s.Rows = append(s.Rows, vals)
+	}
+	return s, rows.Err()
+}
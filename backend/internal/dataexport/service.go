@@ -0,0 +1,198 @@
+package dataexport
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"time"
+
+	"hr-portal-backend/pkg/storage"
+)
+
+var (
+	ErrNotFound = errors.New("export not found")
+	ErrNotReady = errors.New("export is not ready yet")
+	ErrExpired  = errors.New("export has expired, request a new one")
+)
+
+// Batas waktu satu job RUNNING sebelum dianggap macet.
+const runLease = 30 * time.Minute
+
+// CustomFields is satisfied by *user.Service.
+type CustomFields interface {
+	VisibleCustomFields(ctx context.Context, userID int64) (map[string]any, error)
+}
+
+type Service struct {
+	repo   *Repository
+	store  storage.Storage
+	fields CustomFields
+	ttl    time.Duration // berapa lama ZIP bisa diunduh
+}
+
+func NewService(repo *Repository, store storage.Storage, fields CustomFields, ttl time.Duration) *Service {
+	return &Service{repo: repo, store: store, fields: fields, ttl: ttl}
+}
+
+func storageKey(j *Job) string {
+	return fmt.Sprintf("exports/%d/%d.zip", j.UserID, j.ID)
+}
+
+func withLink(j *Job) *Job {
+	if j.Status == StatusDone {
+		j.DownloadURL = fmt.Sprintf("/api/me/export/%d/download", j.ID)
+	}
+	return j
+}
+
+// Request queues an export of the user's own data. created = false berarti
+// masih ada job yang berjalan dan job itu yang dikembalikan.
+func (s *Service) Request(ctx context.Context, userID int64) (*Job, bool, error) {
+	j, created, err := s.repo.CreateJob(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	return withLink(j), created, nil
+}
+
+func (s *Service) List(ctx context.Context, userID int64) ([]*Job, error) {
+	list, err := s.repo.ListJobs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, j := range list {
+		withLink(j)
+	}
+	if list == nil {
+		list = []*Job{}
+	}
+	return list, nil
+}
+
+// Get returns one of the user's jobs; job orang lain dianggap tidak ada.
+func (s *Service) Get(ctx context.Context, userID, id int64) (*Job, error) {
+	j, err := s.repo.FindJob(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if j.UserID != userID {
+		return nil, ErrNotFound
+	}
+	return withLink(j), nil
+}
+
+// Open streams the finished ZIP.
+func (s *Service) Open(ctx context.Context, userID, id int64) (io.ReadCloser, *Job, error) {
+	j, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case j.Status == StatusExpired, j.Status == StatusDone && j.ExpiresAt != nil && j.ExpiresAt.Before(time.Now()):
+		return nil, nil, ErrExpired
+	case j.Status != StatusDone:
+		return nil, nil, ErrNotReady
+	}
+	rc, err := s.store.Open(ctx, j.storageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, ErrExpired
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return rc, j, nil
+}
+
+// ==========================
+// Worker
+// ==========================
+
+// ProcessPending builds every queued export, then removes expired files.
+// Dijalankan scheduler "data-exports".
+func (s *Service) ProcessPending(ctx context.Context) error {
+	for {
+		j, err := s.repo.ClaimNext(ctx, runLease)
+		if err == sql.ErrNoRows {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.run(ctx, j); err != nil {
+			log.Printf("data export %d failed: %v", j.ID, err)
+			if err := s.repo.MarkFailed(ctx, j.ID, err.Error()); err != nil {
+				return err
+			}
+		}
+	}
+	return s.removeExpired(ctx)
+}
+
+func (s *Service) run(ctx context.Context, j *Job) error {
+	sections, err := s.repo.Sections(ctx, j.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.filterCustomFields(ctx, j.UserID, sections); err != nil {
+		return err
+	}
+	now := time.Now()
+	data, err := buildArchive(sections, now)
+	if err != nil {
+		return err
+	}
+	key := storageKey(j)
+	if err := s.store.Put(ctx, key, bytes.NewReader(data)); err != nil {
+		return err
+	}
+	return s.repo.MarkDone(ctx, j.ID, key, int64(len(data)), now.Add(s.ttl))
+}
+
+// filterCustomFields replaces profile.custom_fields with only the fields the
+// employee may see; field dengan visible_roles lain tidak ikut diekspor.
+func (s *Service) filterCustomFields(ctx context.Context, userID int64, sections []Section) error {
+	for i := range sections {
+		sec := &sections[i]
+		col := slices.Index(sec.Columns, "custom_fields")
+		if sec.Name != "profile" || col < 0 {
+			continue
+		}
+		values, err := s.fields.VisibleCustomFields(ctx, userID)
+		if err != nil {
+			return err
+		}
+		b, err := json.Marshal(values)
+		if err != nil {
+			return err
+		}
+		for _, row := range sec.Rows {
+			row[col] = string(b)
+		}
+	}
+	return nil
+}
+
+func (s *Service) removeExpired(ctx context.Context) error {
+	list, err := s.repo.ListExpired(ctx)
+	if err != nil {
+		return err
+	}
+	for _, j := range list {
+		if err := s.store.Delete(ctx, j.storageKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+		if err := s.repo.MarkExpired(ctx, j.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
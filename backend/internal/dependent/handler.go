@@ -0,0 +1,256 @@
+package dependent
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func toFiberError(err error, fallback string) error {
+	var vErr *ValidationError
+	switch {
+	case errors.As(err, &vErr):
+		return fiber.NewError(fiber.StatusBadRequest, vErr.Message)
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrUserNotFound), errors.Is(err, ErrChangeNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, ErrChangeNotPending), errors.Is(err, ErrStale):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, ErrReviewOwnChange):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, ErrNotConfigured):
+		return fiber.NewError(fiber.StatusServiceUnavailable, err.Error())
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, fallback)
+}
+
+func parseID(c *fiber.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	return id, nil
+}
+
+func parseInput(c *fiber.Ctx) (*Input, error) {
+	var in Input
+	if err := c.BodyParser(&in); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	return &in, nil
+}
+
+// ==========================
+// Self-service
+// ==========================
+
+// GET /api/me/dependents - tanggungan saya, pengajuan pending dan status PTKP
+func (h *Handler) GetMine(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	sum, err := h.svc.Summary(c.Context(), userID)
+	if err != nil {
+		return toFiberError(err, "failed to fetch dependents")
+	}
+	return c.JSON(sum)
+}
+
+// POST /api/me/dependents - ajukan tanggungan baru (202, menunggu review HRD)
+func (h *Handler) RequestCreate(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	in, err := parseInput(c)
+	if err != nil {
+		return err
+	}
+	ch, err := h.svc.RequestCreate(c.Context(), userID, in)
+	if err != nil {
+		return toFiberError(err, "failed to submit dependent")
+	}
+	return c.Status(fiber.StatusAccepted).JSON(ch)
+}
+
+// PUT /api/me/dependents/:id - ajukan perubahan tanggungan
+func (h *Handler) RequestUpdate(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	in, err := parseInput(c)
+	if err != nil {
+		return err
+	}
+	ch, err := h.svc.RequestUpdate(c.Context(), userID, id, in)
+	if err != nil {
+		return toFiberError(err, "failed to submit dependent change")
+	}
+	return c.Status(fiber.StatusAccepted).JSON(ch)
+}
+
+// DELETE /api/me/dependents/:id - ajukan penghapusan tanggungan
+func (h *Handler) RequestDelete(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	ch, err := h.svc.RequestDelete(c.Context(), userID, id)
+	if err != nil {
+		return toFiberError(err, "failed to submit dependent removal")
+	}
+	return c.Status(fiber.StatusAccepted).JSON(ch)
+}
+
+// GET /api/me/dependent-changes
+func (h *Handler) GetMyChanges(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	list, err := h.svc.ListMyChanges(c.Context(), userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch dependent changes")
+	}
+	return c.JSON(list)
+}
+
+// DELETE /api/me/dependent-changes/:id - batalkan pengajuan yang masih pending
+func (h *Handler) CancelMyChange(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	if err := h.svc.Cancel(c.Context(), userID, id); err != nil {
+		return toFiberError(err, "failed to cancel dependent change")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ==========================
+// HRD
+// ==========================
+
+// GET /api/employees/:id/dependents
+func (h *Handler) GetForEmployee(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	sum, err := h.svc.Summary(c.Context(), id)
+	if err != nil {
+		return toFiberError(err, "failed to fetch dependents")
+	}
+	return c.JSON(sum)
+}
+
+// POST /api/employees/:id/dependents - langsung tersimpan tanpa review
+func (h *Handler) Create(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	in, err := parseInput(c)
+	if err != nil {
+		return err
+	}
+	d, err := h.svc.Create(c.Context(), id, in)
+	if err != nil {
+		return toFiberError(err, "failed to create dependent")
+	}
+	return c.Status(fiber.StatusCreated).JSON(d)
+}
+
+// PUT /api/dependents/:id
+func (h *Handler) Update(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	in, err := parseInput(c)
+	if err != nil {
+		return err
+	}
+	d, err := h.svc.Update(c.Context(), id, in)
+	if err != nil {
+		return toFiberError(err, "failed to update dependent")
+	}
+	return c.JSON(d)
+}
+
+// DELETE /api/dependents/:id
+func (h *Handler) Delete(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	if err := h.svc.Delete(c.Context(), id); err != nil {
+		return toFiberError(err, "failed to delete dependent")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GET /api/dependent-changes?status=PENDING
+func (h *Handler) ListChanges(c *fiber.Ctx) error {
+	list, err := h.svc.ListChanges(c.Context(), c.Query("status", StatusPending))
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch dependent changes")
+	}
+	return c.JSON(list)
+}
+
+// POST /api/dependent-changes/:id/approve
+func (h *Handler) Approve(c *fiber.Ctx) error {
+	return h.review(c, true)
+}
+
+// POST /api/dependent-changes/:id/reject  body: {"note": "..."}
+func (h *Handler) Reject(c *fiber.Ctx) error {
+	return h.review(c, false)
+}
+
+func (h *Handler) review(c *fiber.Ctx, approve bool) error {
+	reviewerID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	var body struct {
+		Note string `json:"note"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+		}
+	}
+	ch, err := h.svc.Review(c.Context(), reviewerID, id, approve, body.Note)
+	if err != nil {
+		return toFiberError(err, "failed to review dependent change")
+	}
+	return c.JSON(ch)
+}
@@ -0,0 +1,81 @@
+package dependent
+
+import "time"
+
+// Hubungan keluarga.
+const (
+	RelSpouse = "SPOUSE"
+	RelChild  = "CHILD"
+	RelParent = "PARENT"
+)
+
+// Aksi pengajuan perubahan.
+const (
+	ActionCreate = "CREATE"
+	ActionUpdate = "UPDATE"
+	ActionDelete = "DELETE"
+)
+
+// Status pengajuan, sama dengan profile_change_requests.
+const (
+	StatusPending   = "PENDING"
+	StatusApproved  = "APPROVED"
+	StatusRejected  = "REJECTED"
+	StatusCancelled = "CANCELLED"
+)
+
+// Dependent adalah row di tabel "employee_dependents".
+type Dependent struct {
+	ID           int64      `json:"id"`
+	UserID       int64      `json:"user_id"`
+	FullName     string     `json:"full_name"`
+	Relationship string     `json:"relationship"`
+	Gender       string     `json:"gender,omitempty"`
+	BirthDate    *time.Time `json:"birth_date,omitempty"`
+	NIK          string     `json:"nik,omitempty"`
+	// Ditanggung asuransi kesehatan (BPJS Kesehatan / asuransi kantor).
+	HealthCoverage bool `json:"health_coverage"`
+	// Dihitung sebagai tanggungan PTKP.
+	TaxDependent bool      `json:"tax_dependent"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	nikEnc string // ciphertext; NIK diisi service setelah dekripsi
+}
+
+// Input is the body used to create or update a dependent.
+type Input struct {
+	FullName       string `json:"full_name"`
+	Relationship   string `json:"relationship"`
+	Gender         string `json:"gender"`
+	BirthDate      string `json:"birth_date"` // YYYY-MM-DD
+	NIK            string `json:"nik"`        // di response selalu dimask
+	HealthCoverage bool   `json:"health_coverage"`
+	TaxDependent   bool   `json:"tax_dependent"`
+}
+
+// Change is a self-service request waiting for HR review.
+type Change struct {
+	ID           int64      `json:"id"`
+	UserID       int64      `json:"user_id"`
+	EmployeeName string     `json:"employee_name"`
+	EmployeeCode string     `json:"employee_code"`
+	DependentID  *int64     `json:"dependent_id,omitempty"`
+	Action       string     `json:"action"`            // CREATE, UPDATE, DELETE
+	Data         *Input     `json:"data,omitempty"`    // nilai baru (CREATE/UPDATE)
+	Current      *Dependent `json:"current,omitempty"` // data sekarang, untuk dibandingkan
+	Status       string     `json:"status"`
+	ReviewedBy   *int64     `json:"reviewed_by,omitempty"`
+	ReviewerName string     `json:"reviewer_name,omitempty"`
+	ReviewNote   string     `json:"review_note,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// Summary is the dependents page of one employee.
+type Summary struct {
+	Dependents []Dependent `json:"dependents"`
+	Pending    []Change    `json:"pending_changes"`
+	// TaxStatus adalah status PTKP dari data yang sudah disetujui, mis. K/2.
+	TaxStatus string `json:"tax_status"`
+}
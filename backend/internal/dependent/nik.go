@@ -0,0 +1,142 @@
+package dependent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// nikAAD binds a NIK ciphertext to the employee; dipakai untuk row
+// tanggungan maupun payload pengajuan supaya bisa disalin apa adanya.
+// Rotasi key (sensitive.Rekey) memakai AAD yang sama.
+func nikAAD(userID int64) string {
+	return "dependent_nik:" + strconv.FormatInt(userID, 10)
+}
+
+// maskNIK hides everything except the last four digits.
+func maskNIK(v string) string {
+	if v == "" {
+		return ""
+	}
+	if len(v) <= 4 {
+		return strings.Repeat("*", len(v))
+	}
+	return strings.Repeat("*", len(v)-4) + v[len(v)-4:]
+}
+
+// sealNIK encrypts a NIK for storage; kosong tetap kosong.
+func (s *Service) sealNIK(userID int64, nik string) (string, error) {
+	if nik == "" {
+		return "", nil
+	}
+	if s.keys == nil {
+		return "", ErrNotConfigured
+	}
+	return s.keys.Encrypt(nik, nikAAD(userID))
+}
+
+// openNIK decrypts a stored NIK. Nilai tanpa ':' adalah plaintext lama yang
+// belum dienkripsi job background.
+func (s *Service) openNIK(userID int64, stored string) (string, error) {
+	if stored == "" || !strings.Contains(stored, ":") {
+		return stored, nil
+	}
+	if s.keys == nil {
+		return "", ErrNotConfigured
+	}
+	return s.keys.Decrypt(stored, nikAAD(userID))
+}
+
+// reveal fills d.NIK with the decrypted value.
+func (s *Service) reveal(d *Dependent) error {
+	if d.nikEnc == "" {
+		return nil
+	}
+	nik, err := s.openNIK(d.UserID, d.nikEnc)
+	if err != nil {
+		return err
+	}
+	d.NIK, d.nikEnc = nik, ""
+	return nil
+}
+
+// present prepares a dependent for the API: NIK selalu dimask. Gagal decrypt
+// (mis. key lama sudah dihapus) dikembalikan sebagai error, bukan NIK kosong.
+func (s *Service) present(d *Dependent) error {
+	if err := s.reveal(d); err != nil {
+		return fmt.Errorf("decrypt nik of dependent %d: %w", d.ID, err)
+	}
+	d.NIK = maskNIK(d.NIK)
+	return nil
+}
+
+// presentChange masks the NIK of the payload and of the current dependent.
+func (s *Service) presentChange(c *Change) error {
+	if c.Data != nil {
+		nik, err := s.openNIK(c.UserID, c.Data.NIK)
+		if err != nil {
+			return fmt.Errorf("decrypt nik of dependent change %d: %w", c.ID, err)
+		}
+		c.Data.NIK = maskNIK(nik)
+	}
+	if c.Current != nil {
+		return s.present(c.Current)
+	}
+	return nil
+}
+
+// resolveMaskedNIK turns a NIK sent back in masked form (form edit yang
+// tidak mengubah NIK) into the current plaintext value.
+func resolveMaskedNIK(in *Input, cur *Dependent) {
+	if cur != nil && strings.Contains(in.NIK, "*") && in.NIK == maskNIK(cur.NIK) {
+		in.NIK = cur.NIK
+	}
+}
+
+// EncryptLegacy encrypts NIKs stored before encryption was introduced, in
+// batches. Dijalankan periodik lewat scheduler.Every.
+func (s *Service) EncryptLegacy(ctx context.Context) error {
+	if s.keys == nil {
+		return nil
+	}
+	const batch = 100
+	total := 0
+	for {
+		deps, err := s.repo.PlainDependentNIKs(ctx, batch)
+		if err != nil {
+			return err
+		}
+		for _, p := range deps {
+			enc, err := s.sealNIK(p.UserID, p.NIK)
+			if err != nil {
+				return err
+			}
+			if err := s.repo.SetDependentNIKEnc(ctx, p.ID, enc); err != nil {
+				return err
+			}
+		}
+		payloads, err := s.repo.PlainPayloadNIKs(ctx, batch)
+		if err != nil {
+			return err
+		}
+		for _, p := range payloads {
+			enc, err := s.sealNIK(p.UserID, p.NIK)
+			if err != nil {
+				return err
+			}
+			if err := s.repo.SetPayloadNIK(ctx, p.ID, enc); err != nil {
+				return err
+			}
+		}
+		total += len(deps) + len(payloads)
+		if len(deps) < batch && len(payloads) < batch {
+			break
+		}
+	}
+	if total > 0 {
+		log.Printf("dependent: encrypted %d legacy NIK values", total)
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+package dependent
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+// dbtx is satisfied by both *sql.DB and *sql.Tx.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type Repository struct {
+	db   dbtx
+	conn *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db, conn: db}
+}
+
+func (r *Repository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return r.conn.BeginTx(ctx, nil)
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx.
+func (r *Repository) WithTx(tx *sql.Tx) *Repository {
+	return &Repository{db: tx, conn: r.conn}
+}
+
+// UserExists reports whether the employee exists.
+func (r *Repository) UserExists(ctx context.Context, userID int64) (bool, error) {
+	var ok bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&ok)
+	return ok, err
+}
+
+// LockUser serializes dependent changes of one employee (mis. cek satu
+// pasangan) for the rest of the transaction.
+func (r *Repository) LockUser(ctx context.Context, userID int64) error {
+	var x int64
+	return r.db.QueryRowContext(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&x)
+}
+
+// nik berisi NIK plaintext dari data lama (sebelum enkripsi), nik_enc
+// ciphertext; service yang mendekripsi.
+const dependentSelect = `
+	SELECT id, user_id, full_name, relationship, COALESCE(gender, ''), birth_date,
+		COALESCE(nik, ''), COALESCE(nik_enc, ''), health_coverage, tax_dependent, created_at, updated_at
+	FROM employee_dependents
+`
+
+func scanDependent(row interface{ Scan(dest ...any) error }) (*Dependent, error) {
+	var d Dependent
+	var birth sql.NullTime
+	err := row.Scan(&d.ID, &d.UserID, &d.FullName, &d.Relationship, &d.Gender, &birth,
+		&d.NIK, &d.nikEnc, &d.HealthCoverage, &d.TaxDependent, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if birth.Valid {
+		d.BirthDate = &birth.Time
+	}
+	return &d, nil
+}
+
+func (r *Repository) ListByUser(ctx context.Context, userID int64) ([]Dependent, error) {
+	rows, err := r.db.QueryContext(ctx, dependentSelect+`
+		WHERE user_id = $1
+		ORDER BY CASE relationship WHEN 'SPOUSE' THEN 0 WHEN 'CHILD' THEN 1 ELSE 2 END, birth_date NULLS LAST, id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Dependent{}
+	for rows.Next() {
+		d, err := scanDependent(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *d)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) Find(ctx context.Context, id int64) (*Dependent, error) {
+	return scanDependent(r.db.QueryRowContext(ctx, dependentSelect+` WHERE id = $1`, id))
+}
+
+// CountSpouses counts the SPOUSE rows of the employee, excluding excludeID.
+func (r *Repository) CountSpouses(ctx context.Context, userID, excludeID int64) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM employee_dependents
+		WHERE user_id = $1 AND relationship = 'SPOUSE' AND id <> $2
+	`, userID, excludeID).Scan(&n)
+	return n, err
+}
+
+// Insert stores v; nikEnc adalah NIK yang sudah dienkripsi.
+func (r *Repository) Insert(ctx context.Context, userID int64, v *values, nikEnc string) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO employee_dependents
+			(user_id, full_name, relationship, gender, birth_date, nik_enc, health_coverage, tax_dependent)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, ''), $7, $8)
+		RETURNING id
+	`, userID, v.FullName, v.Relationship, v.Gender, v.BirthDate, nikEnc, v.HealthCoverage, v.TaxDependent).Scan(&id)
+	return id, err
+}
+
+// Update saves v; NIK plaintext lama ikut dihapus.
+func (r *Repository) Update(ctx context.Context, id int64, v *values, nikEnc string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE employee_dependents SET
+			full_name = $2, relationship = $3, gender = NULLIF($4, ''), birth_date = $5,
+			nik = NULL, nik_enc = NULLIF($6, ''), health_coverage = $7, tax_dependent = $8, updated_at = NOW()
+		WHERE id = $1
+	`, id, v.FullName, v.Relationship, v.Gender, v.BirthDate, nikEnc, v.HealthCoverage, v.TaxDependent)
+	return err
+}
+
+func (r *Repository) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM employee_dependents WHERE id = $1`, id)
+	return err
+}
+
+// ==========================
+// Change requests
+// ==========================
+
+const changeSelect = `
+	SELECT p.id, p.user_id, u.name, u.employee_code, p.dependent_id, p.action, p.payload, p.status,
+		p.reviewed_by, COALESCE(rv.name, ''), COALESCE(p.review_note, ''), p.created_at, p.reviewed_at
+	FROM dependent_change_requests p
+	JOIN users u ON u.id = p.user_id
+	LEFT JOIN users rv ON rv.id = p.reviewed_by
+`
+
+func scanChange(row interface{ Scan(dest ...any) error }) (*Change, error) {
+	var c Change
+	var depID, reviewedBy sql.NullInt64
+	var payload []byte
+	var reviewedAt sql.NullTime
+	err := row.Scan(&c.ID, &c.UserID, &c.EmployeeName, &c.EmployeeCode, &depID, &c.Action, &payload, &c.Status,
+		&reviewedBy, &c.ReviewerName, &c.ReviewNote, &c.CreatedAt, &reviewedAt)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > 0 {
+		c.Data = &Input{}
+		if err := json.Unmarshal(payload, c.Data); err != nil {
+			return nil, err
+		}
+	}
+	if depID.Valid {
+		id := depID.Int64
+		c.DependentID = &id
+	}
+	if reviewedBy.Valid {
+		id := reviewedBy.Int64
+		c.ReviewedBy = &id
+	}
+	if reviewedAt.Valid {
+		c.ReviewedAt = &reviewedAt.Time
+	}
+	return &c, nil
+}
+
+func (r *Repository) queryChanges(ctx context.Context, where string, args ...any) ([]Change, error) {
+	rows, err := r.db.QueryContext(ctx, changeSelect+` WHERE `+where+` ORDER BY p.created_at DESC, p.id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Change{}
+	for rows.Next() {
+		c, err := scanChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *c)
+	}
+	return out, rows.Err()
+}
+
+// CreateChange stores a PENDING request and returns its id.
+func (r *Repository) CreateChange(ctx context.Context, userID int64, dependentID *int64, action string, data *Input) (int64, error) {
+	var payload any // NULL untuk DELETE
+	if data != nil {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return 0, err
+		}
+		payload = b
+	}
+	var id int64
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO dependent_change_requests (user_id, dependent_id, action, payload)
+		VALUES ($1, $2, $3, $4) RETURNING id
+	`, userID, dependentID, action, payload).Scan(&id)
+	return id, err
+}
+
+// CancelPendingFor cancels open requests on one dependent; pengajuan baru
+// untuk tanggungan yang sama menggantikan yang lama.
+func (r *Repository) CancelPendingFor(ctx context.Context, dependentID int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE dependent_change_requests SET status = 'CANCELLED', reviewed_at = NOW()
+		WHERE dependent_id = $1 AND status = 'PENDING'
+	`, dependentID)
+	return err
+}
+
+func (r *Repository) FindChange(ctx context.Context, id int64) (*Change, error) {
+	return scanChange(r.db.QueryRowContext(ctx, changeSelect+` WHERE p.id = $1`, id))
+}
+
+// LockChange locks the request row for the rest of the transaction.
+func (r *Repository) LockChange(ctx context.Context, id int64) error {
+	var x int64
+	return r.db.QueryRowContext(ctx, `SELECT id FROM dependent_change_requests WHERE id = $1 FOR UPDATE`, id).Scan(&x)
+}
+
+func (r *Repository) ListChanges(ctx context.Context, status string) ([]Change, error) {
+	if status == "" {
+		return r.queryChanges(ctx, "TRUE")
+	}
+	return r.queryChanges(ctx, "p.status = $1", status)
+}
+
+func (r *Repository) ListChangesByUser(ctx context.Context, userID int64, status string) ([]Change, error) {
+	if status == "" {
+		return r.queryChanges(ctx, "p.user_id = $1", userID)
+	}
+	return r.queryChanges(ctx, "p.user_id = $1 AND p.status = $2", userID, status)
+}
+
+func (r *Repository) SetChangeStatus(ctx context.Context, id int64, status string, reviewerID *int64, note string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE dependent_change_requests
+		SET status = $2, reviewed_by = $3, review_note = NULLIF($4, ''), reviewed_at = NOW()
+		WHERE id = $1
+	`, id, status, reviewerID, note)
+	return err
+}
+
+// SetChangeDependent links an approved CREATE request to the new row.
+func (r *Repository) SetChangeDependent(ctx context.Context, id, dependentID int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE dependent_change_requests SET dependent_id = $2 WHERE id = $1`, id, dependentID)
+	return err
+}
+
+// ==========================
+// Enkripsi data lama
+// ==========================
+
+// plainNIK is a NIK still stored in plaintext, in a dependent row or in a
+// change request payload.
+type plainNIK struct {
+	ID     int64
+	UserID int64
+	NIK    string
+}
+
+func (r *Repository) queryPlainNIKs(ctx context.Context, q string, limit int) ([]plainNIK, error) {
+	rows, err := r.db.QueryContext(ctx, q, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []plainNIK
+	for rows.Next() {
+		var p plainNIK
+		if err := rows.Scan(&p.ID, &p.UserID, &p.NIK); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// PlainDependentNIKs returns dependents whose NIK is not encrypted yet.
+func (r *Repository) PlainDependentNIKs(ctx context.Context, limit int) ([]plainNIK, error) {
+	return r.queryPlainNIKs(ctx, `
+		SELECT id, user_id, nik FROM employee_dependents
+		WHERE nik IS NOT NULL
+		ORDER BY id LIMIT $1
+	`, limit)
+}
+
+// SetDependentNIKEnc replaces the plaintext NIK of a dependent.
+func (r *Repository) SetDependentNIKEnc(ctx context.Context, id int64, enc string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE employee_dependents SET nik_enc = $2, nik = NULL WHERE id = $1 AND nik IS NOT NULL
+	`, id, enc)
+	return err
+}
+
+// PlainPayloadNIKs returns change requests whose payload still holds a
+// plaintext NIK (ciphertext selalu berisi ':').
+func (r *Repository) PlainPayloadNIKs(ctx context.Context, limit int) ([]plainNIK, error) {
+	return r.queryPlainNIKs(ctx, `
+		SELECT id, user_id, payload->>'nik' FROM dependent_change_requests
+		WHERE payload->>'nik' ~ '^[0-9]+$'
+		ORDER BY id LIMIT $1
+	`, limit)
+}
+
+// SetPayloadNIK replaces the NIK inside a change request payload.
+func (r *Repository) SetPayloadNIK(ctx context.Context, id int64, enc string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE dependent_change_requests SET payload = jsonb_set(payload, '{nik}', to_jsonb($2::text)) WHERE id = $1
+	`, id, enc)
+	return err
+}
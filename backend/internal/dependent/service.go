@@ -0,0 +1,624 @@
+package dependent
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"hr-portal-backend/pkg/crypto"
+)
+
+var (
+	ErrNotFound         = errors.New("dependent not found")
+	ErrUserNotFound     = errors.New("employee not found")
+	ErrChangeNotFound   = errors.New("change request not found")
+	ErrChangeNotPending = errors.New("change request is no longer pending")
+	ErrReviewOwnChange  = errors.New("cannot review your own change request")
+	ErrStale            = errors.New("dependent no longer exists, reject this request")
+	ErrNotConfigured    = errors.New("dependent NIK encryption is not configured")
+)
+
+// ValidationError is returned for invalid input; handler mengembalikan 400.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string { return e.Message }
+
+func invalid(msg string) error { return &ValidationError{Message: msg} }
+
+// Maksimal tanggungan PTKP (anak/orang tua) yang dihitung.
+const maxTaxDependents = 3
+
+var nikPattern = regexp.MustCompile(`^[0-9]{16}$`)
+
+// values is a validated Input ready to be stored.
+type values struct {
+	FullName       string
+	Relationship   string
+	Gender         string
+	BirthDate      *time.Time
+	NIK            string
+	HealthCoverage bool
+	TaxDependent   bool
+}
+
+// validate normalizes the input. Aturan yang butuh database (satu pasangan,
+// NIK unik) ada di checkRules.
+func validate(in *Input) (*values, error) {
+	v := &values{
+		FullName:       strings.TrimSpace(in.FullName),
+		Relationship:   strings.ToUpper(strings.TrimSpace(in.Relationship)),
+		Gender:         strings.ToUpper(strings.TrimSpace(in.Gender)),
+		NIK:            strings.TrimSpace(in.NIK),
+		HealthCoverage: in.HealthCoverage,
+		TaxDependent:   in.TaxDependent,
+	}
+	if v.FullName == "" {
+		return nil, invalid("full_name is required")
+	}
+	if len(v.FullName) > 100 {
+		return nil, invalid("full_name must be at most 100 characters")
+	}
+	switch v.Relationship {
+	case RelSpouse, RelChild, RelParent:
+	default:
+		return nil, invalid("relationship must be SPOUSE, CHILD or PARENT")
+	}
+	if len(v.Gender) > 10 {
+		return nil, invalid("gender must be at most 10 characters")
+	}
+	if v.NIK != "" && !nikPattern.MatchString(v.NIK) {
+		return nil, invalid("nik must be 16 digits")
+	}
+	if s := strings.TrimSpace(in.BirthDate); s != "" {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return nil, invalid("birth_date must be in YYYY-MM-DD format")
+		}
+		if t.After(time.Now()) {
+			return nil, invalid("birth_date cannot be in the future")
+		}
+		v.BirthDate = &t
+	}
+	// Pasangan masuk status K, bukan tanggungan.
+	if v.TaxDependent && v.Relationship == RelSpouse {
+		return nil, invalid("tax_dependent applies to children and parents only")
+	}
+	return v, nil
+}
+
+// TaxStatus returns the PTKP status (TK/0 .. K/3) dari data tanggungan.
+func TaxStatus(deps []Dependent) string {
+	married := false
+	n := 0
+	for _, d := range deps {
+		switch {
+		case d.Relationship == RelSpouse:
+			married = true
+		case d.TaxDependent:
+			n++
+		}
+	}
+	n = min(n, maxTaxDependents)
+	if married {
+		return fmt.Sprintf("K/%d", n)
+	}
+	return fmt.Sprintf("TK/%d", n)
+}
+
+// Service menyimpan NIK tanggungan terenkripsi dengan keyring yang sama
+// dengan data sensitif payroll; API hanya mengembalikan NIK yang dimask.
+type Service struct {
+	repo *Repository
+	keys *crypto.Keyring // nil = FIELD_ENCRYPTION_KEYS belum diset
+}
+
+func NewService(repo *Repository, keys *crypto.Keyring) *Service {
+	return &Service{repo: repo, keys: keys}
+}
+
+// checkRules validates v against the employee's other dependents; excludeID
+// adalah row yang sedang diubah (0 untuk tanggungan baru).
+func (s *Service) checkRules(ctx context.Context, repo *Repository, userID, excludeID int64, v *values) error {
+	if v.Relationship == RelSpouse {
+		n, err := repo.CountSpouses(ctx, userID, excludeID)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return invalid("employee already has a spouse registered")
+		}
+	}
+	if v.NIK != "" {
+		// NIK terenkripsi dengan nonce acak, jadi dibandingkan setelah dekripsi.
+		deps, err := repo.ListByUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for i := range deps {
+			d := &deps[i]
+			if d.ID == excludeID {
+				continue
+			}
+			if err := s.reveal(d); err != nil {
+				return err
+			}
+			if d.NIK == v.NIK {
+				return invalid("nik is already used by another dependent")
+			}
+		}
+	}
+	return nil
+}
+
+// Summary returns the approved dependents, pending requests and PTKP status.
+func (s *Service) Summary(ctx context.Context, userID int64) (*Summary, error) {
+	ok, err := s.repo.UserExists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	deps, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := s.repo.ListChangesByUser(ctx, userID, StatusPending)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fillCurrent(ctx, pending); err != nil {
+		return nil, err
+	}
+	for i := range deps {
+		if err := s.present(&deps[i]); err != nil {
+			return nil, err
+		}
+	}
+	return &Summary{Dependents: deps, Pending: pending, TaxStatus: TaxStatus(deps)}, nil
+}
+
+// ==========================
+// HRD: perubahan langsung
+// ==========================
+
+// Create adds a dependent directly (HRD, tanpa review).
+func (s *Service) Create(ctx context.Context, userID int64, in *Input) (*Dependent, error) {
+	v, err := validate(in)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	if err := repo.LockUser(ctx, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if err := s.checkRules(ctx, repo, userID, 0, v); err != nil {
+		return nil, err
+	}
+	enc, err := s.sealNIK(userID, v.NIK)
+	if err != nil {
+		return nil, err
+	}
+	id, err := repo.Insert(ctx, userID, v, enc)
+	if err != nil {
+		return nil, err
+	}
+	d, err := repo.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.present(d); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Update changes a dependent directly. Pengajuan karyawan yang masih pending
+// untuk tanggungan ini dibatalkan karena datanya sudah berubah. NIK yang
+// dikirim balik dalam bentuk mask berarti tidak berubah.
+func (s *Service) Update(ctx context.Context, id int64, in *Input) (*Dependent, error) {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	cur, err := s.lockDependent(ctx, repo, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reveal(cur); err != nil {
+		return nil, err
+	}
+	resolveMaskedNIK(in, cur)
+	v, err := validate(in)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRules(ctx, repo, cur.UserID, id, v); err != nil {
+		return nil, err
+	}
+	enc, err := s.sealNIK(cur.UserID, v.NIK)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.Update(ctx, id, v, enc); err != nil {
+		return nil, err
+	}
+	if err := repo.CancelPendingFor(ctx, id); err != nil {
+		return nil, err
+	}
+	d, err := repo.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.present(d); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Delete removes a dependent directly.
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	if _, err := s.lockDependent(ctx, repo, id); err != nil {
+		return err
+	}
+	if err := repo.CancelPendingFor(ctx, id); err != nil {
+		return err
+	}
+	if err := repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockDependent loads the dependent and locks its employee row.
+func (s *Service) lockDependent(ctx context.Context, repo *Repository, id int64) (*Dependent, error) {
+	d, err := repo.Find(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.LockUser(ctx, d.UserID); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ==========================
+// Self-service: pengajuan
+// ==========================
+
+// RequestCreate submits a new dependent for HRD review.
+func (s *Service) RequestCreate(ctx context.Context, userID int64, in *Input) (*Change, error) {
+	return s.request(ctx, userID, 0, ActionCreate, in)
+}
+
+// RequestUpdate submits new values for one of the user's dependents.
+func (s *Service) RequestUpdate(ctx context.Context, userID, dependentID int64, in *Input) (*Change, error) {
+	return s.request(ctx, userID, dependentID, ActionUpdate, in)
+}
+
+// RequestDelete asks HRD to remove one of the user's dependents.
+func (s *Service) RequestDelete(ctx context.Context, userID, dependentID int64) (*Change, error) {
+	return s.request(ctx, userID, dependentID, ActionDelete, nil)
+}
+
+func (s *Service) request(ctx context.Context, userID, dependentID int64, action string, in *Input) (*Change, error) {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	var depID *int64
+	var cur *Dependent
+	if dependentID > 0 {
+		if cur, err = s.lockDependent(ctx, repo, dependentID); err != nil {
+			return nil, err
+		}
+		// Tanggungan karyawan lain diperlakukan seperti tidak ada.
+		if cur.UserID != userID {
+			return nil, ErrNotFound
+		}
+		if err := s.reveal(cur); err != nil {
+			return nil, err
+		}
+		// Pengajuan baru menggantikan pengajuan lama untuk tanggungan yang sama.
+		if err := repo.CancelPendingFor(ctx, dependentID); err != nil {
+			return nil, err
+		}
+		depID = &dependentID
+	} else if err := repo.LockUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	// Cek aturan sekarang supaya karyawan langsung tahu; dicek ulang saat approve.
+	var payload *Input
+	if action != ActionDelete {
+		resolveMaskedNIK(in, cur)
+		v, err := validate(in)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkRules(ctx, repo, userID, dependentID, v); err != nil {
+			return nil, err
+		}
+		payload = inputFromValues(v)
+		if payload.NIK, err = s.sealNIK(userID, v.NIK); err != nil {
+			return nil, err
+		}
+	}
+	id, err := repo.CreateChange(ctx, userID, depID, action, payload)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.withCurrent(ctx, repo, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// inputFromValues stores the normalized values as the request payload.
+func inputFromValues(v *values) *Input {
+	in := &Input{
+		FullName:       v.FullName,
+		Relationship:   v.Relationship,
+		Gender:         v.Gender,
+		NIK:            v.NIK,
+		HealthCoverage: v.HealthCoverage,
+		TaxDependent:   v.TaxDependent,
+	}
+	if v.BirthDate != nil {
+		in.BirthDate = v.BirthDate.Format("2006-01-02")
+	}
+	return in
+}
+
+// withCurrent loads a change request together with the dependent it
+// targets, supaya reviewer bisa membandingkan data lama dan baru.
+func (s *Service) withCurrent(ctx context.Context, repo *Repository, id int64) (*Change, error) {
+	c, err := repo.FindChange(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.DependentID != nil && c.Status == StatusPending {
+		d, err := repo.Find(ctx, *c.DependentID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		c.Current = d
+	}
+	if err := s.presentChange(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *Service) fillCurrent(ctx context.Context, list []Change) error {
+	for i := range list {
+		c := &list[i]
+		if c.DependentID == nil || c.Status != StatusPending {
+			continue
+		}
+		d, err := s.repo.Find(ctx, *c.DependentID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		c.Current = d
+	}
+	for i := range list {
+		if err := s.presentChange(&list[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListMyChanges lists the user's own requests, terbaru dulu.
+func (s *Service) ListMyChanges(ctx context.Context, userID int64) ([]Change, error) {
+	list, err := s.repo.ListChangesByUser(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+	return list, s.fillCurrent(ctx, list)
+}
+
+// ListChanges lists requests for HRD review; status kosong = semua.
+func (s *Service) ListChanges(ctx context.Context, status string) ([]Change, error) {
+	list, err := s.repo.ListChanges(ctx, strings.ToUpper(strings.TrimSpace(status)))
+	if err != nil {
+		return nil, err
+	}
+	return list, s.fillCurrent(ctx, list)
+}
+
+// lockChange locks and loads a change request.
+func lockChange(ctx context.Context, repo *Repository, id int64) (*Change, error) {
+	if err := repo.LockChange(ctx, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrChangeNotFound
+		}
+		return nil, err
+	}
+	c, err := repo.FindChange(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.Status != StatusPending {
+		return nil, ErrChangeNotPending
+	}
+	return c, nil
+}
+
+// Review approves (applying the change) or rejects a pending request.
+func (s *Service) Review(ctx context.Context, reviewerID, id int64, approve bool, note string) (*Change, error) {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	c, err := lockChange(ctx, repo, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.UserID == reviewerID {
+		return nil, ErrReviewOwnChange
+	}
+
+	status := StatusRejected
+	if approve {
+		status = StatusApproved
+		if err := s.apply(ctx, repo, c); err != nil {
+			return nil, err
+		}
+	}
+	if err := repo.SetChangeStatus(ctx, id, status, &reviewerID, strings.TrimSpace(note)); err != nil {
+		return nil, err
+	}
+	if c, err = repo.FindChange(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := s.presentChange(c); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// apply writes an approved request. Aturan dicek ulang karena data bisa
+// berubah sejak pengajuan dibuat.
+func (s *Service) apply(ctx context.Context, repo *Repository, c *Change) error {
+	if c.Action == ActionCreate {
+		v, err := s.changeValues(c)
+		if err != nil {
+			return err
+		}
+		if err := repo.LockUser(ctx, c.UserID); err != nil {
+			return err
+		}
+		if err := s.checkRules(ctx, repo, c.UserID, 0, v); err != nil {
+			return err
+		}
+		enc, err := s.sealNIK(c.UserID, v.NIK)
+		if err != nil {
+			return err
+		}
+		depID, err := repo.Insert(ctx, c.UserID, v, enc)
+		if err != nil {
+			return err
+		}
+		return repo.SetChangeDependent(ctx, c.ID, depID)
+	}
+
+	if c.DependentID == nil {
+		return ErrStale
+	}
+	cur, err := s.lockDependent(ctx, repo, *c.DependentID)
+	if err == ErrNotFound {
+		return ErrStale
+	}
+	if err != nil {
+		return err
+	}
+	if c.Action == ActionDelete {
+		return repo.Delete(ctx, cur.ID)
+	}
+	v, err := s.changeValues(c)
+	if err != nil {
+		return err
+	}
+	if err := s.checkRules(ctx, repo, c.UserID, cur.ID, v); err != nil {
+		return err
+	}
+	enc, err := s.sealNIK(c.UserID, v.NIK)
+	if err != nil {
+		return err
+	}
+	return repo.Update(ctx, cur.ID, v, enc)
+}
+
+// changeValues decrypts and re-validates the payload of a request.
+func (s *Service) changeValues(c *Change) (*values, error) {
+	if c.Data == nil {
+		return nil, ErrStale
+	}
+	data := *c.Data
+	nik, err := s.openNIK(c.UserID, data.NIK)
+	if err != nil {
+		return nil, err
+	}
+	data.NIK = nik
+	return validate(&data)
+}
+
+// Cancel lets the owner withdraw a pending request.
+func (s *Service) Cancel(ctx context.Context, userID, id int64) error {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	if err := repo.LockChange(ctx, id); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrChangeNotFound
+		}
+		return err
+	}
+	c, err := repo.FindChange(ctx, id)
+	if err != nil {
+		return err
+	}
+	// Request milik orang lain diperlakukan seperti tidak ada.
+	if c.UserID != userID {
+		return ErrChangeNotFound
+	}
+	if c.Status != StatusPending {
+		return ErrChangeNotPending
+	}
+	if err := repo.SetChangeStatus(ctx, id, StatusCancelled, nil, ""); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,220 @@
+package document
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func actorFrom(c *fiber.Ctx) (Actor, bool) {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return Actor{}, false
+	}
+	roles, _ := c.Locals("roles").([]string)
+	return Actor{UserID: userID, Roles: roles}, true
+}
+
+func toFiberError(err error, fallback string) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, ErrForbidden):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, ErrTooLarge):
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, ErrInvalidFile), errors.Is(err, ErrInvalid):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, fallback)
+}
+
+func parseID(c *fiber.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	return id, nil
+}
+
+// GET /api/document-types?include_inactive=true
+func (h *Handler) ListTypes(c *fiber.Ctx) error {
+	list, err := h.svc.ListTypes(c.Context(), c.QueryBool("include_inactive"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch document types")
+	}
+	return c.JSON(list)
+}
+
+// PUT /api/document-types  body: {"code":"BPJS","name":"Kartu BPJS","requires_expiry":false,"is_active":true}
+func (h *Handler) SaveType(c *fiber.Ctx) error {
+	var in struct {
+		DocumentType
+		IsActive *bool `json:"is_active"` // tidak dikirim = tidak berubah
+	}
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	t, err := h.svc.SaveType(c.Context(), in.DocumentType, in.IsActive)
+	if err != nil {
+		return toFiberError(err, "failed to save document type")
+	}
+	return c.JSON(t)
+}
+
+// GET /api/me/documents
+func (h *Handler) ListMine(c *fiber.Ctx) error {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	list, err := h.svc.ListForUser(c.Context(), actor, actor.UserID)
+	if err != nil {
+		return toFiberError(err, "failed to fetch documents")
+	}
+	return c.JSON(list)
+}
+
+// GET /api/employees/:id/documents
+func (h *Handler) ListForEmployee(c *fiber.Ctx) error {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	list, err := h.svc.ListForUser(c.Context(), actor, id)
+	if err != nil {
+		return toFiberError(err, "failed to fetch documents")
+	}
+	return c.JSON(list)
+}
+
+// POST /api/employees/:id/documents (multipart: type_code, title, expiry_date, file)
+func (h *Handler) Create(c *fiber.Ctx) error {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "file is required")
+	}
+
+	d, err := h.svc.Create(c.Context(), actor, id, UploadInput{
+		TypeCode:   c.FormValue("type_code"),
+		Title:      c.FormValue("title"),
+		ExpiryDate: c.FormValue("expiry_date"),
+	}, fh)
+	if err != nil {
+		return toFiberError(err, "failed to upload document")
+	}
+	return c.Status(fiber.StatusCreated).JSON(d)
+}
+
+// GET /api/documents/:id - detail + riwayat versi
+func (h *Handler) Get(c *fiber.Ctx) error {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	d, err := h.svc.Get(c.Context(), actor, id)
+	if err != nil {
+		return toFiberError(err, "failed to fetch document")
+	}
+	return c.JSON(d)
+}
+
+// POST /api/documents/:id/versions (multipart: file, expiry_date)
+func (h *Handler) AddVersion(c *fiber.Ctx) error {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "file is required")
+	}
+	d, err := h.svc.AddVersion(c.Context(), actor, id, c.FormValue("expiry_date"), fh)
+	if err != nil {
+		return toFiberError(err, "failed to upload new version")
+	}
+	return c.Status(fiber.StatusCreated).JSON(d)
+}
+
+// GET /api/documents/:id/download?version=2 (default versi terbaru)
+func (h *Handler) Download(c *fiber.Ctx) error {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	v, rc, err := h.svc.Open(c.Context(), actor, id, c.QueryInt("version"))
+	if err != nil {
+		return toFiberError(err, "failed to download document")
+	}
+	c.Set("Content-Type", v.MimeType)
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", v.FileName))
+	c.Set("X-Checksum-SHA256", v.Checksum)
+	return c.SendStream(rc, int(v.SizeBytes))
+}
+
+// DELETE /api/documents/:id
+func (h *Handler) Delete(c *fiber.Ctx) error {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	if err := h.svc.Delete(c.Context(), actor, id); err != nil {
+		return toFiberError(err, "failed to delete document")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GET /api/documents/expiring?days=30
+func (h *Handler) ListExpiring(c *fiber.Ctx) error {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	days := c.QueryInt("days", 30)
+	if days < 0 || days > 366 {
+		return fiber.NewError(fiber.StatusBadRequest, "days must be between 0 and 366")
+	}
+	list, err := h.svc.ListExpiring(c.Context(), actor, days)
+	if err != nil {
+		return toFiberError(err, "failed to fetch expiring documents")
+	}
+	return c.JSON(list)
+}
@@ -0,0 +1,59 @@
+package document
+
+import "time"
+
+// DocumentType adalah jenis dokumen (KTP, NPWP, kontrak, sertifikat, ...).
+type DocumentType struct {
+	Code           string `json:"code"`
+	Name           string `json:"name"`
+	RequiresExpiry bool   `json:"requires_expiry"`
+	IsActive       bool   `json:"is_active"`
+}
+
+// Document is one employee document; the file itself lives in its versions.
+type Document struct {
+	ID             int64      `json:"id"`
+	UserID         int64      `json:"user_id"`
+	EmployeeName   string     `json:"employee_name"`
+	EmployeeCode   string     `json:"employee_code"`
+	TypeCode       string     `json:"type_code"`
+	TypeName       string     `json:"type_name"`
+	Title          string     `json:"title"`
+	ExpiryDate     *time.Time `json:"expiry_date,omitempty"`
+	CurrentVersion int        `json:"current_version"`
+	FileName       string     `json:"file_name"`
+	MimeType       string     `json:"mime_type"`
+	SizeBytes      int64      `json:"size_bytes"`
+	CreatedBy      *int64     `json:"created_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	Versions       []Version  `json:"versions,omitempty"`
+}
+
+// Version adalah satu file yang pernah di-upload untuk sebuah dokumen.
+type Version struct {
+	ID           int64     `json:"id"`
+	DocumentID   int64     `json:"document_id"`
+	Version      int       `json:"version"`
+	FileName     string    `json:"file_name"`
+	MimeType     string    `json:"mime_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	Checksum     string    `json:"checksum_sha256"`
+	StorageKey   string    `json:"-"`
+	UploadedBy   *int64    `json:"uploaded_by,omitempty"`
+	UploaderName string    `json:"uploader_name,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// UploadInput is the form data of a new document or version.
+type UploadInput struct {
+	TypeCode   string
+	Title      string
+	ExpiryDate string // YYYY-MM-DD, opsional kecuali type mewajibkan
+}
+
+// Actor is the authenticated caller.
+type Actor struct {
+	UserID int64
+	Roles  []string
+}
@@ -0,0 +1,287 @@
+package document
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// dbtx is satisfied by both *sql.DB and *sql.Tx.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type Repository struct {
+	db   dbtx
+	conn *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db, conn: db}
+}
+
+func (r *Repository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return r.conn.BeginTx(ctx, nil)
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx.
+func (r *Repository) WithTx(tx *sql.Tx) *Repository {
+	return &Repository{db: tx, conn: r.conn}
+}
+
+// ==========================
+// Document types
+// ==========================
+
+func (r *Repository) ListTypes(ctx context.Context, includeInactive bool) ([]DocumentType, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT code, name, requires_expiry, is_active
+		FROM document_types
+		WHERE is_active OR $1
+		ORDER BY name
+	`, includeInactive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []DocumentType{}
+	for rows.Next() {
+		var t DocumentType
+		if err := rows.Scan(&t.Code, &t.Name, &t.RequiresExpiry, &t.IsActive); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) FindType(ctx context.Context, code string) (*DocumentType, error) {
+	var t DocumentType
+	err := r.db.QueryRowContext(ctx, `
+		SELECT code, name, requires_expiry, is_active FROM document_types WHERE code = $1
+	`, code).Scan(&t.Code, &t.Name, &t.RequiresExpiry, &t.IsActive)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// SaveType inserts or updates a document type. active nil = aktif untuk
+// tipe baru, tidak berubah untuk tipe yang sudah ada.
+func (r *Repository) SaveType(ctx context.Context, t *DocumentType, active *bool) error {
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO document_types (code, name, requires_expiry, is_active)
+		VALUES ($1, $2, $3, COALESCE($4, TRUE))
+		ON CONFLICT (code) DO UPDATE
+		SET name = EXCLUDED.name, requires_expiry = EXCLUDED.requires_expiry,
+			is_active = COALESCE($4, document_types.is_active)
+		RETURNING is_active
+	`, t.Code, t.Name, t.RequiresExpiry, active).Scan(&t.IsActive)
+}
+
+// ==========================
+// Documents
+// ==========================
+
+const documentSelect = `
+	SELECT d.id, d.user_id, u.name, u.employee_code, d.type_code, t.name, d.title, d.expiry_date,
+		d.current_version, COALESCE(v.file_name, ''), COALESCE(v.mime_type, ''), COALESCE(v.size_bytes, 0),
+		d.created_by, d.created_at, d.updated_at
+	FROM employee_documents d
+	JOIN users u ON u.id = d.user_id
+	JOIN document_types t ON t.code = d.type_code
+	LEFT JOIN employee_document_versions v ON v.document_id = d.id AND v.version = d.current_version
+`
+
+func scanDocument(row interface{ Scan(dest ...any) error }) (*Document, error) {
+	var d Document
+	var expiry sql.NullTime
+	var createdBy sql.NullInt64
+	err := row.Scan(&d.ID, &d.UserID, &d.EmployeeName, &d.EmployeeCode, &d.TypeCode, &d.TypeName, &d.Title, &expiry,
+		&d.CurrentVersion, &d.FileName, &d.MimeType, &d.SizeBytes,
+		&createdBy, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if expiry.Valid {
+		d.ExpiryDate = &expiry.Time
+	}
+	if createdBy.Valid {
+		id := createdBy.Int64
+		d.CreatedBy = &id
+	}
+	return &d, nil
+}
+
+func (r *Repository) queryDocuments(ctx context.Context, tail string, args ...any) ([]Document, error) {
+	rows, err := r.db.QueryContext(ctx, documentSelect+tail, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []Document{}
+	for rows.Next() {
+		d, err := scanDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *d)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) CreateDocument(ctx context.Context, d *Document) error {
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO employee_documents (user_id, type_code, title, expiry_date, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, d.UserID, d.TypeCode, d.Title, d.ExpiryDate, d.CreatedBy).Scan(&d.ID)
+}
+
+func (r *Repository) FindDocument(ctx context.Context, id int64) (*Document, error) {
+	return scanDocument(r.db.QueryRowContext(ctx, documentSelect+` WHERE d.id = $1`, id))
+}
+
+func (r *Repository) ListByUser(ctx context.Context, userID int64) ([]Document, error) {
+	return r.queryDocuments(ctx, ` WHERE d.user_id = $1 ORDER BY t.name, d.title`, userID)
+}
+
+// ListExpiring returns documents whose expiry date is on or before
+// today + days, already expired ones included.
+func (r *Repository) ListExpiring(ctx context.Context, days int) ([]Document, error) {
+	return r.queryDocuments(ctx, `
+		WHERE d.expiry_date IS NOT NULL AND d.expiry_date <= CURRENT_DATE + $1::int AND u.status = 'ACTIVE'
+		ORDER BY d.expiry_date, d.id
+	`, days)
+}
+
+// ClaimExpiringUnalerted is ListExpiring limited to documents not yet
+// alerted for their current expiry date, locked for the transaction.
+func (r *Repository) ClaimExpiringUnalerted(ctx context.Context, days int) ([]Document, error) {
+	return r.queryDocuments(ctx, `
+		WHERE d.expiry_date IS NOT NULL AND d.expiry_date <= CURRENT_DATE + $1::int AND u.status = 'ACTIVE'
+		  AND d.expiry_alerted_for IS DISTINCT FROM d.expiry_date
+		ORDER BY d.expiry_date, d.id
+		FOR UPDATE OF d SKIP LOCKED
+	`, days)
+}
+
+func (r *Repository) MarkAlerted(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE employee_documents SET expiry_alerted_for = expiry_date WHERE id = $1`, id)
+	return err
+}
+
+// LockDocument locks the document row so version numbers are assigned one
+// at a time.
+func (r *Repository) LockDocument(ctx context.Context, id int64) error {
+	var x int64
+	return r.db.QueryRowContext(ctx, `SELECT id FROM employee_documents WHERE id = $1 FOR UPDATE`, id).Scan(&x)
+}
+
+// AddVersion stores the next version of a document and makes it current.
+// expiry nil keeps the current expiry date. Panggil setelah LockDocument.
+func (r *Repository) AddVersion(ctx context.Context, v *Version, expiry *time.Time) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO employee_document_versions
+			(document_id, version, file_name, mime_type, size_bytes, checksum_sha256, storage_key, uploaded_by)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4, $5, $6, $7
+		FROM employee_document_versions WHERE document_id = $1
+		RETURNING id, version, created_at
+	`, v.DocumentID, v.FileName, v.MimeType, v.SizeBytes, v.Checksum, v.StorageKey, v.UploadedBy,
+	).Scan(&v.ID, &v.Version, &v.CreatedAt)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE employee_documents
+		SET current_version = $1, expiry_date = COALESCE($2, expiry_date), updated_at = NOW()
+		WHERE id = $3
+	`, v.Version, expiry, v.DocumentID)
+	return err
+}
+
+const versionSelect = `
+	SELECT v.id, v.document_id, v.version, v.file_name, v.mime_type, v.size_bytes, v.checksum_sha256,
+		v.storage_key, v.uploaded_by, COALESCE(u.name, ''), v.created_at
+	FROM employee_document_versions v
+	LEFT JOIN users u ON u.id = v.uploaded_by
+`
+
+func scanVersion(row interface{ Scan(dest ...any) error }) (*Version, error) {
+	var v Version
+	var uploadedBy sql.NullInt64
+	err := row.Scan(&v.ID, &v.DocumentID, &v.Version, &v.FileName, &v.MimeType, &v.SizeBytes, &v.Checksum,
+		&v.StorageKey, &uploadedBy, &v.UploaderName, &v.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if uploadedBy.Valid {
+		id := uploadedBy.Int64
+		v.UploadedBy = &id
+	}
+	return &v, nil
+}
+
+func (r *Repository) ListVersions(ctx context.Context, documentID int64) ([]Version, error) {
+	rows, err := r.db.QueryContext(ctx, versionSelect+` WHERE v.document_id = $1 ORDER BY v.version DESC`, documentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []Version{}
+	for rows.Next() {
+		v, err := scanVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *v)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) FindVersion(ctx context.Context, documentID int64, version int) (*Version, error) {
+	return scanVersion(r.db.QueryRowContext(ctx, versionSelect+` WHERE v.document_id = $1 AND v.version = $2`, documentID, version))
+}
+
+// DeleteDocument removes the document (versions cascade) and returns the
+// storage keys of its files.
+func (r *Repository) DeleteDocument(ctx context.Context, id int64) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT storage_key FROM employee_document_versions WHERE document_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM employee_documents WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return keys, nil
+}
+
+// UserExists reports whether an employee id exists.
+func (r *Repository) UserExists(ctx context.Context, userID int64) (bool, error) {
+	var ok bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&ok)
+	return ok, err
+}
@@ -0,0 +1,364 @@
+package document
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
+	"time"
+
+	"hr-portal-backend/internal/mail"
+	"hr-portal-backend/pkg/storage"
+)
+
+var (
+	ErrNotFound    = errors.New("document not found")
+	ErrForbidden   = errors.New("not allowed to access this document")
+	ErrInvalidFile = storage.ErrInvalidFile
+	ErrTooLarge    = storage.ErrTooLarge
+	ErrInvalid     = errors.New("invalid document")
+)
+
+// ManagePermission lets HR see and manage every employee's documents.
+const ManagePermission = "MANAGE_EMPLOYEES"
+
+// PermissionChecker is satisfied by *rbac.Repository.
+type PermissionChecker interface {
+	HasAnyPermission(ctx context.Context, roles []string, codes ...string) (bool, error)
+}
+
+// Notifier queues an email inside the caller's transaction.
+type Notifier interface {
+	NotifyPermissionTx(ctx context.Context, tx *sql.Tx, n mail.Notification, permissions ...string) (int, error)
+}
+
+// allowedTypes: scan dokumen cukup PDF atau gambar.
+var allowedTypes = storage.Types(".pdf", ".jpg", ".jpeg", ".png")
+
+type Service struct {
+	repo     *Repository
+	store    storage.Storage
+	perms    PermissionChecker
+	notifier Notifier
+	maxBytes int64
+}
+
+func NewService(repo *Repository, store storage.Storage, perms PermissionChecker, notifier Notifier, maxBytes int64) *Service {
+	return &Service{repo: repo, store: store, perms: perms, notifier: notifier, maxBytes: maxBytes}
+}
+
+func (s *Service) isHR(ctx context.Context, actor Actor) (bool, error) {
+	return s.perms.HasAnyPermission(ctx, actor.Roles, ManagePermission)
+}
+
+// canRead: pemilik dokumen atau HR.
+func (s *Service) canRead(ctx context.Context, actor Actor, ownerID int64) error {
+	if actor.UserID == ownerID {
+		return nil
+	}
+	hr, err := s.isHR(ctx, actor)
+	if err != nil {
+		return err
+	}
+	if !hr {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (s *Service) requireHR(ctx context.Context, actor Actor) error {
+	hr, err := s.isHR(ctx, actor)
+	if err != nil {
+		return err
+	}
+	if !hr {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func notFoundOr(err error) error {
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
+
+// ==========================
+// Types
+// ==========================
+
+func (s *Service) ListTypes(ctx context.Context, includeInactive bool) ([]DocumentType, error) {
+	return s.repo.ListTypes(ctx, includeInactive)
+}
+
+func (s *Service) SaveType(ctx context.Context, t DocumentType, active *bool) (*DocumentType, error) {
+	t.Code = strings.ToUpper(strings.TrimSpace(t.Code))
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Code == "" || len(t.Code) > 20 {
+		return nil, fmt.Errorf("%w: code must be 1-20 characters", ErrInvalid)
+	}
+	if t.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalid)
+	}
+	if err := s.repo.SaveType(ctx, &t, active); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ==========================
+// Documents
+// ==========================
+
+// ListForUser returns the documents of one employee.
+func (s *Service) ListForUser(ctx context.Context, actor Actor, userID int64) ([]Document, error) {
+	if err := s.canRead(ctx, actor, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListByUser(ctx, userID)
+}
+
+// Get returns a document with its full version history.
+func (s *Service) Get(ctx context.Context, actor Actor, id int64) (*Document, error) {
+	d, err := s.repo.FindDocument(ctx, id)
+	if err != nil {
+		return nil, notFoundOr(err)
+	}
+	if err := s.canRead(ctx, actor, d.UserID); err != nil {
+		return nil, err
+	}
+	if d.Versions, err = s.repo.ListVersions(ctx, id); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func parseExpiry(v string) (*time.Time, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid expiry_date (YYYY-MM-DD)", ErrInvalid)
+	}
+	return &t, nil
+}
+
+// Create adds a new document (version 1) for an employee. HR only.
+func (s *Service) Create(ctx context.Context, actor Actor, userID int64, in UploadInput, fh *multipart.FileHeader) (*Document, error) {
+	if err := s.requireHR(ctx, actor); err != nil {
+		return nil, err
+	}
+	exists, err := s.repo.UserExists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	in.TypeCode = strings.ToUpper(strings.TrimSpace(in.TypeCode))
+	in.Title = strings.TrimSpace(in.Title)
+	t, err := s.repo.FindType(ctx, in.TypeCode)
+	if err != nil || !t.IsActive {
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: unknown document type %q", ErrInvalid, in.TypeCode)
+	}
+	if in.Title == "" {
+		in.Title = t.Name
+	}
+	expiry, err := parseExpiry(in.ExpiryDate)
+	if err != nil {
+		return nil, err
+	}
+	if t.RequiresExpiry && expiry == nil {
+		return nil, fmt.Errorf("%w: expiry_date is required for %s", ErrInvalid, t.Name)
+	}
+
+	v, err := s.storeFile(ctx, fh)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		_ = s.store.Delete(ctx, v.StorageKey)
+		return nil, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	d := &Document{UserID: userID, TypeCode: t.Code, Title: in.Title, ExpiryDate: expiry, CreatedBy: &actor.UserID}
+	if err := repo.CreateDocument(ctx, d); err != nil {
+		_ = s.store.Delete(ctx, v.StorageKey)
+		return nil, err
+	}
+	v.DocumentID = d.ID
+	v.UploadedBy = &actor.UserID
+	if err := repo.AddVersion(ctx, v, nil); err != nil {
+		_ = s.store.Delete(ctx, v.StorageKey)
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		_ = s.store.Delete(ctx, v.StorageKey)
+		return nil, err
+	}
+	return s.Get(ctx, actor, d.ID)
+}
+
+// AddVersion uploads a replacement file, optionally with a new expiry date
+// (mis. KTP/kontrak yang diperbarui). Versi lama tetap tersimpan. HR only.
+func (s *Service) AddVersion(ctx context.Context, actor Actor, id int64, expiryDate string, fh *multipart.FileHeader) (*Document, error) {
+	if err := s.requireHR(ctx, actor); err != nil {
+		return nil, err
+	}
+	expiry, err := parseExpiry(expiryDate)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.FindDocument(ctx, id); err != nil {
+		return nil, notFoundOr(err)
+	}
+
+	v, err := s.storeFile(ctx, fh)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		_ = s.store.Delete(ctx, v.StorageKey)
+		return nil, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	if err := repo.LockDocument(ctx, id); err != nil {
+		_ = s.store.Delete(ctx, v.StorageKey)
+		return nil, notFoundOr(err)
+	}
+	v.DocumentID = id
+	v.UploadedBy = &actor.UserID
+	if err := repo.AddVersion(ctx, v, expiry); err != nil {
+		_ = s.store.Delete(ctx, v.StorageKey)
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		_ = s.store.Delete(ctx, v.StorageKey)
+		return nil, err
+	}
+	return s.Get(ctx, actor, id)
+}
+
+// Open returns a version (0 = current) of a document and its content.
+// Caller wajib menutup reader.
+func (s *Service) Open(ctx context.Context, actor Actor, id int64, version int) (*Version, io.ReadCloser, error) {
+	d, err := s.repo.FindDocument(ctx, id)
+	if err != nil {
+		return nil, nil, notFoundOr(err)
+	}
+	if err := s.canRead(ctx, actor, d.UserID); err != nil {
+		return nil, nil, err
+	}
+	if version <= 0 {
+		version = d.CurrentVersion
+	}
+	v, err := s.repo.FindVersion(ctx, id, version)
+	if err != nil {
+		return nil, nil, notFoundOr(err)
+	}
+	rc, err := s.store.Open(ctx, v.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+	return v, rc, nil
+}
+
+// Delete removes a document with all versions and files. HR only.
+func (s *Service) Delete(ctx context.Context, actor Actor, id int64) error {
+	if err := s.requireHR(ctx, actor); err != nil {
+		return err
+	}
+	keys, err := s.repo.DeleteDocument(ctx, id)
+	if err != nil {
+		return notFoundOr(err)
+	}
+	for _, k := range keys {
+		if err := s.store.Delete(ctx, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListExpiring returns documents expiring within days (expired included). HR only.
+func (s *Service) ListExpiring(ctx context.Context, actor Actor, days int) ([]Document, error) {
+	if err := s.requireHR(ctx, actor); err != nil {
+		return nil, err
+	}
+	return s.repo.ListExpiring(ctx, days)
+}
+
+// AlertExpiring emails HR one digest of documents expiring within days that
+// were not alerted yet for their current expiry date. Dijalankan harian.
+func (s *Service) AlertExpiring(ctx context.Context, days int) error {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	docs, err := repo.ClaimExpiringUnalerted(ctx, days)
+	if err != nil || len(docs) == 0 {
+		return err
+	}
+	rows := make([]map[string]any, 0, len(docs))
+	for _, d := range docs {
+		rows = append(rows, map[string]any{
+			"EmployeeCode": d.EmployeeCode,
+			"EmployeeName": d.EmployeeName,
+			"Type":         d.TypeName,
+			"Title":        d.Title,
+			"ExpiryDate":   d.ExpiryDate.Format("2006-01-02"),
+		})
+	}
+	sent, err := s.notifier.NotifyPermissionTx(ctx, tx, mail.Notification{
+		Event: mail.EventDocumentsExpiring,
+		Data:  map[string]any{"Documents": rows, "Days": days},
+	}, ManagePermission)
+	if err != nil || sent == 0 {
+		return err // belum ada penerima: jangan tandai, dicoba lagi besok
+	}
+	for _, d := range docs {
+		if err := repo.MarkAlerted(ctx, d.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// storeFile validates the upload and streams it to storage.
+func (s *Service) storeFile(ctx context.Context, fh *multipart.FileHeader) (*Version, error) {
+	up, err := storage.PutUpload(ctx, s.store, "documents", fh, allowedTypes, s.maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	return &Version{
+		FileName:   up.FileName,
+		MimeType:   up.MimeType,
+		SizeBytes:  up.SizeBytes,
+		Checksum:   up.Checksum,
+		StorageKey: up.Key,
+	}, nil
+}
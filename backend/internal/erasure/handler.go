@@ -0,0 +1,89 @@
+package erasure
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func toFiberError(err error, fallback string) error {
+	var vErr *ValidationError
+	switch {
+	case errors.As(err, &vErr):
+		return fiber.NewError(fiber.StatusBadRequest, vErr.Message)
+	case errors.Is(err, ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, ErrStillActive), errors.Is(err, ErrAlreadyAnonymized), errors.Is(err, ErrBlocked):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, fallback)
+}
+
+func parseID(c *fiber.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	return id, nil
+}
+
+// GET /api/employees/:id/dependencies
+func (h *Handler) Report(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	rep, err := h.svc.Report(c.Context(), id)
+	if err != nil {
+		return toFiberError(err, "failed to build dependency report")
+	}
+	return c.JSON(rep)
+}
+
+// GET /api/employees/by-code/:code/dependencies
+func (h *Handler) ReportByCode(c *fiber.Ctx) error {
+	rep, err := h.svc.ReportByCode(c.Context(), c.Params("code"))
+	if err != nil {
+		return toFiberError(err, "failed to build dependency report")
+	}
+	return c.JSON(rep)
+}
+
+// DELETE /api/employees/:id/hard?mode=anonymize|cascade (default anonymize)
+func (h *Handler) HardDelete(c *fiber.Ctx) error {
+	actorID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	res, err := h.svc.HardDelete(c.Context(), actorID, id, c.Query("mode"))
+	if err != nil {
+		return toFiberError(err, "failed to delete employee")
+	}
+	return c.JSON(res)
+}
+
+// DELETE /api/employees/by-code/:code/hard?mode=anonymize|cascade
+func (h *Handler) HardDeleteByCode(c *fiber.Ctx) error {
+	actorID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	res, err := h.svc.HardDeleteByCode(c.Context(), actorID, c.Params("code"), c.Query("mode"))
+	if err != nil {
+		return toFiberError(err, "failed to delete employee")
+	}
+	return c.JSON(res)
+}
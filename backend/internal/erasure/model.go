@@ -0,0 +1,56 @@
+package erasure
+
+// Mode hard delete.
+const (
+	// ModeAnonymize menghapus data pribadi tapi menyimpan row karyawan beserta
+	// absensi, pengajuan dan riwayat jabatan untuk statistik.
+	ModeAnonymize = "ANONYMIZE"
+	// ModeCascade menghapus karyawan beserta seluruh data yang merujuk ke dia.
+	ModeCascade = "CASCADE"
+)
+
+// Dependency is one foreign key column that references users, with the
+// number of rows pointing at the employee.
+type Dependency struct {
+	Table    string `json:"table"`
+	Column   string `json:"column"`
+	OnDelete string `json:"on_delete"` // CASCADE, SET NULL, NO ACTION, RESTRICT
+	Rows     int    `json:"rows"`
+	// Blocking rows make a plain DELETE FROM users fail.
+	Blocking bool `json:"blocking"`
+}
+
+// Report is the dependency report shown before a hard delete.
+type Report struct {
+	UserID       int64        `json:"user_id"`
+	EmployeeCode string       `json:"employee_code"`
+	Name         string       `json:"name"`
+	Status       string       `json:"status"`
+	Anonymized   bool         `json:"anonymized"`
+	Dependencies []Dependency `json:"dependencies"`
+	BlockingRows int          `json:"blocking_rows"`
+	// File yang ikut dihapus di kedua mode: dokumen, export dan lampiran
+	// pengajuan/pesan.
+	PersonalFiles   int `json:"personal_files"`
+	AttachmentFiles int `json:"attachment_files"`
+	// Pesan terkirim/diterima: isinya dihapus (ANONYMIZE) atau row dihapus
+	// (CASCADE).
+	Messages int `json:"messages"`
+}
+
+// Result is returned after a hard delete.
+type Result struct {
+	Mode         string `json:"mode"`
+	UserID       int64  `json:"user_id"`
+	EmployeeCode string `json:"employee_code"` // kode setelah proses (ANON... untuk anonymize)
+	FilesRemoved int    `json:"files_removed"`
+}
+
+// employee is the minimal users row the erasure works on.
+type employee struct {
+	ID         int64
+	Code       string
+	Name       string
+	Status     string
+	Anonymized bool
+}
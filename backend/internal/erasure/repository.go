@@ -0,0 +1,245 @@
+package erasure
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// dbtx is satisfied by both *sql.DB and *sql.Tx.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type Repository struct {
+	db   dbtx
+	conn *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db, conn: db}
+}
+
+func (r *Repository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return r.conn.BeginTx(ctx, nil)
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx.
+func (r *Repository) WithTx(tx *sql.Tx) *Repository {
+	return &Repository{db: tx, conn: r.conn}
+}
+
+const employeeSelect = `
+	SELECT id, employee_code, name, COALESCE(status, ''), anonymized_at IS NOT NULL
+	FROM users
+`
+
+func (r *Repository) findEmployee(ctx context.Context, query string, arg any) (*employee, error) {
+	var e employee
+	err := r.db.QueryRowContext(ctx, query, arg).Scan(&e.ID, &e.Code, &e.Name, &e.Status, &e.Anonymized)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *Repository) FindByID(ctx context.Context, id int64) (*employee, error) {
+	return r.findEmployee(ctx, employeeSelect+` WHERE id = $1`, id)
+}
+
+func (r *Repository) FindByCode(ctx context.Context, code string) (*employee, error) {
+	return r.findEmployee(ctx, employeeSelect+` WHERE employee_code = $1`, code)
+}
+
+// LockEmployee locks the users row for the rest of the transaction.
+func (r *Repository) LockEmployee(ctx context.Context, id int64) (*employee, error) {
+	return r.findEmployee(ctx, employeeSelect+` WHERE id = $1 FOR UPDATE`, id)
+}
+
+// Dependencies lists every foreign key that references users (dibaca dari
+// katalog supaya tabel baru otomatis ikut) with the rows pointing at userID.
+func (r *Repository) Dependencies(ctx context.Context, userID int64) ([]Dependency, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT c.conrelid::regclass::text, a.attname,
+			CASE c.confdeltype
+				WHEN 'c' THEN 'CASCADE'
+				WHEN 'n' THEN 'SET NULL'
+				WHEN 'd' THEN 'SET DEFAULT'
+				WHEN 'r' THEN 'RESTRICT'
+				ELSE 'NO ACTION'
+			END
+		FROM pg_constraint c
+		JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = c.conkey[1]
+		WHERE c.contype = 'f' AND c.confrelid = 'users'::regclass
+		ORDER BY 1, 2
+	`)
+	if err != nil {
+		return nil, err
+	}
+	var deps []Dependency
+	for rows.Next() {
+		var d Dependency
+		if err := rows.Scan(&d.Table, &d.Column, &d.OnDelete); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		deps = append(deps, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range deps {
+		d := &deps[i]
+		// Nama tabel dari regclass sudah di-quote bila perlu.
+		q := `SELECT COUNT(*) FROM ` + d.Table + ` WHERE ` + pq.QuoteIdentifier(d.Column) + ` = $1`
+		if err := r.db.QueryRowContext(ctx, q, userID).Scan(&d.Rows); err != nil {
+			return nil, err
+		}
+		d.Blocking = d.Rows > 0 && (d.OnDelete == "NO ACTION" || d.OnDelete == "RESTRICT")
+	}
+	return deps, nil
+}
+
+func (r *Repository) storageKeys(ctx context.Context, query string, userID int64) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// PersonalKeys returns the storage keys of the employee's own files: all
+// document versions and data export ZIPs.
+func (r *Repository) PersonalKeys(ctx context.Context, userID int64) ([]string, error) {
+	return r.storageKeys(ctx, `
+		SELECT v.storage_key
+		FROM employee_document_versions v
+		JOIN employee_documents d ON d.id = v.document_id
+		WHERE d.user_id = $1
+		UNION ALL
+		SELECT storage_key FROM data_export_jobs
+		WHERE user_id = $1 AND storage_key IS NOT NULL
+	`, userID)
+}
+
+// attachmentsOfUser matches the attachments of the employee's messages and
+// requests (mis. surat dokter); dihapus di kedua mode.
+const attachmentsOfUser = `
+	(owner_type = 'MESSAGE' AND owner_id IN (SELECT id FROM messages WHERE sender_id = $1 OR receiver_id = $1))
+	OR (owner_type = 'REQUEST' AND owner_id IN (SELECT id FROM requests WHERE user_id = $1))
+`
+
+// AttachmentKeys returns the storage keys of the attachments of the
+// employee's messages and requests.
+func (r *Repository) AttachmentKeys(ctx context.Context, userID int64) ([]string, error) {
+	return r.storageKeys(ctx, `SELECT storage_key FROM attachments WHERE `+attachmentsOfUser, userID)
+}
+
+// messagesOfUser matches the messages sent or received by the employee.
+const messagesOfUser = `sender_id = $1 OR receiver_id = $1`
+
+// CountMessages returns how many messages are sent or received by the
+// employee; isinya dihapus (anonymize) atau row-nya dihapus (cascade).
+func (r *Repository) CountMessages(ctx context.Context, userID int64) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM messages WHERE `+messagesOfUser, userID).Scan(&n)
+	return n, err
+}
+
+func (r *Repository) execAll(ctx context.Context, userID int64, stmts []string) error {
+	for _, q := range stmts {
+		if _, err := r.db.ExecContext(ctx, q, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// celebrationAnnouncements selects the auto-generated birthday/anniversary
+// announcements about the user; isinya memuat nama, jadi ikut dihapus.
+const celebrationAnnouncements = `SELECT announcement_id FROM celebration_announcements WHERE user_id = $1`
+
+// Anonymize scrubs the personal data of the employee. Absensi, pengajuan,
+// kontrak, riwayat jabatan dan pesan tetap ada dan menunjuk ke row anonim;
+// department/branch/job_title/join_date disimpan untuk statistik. Lampiran
+// pengajuan/pesan dan isi pesan ikut dihapus.
+func (r *Repository) Anonymize(ctx context.Context, userID int64) error {
+	return r.execAll(ctx, userID, []string{
+		`DELETE FROM attachments WHERE ` + attachmentsOfUser,
+		`UPDATE attachments SET uploaded_by = NULL WHERE uploaded_by = $1`,
+		`UPDATE messages SET subject = NULL, body = NULL WHERE ` + messagesOfUser,
+		`DELETE FROM employee_documents WHERE user_id = $1`,
+		`DELETE FROM data_export_jobs WHERE user_id = $1`,
+		`DELETE FROM profile_change_requests WHERE user_id = $1`,
+		`DELETE FROM dependent_change_requests WHERE user_id = $1`,
+		`DELETE FROM employee_dependents WHERE user_id = $1`,
+		`DELETE FROM employee_sensitive_data WHERE user_id = $1`,
+		`DELETE FROM employee_skills WHERE user_id = $1`,
+		`DELETE FROM employee_certifications WHERE user_id = $1`,
+		`DELETE FROM announcement_reads WHERE announcement_id IN (` + celebrationAnnouncements + `)`,
+		`DELETE FROM announcements WHERE id IN (` + celebrationAnnouncements + `)`,
+		`DELETE FROM celebration_preferences WHERE user_id = $1`,
+		`DELETE FROM notification_preferences WHERE user_id = $1`,
+		`DELETE FROM mail_outbox WHERE user_id = $1`,
+		`DELETE FROM user_roles WHERE user_id = $1`,
+		`UPDATE users SET manager_id = NULL WHERE manager_id = $1`,
+		`UPDATE departments SET head_user_id = NULL WHERE head_user_id = $1`,
+		`UPDATE users SET
+			employee_code = 'ANON' || id,
+			name = 'Anonymized employee',
+			email = 'anonymized+' || id || '@invalid',
+			password_hash = '!',
+			phone = NULL,
+			address = NULL,
+			birth_date = NULL,
+			gender = NULL,
+			photo_url = NULL,
+			emergency_contact = NULL,
+			emergency_phone = NULL,
+			manager_id = NULL,
+			custom_fields = '{}',
+			roles = '{}',
+			status = 'TERMINATED',
+			tokens_valid_after = NOW(),
+			anonymized_at = NOW()
+		WHERE id = $1`,
+	})
+}
+
+// Cascade deletes the employee and everything that references them, in an
+// order that satisfies the foreign keys without ON DELETE CASCADE. Data milik
+// orang lain (pengumuman, broadcast, approval) hanya dilepas dari user ini.
+// Tabel dengan ON DELETE CASCADE/SET NULL ditangani database.
+func (r *Repository) Cascade(ctx context.Context, userID int64) error {
+	return r.execAll(ctx, userID, []string{
+		`DELETE FROM attachments WHERE ` + attachmentsOfUser,
+		`UPDATE attachments SET uploaded_by = NULL WHERE uploaded_by = $1`,
+		`UPDATE messages SET parent_id = NULL
+		WHERE parent_id IN (SELECT id FROM messages WHERE ` + messagesOfUser + `)`,
+		`DELETE FROM messages WHERE ` + messagesOfUser,
+		`DELETE FROM announcement_reads WHERE user_id = $1`,
+		`DELETE FROM announcement_reads WHERE announcement_id IN (` + celebrationAnnouncements + `)`,
+		`DELETE FROM announcements WHERE id IN (` + celebrationAnnouncements + `)`,
+		`UPDATE announcements SET created_by = NULL WHERE created_by = $1`,
+		`UPDATE broadcasts SET sender_id = NULL WHERE sender_id = $1`,
+		`DELETE FROM requests WHERE user_id = $1`,
+		`UPDATE requests SET approver_id = NULL WHERE approver_id = $1`,
+		`DELETE FROM mail_outbox WHERE user_id = $1`,
+		`DELETE FROM users WHERE id = $1`,
+	})
+}
@@ -0,0 +1,263 @@
+package erasure
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"hr-portal-backend/pkg/storage"
+)
+
+var (
+	ErrNotFound          = errors.New("employee not found")
+	ErrStillActive       = errors.New("employee must be deactivated before hard delete")
+	ErrAlreadyAnonymized = errors.New("employee is already anonymized")
+	ErrBlocked           = errors.New("employee still has dependent data")
+)
+
+// ValidationError is returned for invalid input; handler mengembalikan 400.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string { return e.Message }
+
+func invalid(msg string) error { return &ValidationError{Message: msg} }
+
+// Action audit log.
+const (
+	ActionAnonymized = "EMPLOYEE_ANONYMIZED"
+	ActionDeleted    = "EMPLOYEE_DELETED"
+)
+
+// AuditLogger is satisfied by *audit.Service.
+type AuditLogger interface {
+	LogTx(ctx context.Context, tx *sql.Tx, actorID int64, action, entity, entityID string, details any) error
+}
+
+// PhotoFiles is satisfied by *photo.Service.
+type PhotoFiles interface {
+	DeleteFiles(ctx context.Context, userID int64) error
+}
+
+type Service struct {
+	repo   *Repository
+	store  storage.Storage
+	photos PhotoFiles
+	audit  AuditLogger
+}
+
+func NewService(repo *Repository, store storage.Storage, photos PhotoFiles, audit AuditLogger) *Service {
+	return &Service{repo: repo, store: store, photos: photos, audit: audit}
+}
+
+func normalizeCode(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return "", invalid("invalid employee code")
+	}
+	return code, nil
+}
+
+// ==========================
+// Dependency report
+// ==========================
+
+func (s *Service) Report(ctx context.Context, id int64) (*Report, error) {
+	return s.report(ctx, func(repo *Repository) (*employee, error) {
+		return repo.FindByID(ctx, id)
+	})
+}
+
+func (s *Service) ReportByCode(ctx context.Context, code string) (*Report, error) {
+	code, err := normalizeCode(code)
+	if err != nil {
+		return nil, err
+	}
+	return s.report(ctx, func(repo *Repository) (*employee, error) {
+		return repo.FindByCode(ctx, code)
+	})
+}
+
+func (s *Service) report(ctx context.Context, find func(*Repository) (*employee, error)) (*Report, error) {
+	e, err := find(s.repo)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	deps, err := s.repo.Dependencies(ctx, e.ID)
+	if err != nil {
+		return nil, err
+	}
+	files, err := s.repo.PersonalKeys(ctx, e.ID)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := s.repo.AttachmentKeys(ctx, e.ID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := s.repo.CountMessages(ctx, e.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rep := &Report{
+		UserID:          e.ID,
+		EmployeeCode:    e.Code,
+		Name:            e.Name,
+		Status:          e.Status,
+		Anonymized:      e.Anonymized,
+		Dependencies:    deps,
+		PersonalFiles:   len(files),
+		AttachmentFiles: len(attachments),
+		Messages:        messages,
+	}
+	for _, d := range deps {
+		if d.Blocking {
+			rep.BlockingRows += d.Rows
+		}
+	}
+	return rep, nil
+}
+
+// ==========================
+// Hard delete
+// ==========================
+
+func (s *Service) HardDelete(ctx context.Context, actorID, id int64, mode string) (*Result, error) {
+	return s.erase(ctx, actorID, mode, func(repo *Repository) (*employee, error) {
+		return repo.FindByID(ctx, id)
+	})
+}
+
+func (s *Service) HardDeleteByCode(ctx context.Context, actorID int64, code, mode string) (*Result, error) {
+	code, err := normalizeCode(code)
+	if err != nil {
+		return nil, err
+	}
+	return s.erase(ctx, actorID, mode, func(repo *Repository) (*employee, error) {
+		return repo.FindByCode(ctx, code)
+	})
+}
+
+// erase anonymizes or cascade-deletes an employee in one transaction together
+// with the audit entry. File di storage baru dihapus setelah commit.
+func (s *Service) erase(ctx context.Context, actorID int64, mode string, find func(*Repository) (*employee, error)) (*Result, error) {
+	mode = strings.ToUpper(strings.TrimSpace(mode))
+	if mode == "" {
+		mode = ModeAnonymize
+	}
+	if mode != ModeAnonymize && mode != ModeCascade {
+		return nil, invalid("mode must be ANONYMIZE or CASCADE")
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	e, err := find(repo)
+	if err == nil {
+		e, err = repo.LockEmployee(ctx, e.ID)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	switch {
+	case e.ID == actorID:
+		return nil, invalid("you cannot delete your own account")
+	case e.Status == "ACTIVE":
+		return nil, ErrStillActive
+	case mode == ModeAnonymize && e.Anonymized:
+		return nil, ErrAlreadyAnonymized
+	}
+
+	deps, err := repo.Dependencies(ctx, e.ID)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := repo.PersonalKeys(ctx, e.ID)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := repo.AttachmentKeys(ctx, e.ID)
+	if err != nil {
+		return nil, err
+	}
+	keys = append(keys, attachments...)
+	messages, err := repo.CountMessages(ctx, e.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	action := ActionAnonymized
+	if mode == ModeCascade {
+		action = ActionDeleted
+		err = repo.Cascade(ctx, e.ID)
+	} else {
+		err = repo.Anonymize(ctx, e.ID)
+	}
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			// Tabel baru yang belum ditangani Cascade.
+			return nil, fmt.Errorf("%w: %s", ErrBlocked, pqErr.Detail)
+		}
+		return nil, err
+	}
+
+	rows := map[string]int{}
+	for _, d := range deps {
+		if d.Rows > 0 {
+			rows[d.Table+"."+d.Column] = d.Rows
+		}
+	}
+	details := map[string]any{
+		"mode":          mode,
+		"employee_code": e.Code,
+		"rows":          rows,
+		"files":         len(keys),
+		"attachments":   len(attachments),
+		"messages":      messages,
+	}
+	if err := s.audit.LogTx(ctx, tx, actorID, action, "user", strconv.FormatInt(e.ID, 10), details); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	s.removeFiles(ctx, e.ID, keys)
+	res := &Result{Mode: mode, UserID: e.ID, EmployeeCode: e.Code, FilesRemoved: len(keys)}
+	if mode == ModeAnonymize {
+		res.EmployeeCode = "ANON" + strconv.FormatInt(e.ID, 10)
+	}
+	return res, nil
+}
+
+// removeFiles deletes stored files after the data is gone. Kegagalan hanya
+// dicatat; row di database sudah tidak menunjuk ke file tersebut.
+func (s *Service) removeFiles(ctx context.Context, userID int64, keys []string) {
+	for _, k := range keys {
+		if err := s.store.Delete(ctx, k); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("erasure: delete file %s: %v", k, err)
+		}
+	}
+	if err := s.photos.DeleteFiles(ctx, userID); err != nil {
+		log.Printf("erasure: delete photos of user %d: %v", userID, err)
+	}
+}
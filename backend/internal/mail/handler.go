@@ -0,0 +1,56 @@
+package mail
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// GET /api/me/notification-preferences
+func (h *Handler) GetMyPreferences(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+
+	prefs, err := h.svc.GetPreferences(c.Context(), userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch notification preferences")
+	}
+	return c.JSON(prefs)
+}
+
+// PUT /api/me/notification-preferences
+// Body: {"REQUEST_APPROVED": true, "REQUEST_REJECTED": false}
+func (h *Handler) UpdateMyPreferences(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+
+	var in map[string]bool
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+
+	if err := h.svc.UpdatePreferences(c.Context(), userID, in); err != nil {
+		if errors.Is(err, ErrUnknownEvent) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to update notification preferences")
+	}
+
+	prefs, err := h.svc.GetPreferences(c.Context(), userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch notification preferences")
+	}
+	return c.JSON(prefs)
+}
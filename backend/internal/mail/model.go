@@ -0,0 +1,70 @@
+package mail
+
+import "time"
+
+// Event codes yang bisa memicu email. Kode ini juga dipakai sebagai nama
+// template di folder templates/ (dalam huruf kecil).
+const (
+	EventRequestApproved        = "REQUEST_APPROVED"
+	EventRequestRejected        = "REQUEST_REJECTED"
+	EventDocumentsExpiring      = "DOCUMENTS_EXPIRING"
+	EventContractsDue           = "CONTRACTS_DUE"
+	EventCertificationsExpiring = "CERTIFICATIONS_EXPIRING"
+)
+
+// EventInfo describes an event users can opt in or out of.
+type EventInfo struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Events is the catalog shown on the notification preferences page.
+var Events = []EventInfo{
+	{Code: EventRequestApproved, Name: "Request approved", Description: "Pengajuan cuti/lembur disetujui"},
+	{Code: EventRequestRejected, Name: "Request rejected", Description: "Pengajuan cuti/lembur ditolak"},
+	{Code: EventDocumentsExpiring, Name: "Documents expiring", Description: "Ringkasan harian dokumen karyawan yang akan/sudah kedaluwarsa (HR)"},
+	{Code: EventContractsDue, Name: "Contracts & probation due", Description: "Ringkasan harian kontrak yang akan berakhir dan masa percobaan yang perlu dievaluasi (HR)"},
+	{Code: EventCertificationsExpiring, Name: "Certifications expiring", Description: "Ringkasan harian sertifikat karyawan yang akan/sudah kedaluwarsa (HR)"},
+}
+
+func knownEvent(code string) bool {
+	for _, e := range Events {
+		if e.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification is what business code hands to the mail service.
+type Notification struct {
+	Event  string
+	UserID int64
+	Data   map[string]any
+}
+
+// OutboxMessage adalah representasi row di tabel "mail_outbox".
+type OutboxMessage struct {
+	ID            int64      `json:"id"`
+	Event         string     `json:"event"`
+	UserID        *int64     `json:"user_id,omitempty"`
+	ToAddress     string     `json:"to_address"`
+	Subject       string     `json:"subject"`
+	TextBody      string     `json:"-"`
+	HTMLBody      string     `json:"-"`
+	Status        string     `json:"status"` // PENDING, SENT, FAILED
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+}
+
+// Preference is a single event toggle for a user.
+type Preference struct {
+	Event        string `json:"event"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	EmailEnabled bool   `json:"email_enabled"`
+}
@@ -0,0 +1,173 @@
+package mail
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Repository membungkus akses ke tabel mail_outbox dan notification_preferences.
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+const outboxSelectColumns = `
+	id, event, user_id, to_address, subject,
+	COALESCE(text_body, ''), COALESCE(html_body, ''),
+	status, attempts, COALESCE(last_error, ''), next_attempt_at, created_at, sent_at
+`
+
+func scanOutbox(row interface{ Scan(dest ...any) error }) (*OutboxMessage, error) {
+	var m OutboxMessage
+	var userID sql.NullInt64
+	var sentAt sql.NullTime
+	err := row.Scan(
+		&m.ID, &m.Event, &userID, &m.ToAddress, &m.Subject,
+		&m.TextBody, &m.HTMLBody,
+		&m.Status, &m.Attempts, &m.LastError, &m.NextAttemptAt, &m.CreatedAt, &sentAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if userID.Valid {
+		uid := userID.Int64
+		m.UserID = &uid
+	}
+	if sentAt.Valid {
+		t := sentAt.Time
+		m.SentAt = &t
+	}
+	return &m, nil
+}
+
+// recipient returns name and email of a user, using the caller's transaction.
+func (r *Repository) recipient(ctx context.Context, q queryer, userID int64) (name, email string, err error) {
+	err = q.QueryRowContext(ctx, `SELECT name, email FROM users WHERE id = $1`, userID).Scan(&name, &email)
+	return name, email, err
+}
+
+// emailEnabled reports whether the user wants email for event.
+// Tanpa row preference berarti default aktif.
+func (r *Repository) emailEnabled(ctx context.Context, q queryer, userID int64, event string) (bool, error) {
+	var enabled bool
+	err := q.QueryRowContext(ctx, `
+		SELECT email_enabled FROM notification_preferences
+		WHERE user_id = $1 AND event = $2
+	`, userID, event).Scan(&enabled)
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return enabled, nil
+}
+
+// insertOutbox writes a message using q, so callers can pass the transaction
+// of the business change that triggered it.
+func (r *Repository) insertOutbox(ctx context.Context, q queryer, m *OutboxMessage) error {
+	return q.QueryRowContext(ctx, `
+		INSERT INTO mail_outbox (event, user_id, to_address, subject, text_body, html_body)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, status, next_attempt_at, created_at
+	`, m.Event, m.UserID, m.ToAddress, m.Subject, m.TextBody, m.HTMLBody,
+	).Scan(&m.ID, &m.Status, &m.NextAttemptAt, &m.CreatedAt)
+}
+
+// ClaimDue picks up to limit pending messages whose next attempt is due and
+// pushes their next_attempt_at forward by lease, so a crashed worker simply
+// lets the message be retried once the lease expires.
+func (r *Repository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*OutboxMessage, error) {
+	q := `
+		UPDATE mail_outbox
+		SET next_attempt_at = CURRENT_TIMESTAMP + ($2 * INTERVAL '1 second')
+		WHERE id IN (
+			SELECT id FROM mail_outbox
+			WHERE status = 'PENDING' AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + outboxSelectColumns
+	rows, err := r.db.QueryContext(ctx, q, limit, int(lease.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*OutboxMessage
+	for rows.Next() {
+		m, err := scanOutbox(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) MarkSent(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE mail_outbox
+		SET status = 'SENT', attempts = attempts + 1, sent_at = CURRENT_TIMESTAMP, last_error = NULL
+		WHERE id = $1
+	`, id)
+	return err
+}
+
+// MarkFailed records a failed attempt. The message is retried at nextAttempt,
+// or marked FAILED for good when giveUp is true.
+func (r *Repository) MarkFailed(ctx context.Context, id int64, sendErr string, nextAttempt time.Time, giveUp bool) error {
+	status := "PENDING"
+	if giveUp {
+		status = "FAILED"
+	}
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE mail_outbox
+		SET status = $1, attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+		WHERE id = $4
+	`, status, sendErr, nextAttempt, id)
+	return err
+}
+
+// GetPreferences returns the stored toggles of a user keyed by event.
+func (r *Repository) GetPreferences(ctx context.Context, userID int64) (map[string]bool, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT event, email_enabled FROM notification_preferences WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prefs := map[string]bool{}
+	for rows.Next() {
+		var event string
+		var enabled bool
+		if err := rows.Scan(&event, &enabled); err != nil {
+			return nil, err
+		}
+		prefs[event] = enabled
+	}
+	return prefs, rows.Err()
+}
+
+func (r *Repository) SetPreference(ctx context.Context, userID int64, event string, enabled bool) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO notification_preferences (user_id, event, email_enabled)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, event) DO UPDATE SET email_enabled = EXCLUDED.email_enabled
+	`, userID, event, enabled)
+	return err
+}
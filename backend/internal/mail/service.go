@@ -0,0 +1,137 @@
+package mail
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var ErrUnknownEvent = errors.New("unknown notification event")
+
+// PermissionHolders lists users by permission. Dipenuhi oleh *rbac.Repository.
+type PermissionHolders interface {
+	UsersWithPermission(ctx context.Context, codes ...string) ([]int64, error)
+}
+
+type Service struct {
+	repo    *Repository
+	holders PermissionHolders
+}
+
+func NewService(r *Repository, holders PermissionHolders) *Service {
+	return &Service{repo: r, holders: holders}
+}
+
+// NotifyTx renders the email for n and writes it to the outbox inside tx,
+// so the email is only queued if the business change commits.
+// Users who turned the event off are silently skipped.
+func (s *Service) NotifyTx(ctx context.Context, tx *sql.Tx, n Notification) error {
+	_, err := s.notify(ctx, tx, n)
+	return err
+}
+
+// Notify is NotifyTx for callers without a surrounding transaction.
+func (s *Service) Notify(ctx context.Context, n Notification) error {
+	_, err := s.notify(ctx, s.repo.db, n)
+	return err
+}
+
+// NotifyPermissionTx queues n (digest HR) for every active user holding one
+// of the permissions and returns how many emails were queued. 0 berarti
+// tidak ada yang menerima, jadi pemanggil jangan menandai alert terkirim.
+func (s *Service) NotifyPermissionTx(ctx context.Context, tx *sql.Tx, n Notification, permissions ...string) (int, error) {
+	ids, err := s.holders.UsersWithPermission(ctx, permissions...)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, uid := range ids {
+		n.UserID = uid
+		queued, err := s.notify(ctx, tx, n)
+		if err != nil {
+			return 0, err
+		}
+		if queued {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// notify reports whether an email was queued (false = user opted out).
+func (s *Service) notify(ctx context.Context, q queryer, n Notification) (bool, error) {
+	if !knownEvent(n.Event) {
+		return false, fmt.Errorf("%w: %s", ErrUnknownEvent, n.Event)
+	}
+
+	enabled, err := s.repo.emailEnabled(ctx, q, n.UserID, n.Event)
+	if err != nil {
+		return false, err
+	}
+	if !enabled {
+		return false, nil
+	}
+
+	name, email, err := s.repo.recipient(ctx, q, n.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	data := map[string]any{"Name": name}
+	for k, v := range n.Data {
+		data[k] = v
+	}
+	subject, text, html, err := render(n.Event, data)
+	if err != nil {
+		return false, err
+	}
+
+	uid := n.UserID
+	err = s.repo.insertOutbox(ctx, q, &OutboxMessage{
+		Event:     n.Event,
+		UserID:    &uid,
+		ToAddress: email,
+		Subject:   subject,
+		TextBody:  text,
+		HTMLBody:  html,
+	})
+	return err == nil, err
+}
+
+// GetPreferences returns every known event with the user's choice applied.
+func (s *Service) GetPreferences(ctx context.Context, userID int64) ([]Preference, error) {
+	stored, err := s.repo.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	prefs := make([]Preference, 0, len(Events))
+	for _, e := range Events {
+		enabled, ok := stored[e.Code]
+		if !ok {
+			enabled = true
+		}
+		prefs = append(prefs, Preference{
+			Event:        e.Code,
+			Name:         e.Name,
+			Description:  e.Description,
+			EmailEnabled: enabled,
+		})
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences stores the given toggles; unknown events are rejected.
+func (s *Service) UpdatePreferences(ctx context.Context, userID int64, prefs map[string]bool) error {
+	for event := range prefs {
+		if !knownEvent(event) {
+			return fmt.Errorf("%w: %s", ErrUnknownEvent, event)
+		}
+	}
+	for event, enabled := range prefs {
+		if err := s.repo.SetPreference(ctx, userID, event, enabled); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*.html templates/*.txt
+var templateFS embed.FS
+
+var (
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/*.html"))
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/*.txt"))
+)
+
+// render executes the subject, text and html templates for an event.
+// Template <event>.txt wajib mendefinisikan blok "<event>_subject".
+func render(event string, data map[string]any) (subject, text, html string, err error) {
+	name := strings.ToLower(event)
+
+	var buf bytes.Buffer
+	if err = textTemplates.ExecuteTemplate(&buf, name+"_subject", data); err != nil {
+		return "", "", "", fmt.Errorf("render subject %s: %w", name, err)
+	}
+	subject = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	if err = textTemplates.ExecuteTemplate(&buf, name+".txt", data); err != nil {
+		return "", "", "", fmt.Errorf("render text %s: %w", name, err)
+	}
+	text = buf.String()
+
+	buf.Reset()
+	if err = htmlTemplates.ExecuteTemplate(&buf, name+".html", data); err != nil {
+		return "", "", "", fmt.Errorf("render html %s: %w", name, err)
+	}
+	html = buf.String()
+
+	return subject, text, html, nil
+}
@@ -0,0 +1,81 @@
+package mail
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// WorkerConfig controls batching and retry behaviour of the outbox worker.
+type WorkerConfig struct {
+	BatchSize   int
+	MaxAttempts int
+	// BaseBackoff is doubled after every failed attempt.
+	BaseBackoff time.Duration
+	// Lease is how long a claimed message stays invisible to other workers.
+	Lease time.Duration
+}
+
+// Outbox is the queue the worker drains; dipenuhi oleh *Repository.
+type Outbox interface {
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*OutboxMessage, error)
+	MarkSent(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, sendErr string, nextAttempt time.Time, giveUp bool) error
+}
+
+// Worker delivers queued outbox messages through a Sender.
+type Worker struct {
+	repo   Outbox
+	sender Sender
+	cfg    WorkerConfig
+}
+
+func NewWorker(repo Outbox, sender Sender, cfg WorkerConfig) *Worker {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 20
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = time.Minute
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = 5 * time.Minute
+	}
+	return &Worker{repo: repo, sender: sender, cfg: cfg}
+}
+
+// ProcessBatch sends one batch of due messages. Meant to be run periodically
+// via scheduler.Every.
+func (w *Worker) ProcessBatch(ctx context.Context) error {
+	msgs, err := w.repo.ClaimDue(ctx, w.cfg.BatchSize, w.cfg.Lease)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range msgs {
+		if err := w.sender.Send(m); err != nil {
+			attempt := m.Attempts + 1
+			giveUp := attempt >= w.cfg.MaxAttempts
+			next := time.Now().Add(w.backoff(attempt))
+			log.Printf("mail outbox %d to %s failed (attempt %d): %v", m.ID, m.ToAddress, attempt, err)
+			if err := w.repo.MarkFailed(ctx, m.ID, err.Error(), next, giveUp); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := w.repo.MarkSent(ctx, m.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Worker) backoff(attempt int) time.Duration {
+	d := w.cfg.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+	}
+	return d
+}
@@ -0,0 +1,233 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpSink is a minimal local SMTP server that records delivered messages,
+// mirip Mailpit/MailHog untuk development.
+type smtpSink struct {
+	ln       net.Listener
+	rejectTo string
+
+	mu   sync.Mutex
+	msgs []sinkMessage
+}
+
+type sinkMessage struct {
+	from, to, data string
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &smtpSink{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpSink) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpSink) messages() []sinkMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]sinkMessage(nil), s.msgs...)
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	var cur sinkMessage
+	reply("220 sink ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 sink")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			cur = sinkMessage{from: strings.Trim(line[len("MAIL FROM:"):], "<> ")}
+			reply("250 ok")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			cur.to = strings.Trim(line[len("RCPT TO:"):], "<> ")
+			if cur.to == s.rejectTo {
+				reply("550 mailbox unavailable")
+				continue
+			}
+			reply("250 ok")
+		case cmd == "DATA":
+			reply("354 end with .")
+			var b strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				b.WriteString(l)
+			}
+			cur.data = b.String()
+			s.mu.Lock()
+			s.msgs = append(s.msgs, cur)
+			s.mu.Unlock()
+			reply("250 queued")
+		case cmd == "RSET", cmd == "NOOP":
+			reply("250 ok")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+// fakeOutbox records what the worker does with claimed messages.
+type fakeOutbox struct {
+	due    []*OutboxMessage
+	sent   []int64
+	failed map[int64]failure
+}
+
+type failure struct {
+	err    string
+	next   time.Time
+	giveUp bool
+}
+
+func (f *fakeOutbox) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*OutboxMessage, error) {
+	n := min(limit, len(f.due))
+	out := f.due[:n]
+	f.due = f.due[n:]
+	return out, nil
+}
+
+func (f *fakeOutbox) MarkSent(ctx context.Context, id int64) error {
+	f.sent = append(f.sent, id)
+	return nil
+}
+
+func (f *fakeOutbox) MarkFailed(ctx context.Context, id int64, sendErr string, nextAttempt time.Time, giveUp bool) error {
+	if f.failed == nil {
+		f.failed = map[int64]failure{}
+	}
+	f.failed[id] = failure{err: sendErr, next: nextAttempt, giveUp: giveUp}
+	return nil
+}
+
+func TestWorkerDeliversToSMTPSink(t *testing.T) {
+	sink := newSMTPSink(t)
+	outbox := &fakeOutbox{due: []*OutboxMessage{{
+		ID:        1,
+		ToAddress: "budi@example.com",
+		Subject:   "Pengajuan cuti disetujui",
+		TextBody:  "Halo Budi",
+		HTMLBody:  "<p>Halo Budi</p>",
+	}}}
+	sender := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: sink.port(), From: "hr@example.com"})
+	w := NewWorker(outbox, sender, WorkerConfig{})
+
+	if err := w.ProcessBatch(context.Background()); err != nil {
+		t.Fatalf("ProcessBatch: %v", err)
+	}
+	if len(outbox.sent) != 1 || outbox.sent[0] != 1 {
+		t.Fatalf("sent = %v, want [1]", outbox.sent)
+	}
+	msgs := sink.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("sink received %d messages, want 1", len(msgs))
+	}
+	m := msgs[0]
+	if m.from != "hr@example.com" || m.to != "budi@example.com" {
+		t.Errorf("envelope = %q -> %q", m.from, m.to)
+	}
+	for _, want := range []string{"To: budi@example.com", "multipart/alternative", "Halo Budi", "<p>Halo Budi</p>"} {
+		if !strings.Contains(m.data, want) {
+			t.Errorf("message does not contain %q", want)
+		}
+	}
+}
+
+func TestWorkerRetriesWithBackoff(t *testing.T) {
+	sink := newSMTPSink(t)
+	sink.rejectTo = "gone@example.com"
+	outbox := &fakeOutbox{due: []*OutboxMessage{
+		{ID: 1, ToAddress: "gone@example.com", Subject: "x", Attempts: 0},
+		{ID: 2, ToAddress: "gone@example.com", Subject: "x", Attempts: 2},
+		{ID: 3, ToAddress: "ok@example.com", Subject: "x"},
+	}}
+	sender := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: sink.port(), From: "hr@example.com"})
+	w := NewWorker(outbox, sender, WorkerConfig{MaxAttempts: 3, BaseBackoff: time.Minute})
+
+	start := time.Now()
+	if err := w.ProcessBatch(context.Background()); err != nil {
+		t.Fatalf("ProcessBatch: %v", err)
+	}
+	if len(outbox.sent) != 1 || outbox.sent[0] != 3 {
+		t.Fatalf("sent = %v, want [3]", outbox.sent)
+	}
+
+	first, ok := outbox.failed[1]
+	if !ok || first.giveUp {
+		t.Fatalf("message 1: %+v, want retry", first)
+	}
+	if d := first.next.Sub(start); d < time.Minute || d > 2*time.Minute {
+		t.Errorf("message 1 retry after %v, want ~1m", d)
+	}
+	if !strings.Contains(first.err, "550") {
+		t.Errorf("message 1 error = %q, want SMTP 550", first.err)
+	}
+
+	last, ok := outbox.failed[2]
+	if !ok || !last.giveUp {
+		t.Fatalf("message 2: %+v, want give up after max attempts", last)
+	}
+	if d := last.next.Sub(start); d < 4*time.Minute {
+		t.Errorf("message 2 retry after %v, want backoff doubled to 4m", d)
+	}
+}
+
+func TestWorkerBatchSize(t *testing.T) {
+	sink := newSMTPSink(t)
+	outbox := &fakeOutbox{}
+	for i := int64(1); i <= 3; i++ {
+		outbox.due = append(outbox.due, &OutboxMessage{ID: i, ToAddress: "a@example.com", Subject: "x"})
+	}
+	sender := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: sink.port(), From: "hr@example.com"})
+	w := NewWorker(outbox, sender, WorkerConfig{BatchSize: 2})
+
+	if err := w.ProcessBatch(context.Background()); err != nil {
+		t.Fatalf("ProcessBatch: %v", err)
+	}
+	if len(outbox.sent) != 2 || len(outbox.due) != 1 {
+		t.Fatalf("sent %v, left %d; want 2 sent and 1 left for the next batch", outbox.sent, len(outbox.due))
+	}
+}
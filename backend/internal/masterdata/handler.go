@@ -0,0 +1,212 @@
+package masterdata
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func toFiberError(err error, fallback string) error {
+	var vErr *ValidationError
+	switch {
+	case errors.As(err, &vErr):
+		return fiber.NewError(fiber.StatusBadRequest, vErr.Message)
+	case errors.Is(err, ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, ErrDuplicate):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, fallback)
+}
+
+func parseID(c *fiber.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	return id, nil
+}
+
+// ==========================
+// Departments
+// ==========================
+
+// GET /api/departments?include_inactive=true
+func (h *Handler) ListDepartments(c *fiber.Ctx) error {
+	items, err := h.svc.ListDepartments(c.Context(), c.QueryBool("include_inactive"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch departments")
+	}
+	return c.JSON(items)
+}
+
+// GET /api/departments/:code
+func (h *Handler) GetDepartment(c *fiber.Ctx) error {
+	d, err := h.svc.GetDepartment(c.Context(), c.Params("code"))
+	if err != nil {
+		return toFiberError(err, "failed to fetch department")
+	}
+	return c.JSON(d)
+}
+
+// POST /api/departments
+func (h *Handler) CreateDepartment(c *fiber.Ctx) error {
+	var in Department
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	d, err := h.svc.CreateDepartment(c.Context(), in)
+	if err != nil {
+		return toFiberError(err, "failed to create department")
+	}
+	return c.Status(fiber.StatusCreated).JSON(d)
+}
+
+// PUT /api/departments/:code
+func (h *Handler) UpdateDepartment(c *fiber.Ctx) error {
+	var in struct {
+		Department
+		IsActive *bool `json:"is_active"` // tidak dikirim = tidak berubah
+	}
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	d, err := h.svc.UpdateDepartment(c.Context(), c.Params("code"), in.Department, in.IsActive)
+	if err != nil {
+		return toFiberError(err, "failed to update department")
+	}
+	return c.JSON(d)
+}
+
+// DELETE /api/departments/:code - nonaktifkan, bukan hapus
+func (h *Handler) DeleteDepartment(c *fiber.Ctx) error {
+	if err := h.svc.DeactivateDepartment(c.Context(), c.Params("code")); err != nil {
+		return toFiberError(err, "failed to deactivate department")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ==========================
+// Branches
+// ==========================
+
+// GET /api/branches?include_inactive=true
+func (h *Handler) ListBranches(c *fiber.Ctx) error {
+	items, err := h.svc.ListBranches(c.Context(), c.QueryBool("include_inactive"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch branches")
+	}
+	return c.JSON(items)
+}
+
+// POST /api/branches
+func (h *Handler) CreateBranch(c *fiber.Ctx) error {
+	var in Branch
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	b, err := h.svc.CreateBranch(c.Context(), in)
+	if err != nil {
+		return toFiberError(err, "failed to create branch")
+	}
+	return c.Status(fiber.StatusCreated).JSON(b)
+}
+
+// PUT /api/branches/:id
+func (h *Handler) UpdateBranch(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	var in struct {
+		Branch
+		IsActive *bool `json:"is_active"` // tidak dikirim = tidak berubah
+	}
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	b, err := h.svc.UpdateBranch(c.Context(), id, in.Branch, in.IsActive)
+	if err != nil {
+		return toFiberError(err, "failed to update branch")
+	}
+	return c.JSON(b)
+}
+
+// DELETE /api/branches/:id
+func (h *Handler) DeleteBranch(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	if err := h.svc.DeactivateBranch(c.Context(), id); err != nil {
+		return toFiberError(err, "failed to deactivate branch")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ==========================
+// Job titles
+// ==========================
+
+// GET /api/job-titles?include_inactive=true
+func (h *Handler) ListJobTitles(c *fiber.Ctx) error {
+	items, err := h.svc.ListJobTitles(c.Context(), c.QueryBool("include_inactive"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch job titles")
+	}
+	return c.JSON(items)
+}
+
+// POST /api/job-titles
+func (h *Handler) CreateJobTitle(c *fiber.Ctx) error {
+	var in JobTitle
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	j, err := h.svc.CreateJobTitle(c.Context(), in)
+	if err != nil {
+		return toFiberError(err, "failed to create job title")
+	}
+	return c.Status(fiber.StatusCreated).JSON(j)
+}
+
+// PUT /api/job-titles/:id
+func (h *Handler) UpdateJobTitle(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	var in struct {
+		JobTitle
+		IsActive *bool `json:"is_active"` // tidak dikirim = tidak berubah
+	}
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	j, err := h.svc.UpdateJobTitle(c.Context(), id, in.JobTitle, in.IsActive)
+	if err != nil {
+		return toFiberError(err, "failed to update job title")
+	}
+	return c.JSON(j)
+}
+
+// DELETE /api/job-titles/:id
+func (h *Handler) DeleteJobTitle(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	if err := h.svc.DeactivateJobTitle(c.Context(), id); err != nil {
+		return toFiberError(err, "failed to deactivate job title")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,34 @@
+package masterdata
+
+import "time"
+
+// Department adalah representasi row di tabel "departments".
+// CodePrefix dipakai sebagai prefix employee code (mis. IT -> IT001).
+type Department struct {
+	Code       string    `json:"code"`
+	Name       string    `json:"name"`
+	HeadUserID *int64    `json:"head_user_id,omitempty"`
+	HeadName   string    `json:"head_name,omitempty"`
+	CodePrefix string    `json:"code_prefix"`
+	IsActive   bool      `json:"is_active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Branch adalah representasi row di tabel "branches".
+type Branch struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	TimeZone  string    `json:"time_zone"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// JobTitle adalah representasi row di tabel "job_titles".
+type JobTitle struct {
+	ID        int64     `json:"id"`
+	Title     string    `json:"title"`
+	Grade     string    `json:"grade"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+}
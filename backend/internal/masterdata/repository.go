@@ -0,0 +1,288 @@
+package masterdata
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Repository membungkus akses ke tabel departments, branches dan job_titles.
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// ==========================
+// Departments
+// ==========================
+
+const departmentSelectColumns = `
+	d.code, d.name, d.head_user_id, COALESCE(u.name, ''), d.code_prefix, d.is_active, d.created_at
+`
+
+func scanDepartment(row interface{ Scan(dest ...any) error }) (*Department, error) {
+	var d Department
+	var head sql.NullInt64
+	if err := row.Scan(&d.Code, &d.Name, &head, &d.HeadName, &d.CodePrefix, &d.IsActive, &d.CreatedAt); err != nil {
+		return nil, err
+	}
+	if head.Valid {
+		h := head.Int64
+		d.HeadUserID = &h
+	}
+	return &d, nil
+}
+
+func (r *Repository) ListDepartments(ctx context.Context, includeInactive bool) ([]Department, error) {
+	q := `
+		SELECT ` + departmentSelectColumns + `
+		FROM departments d
+		LEFT JOIN users u ON u.id = d.head_user_id
+		WHERE d.is_active OR $1
+		ORDER BY d.code
+	`
+	rows, err := r.db.QueryContext(ctx, q, includeInactive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []Department{}
+	for rows.Next() {
+		d, err := scanDepartment(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *d)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) FindDepartment(ctx context.Context, code string) (*Department, error) {
+	q := `
+		SELECT ` + departmentSelectColumns + `
+		FROM departments d
+		LEFT JOIN users u ON u.id = d.head_user_id
+		WHERE d.code = $1
+	`
+	return scanDepartment(r.db.QueryRowContext(ctx, q, code))
+}
+
+func (r *Repository) CreateDepartment(ctx context.Context, d *Department) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO departments (code, name, head_user_id, code_prefix)
+		VALUES ($1, $2, $3, $4)
+	`, d.Code, d.Name, d.HeadUserID, d.CodePrefix)
+	return err
+}
+
+// UpdateDepartment; active nil = status aktif tidak berubah.
+func (r *Repository) UpdateDepartment(ctx context.Context, d *Department, active *bool) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE departments
+		SET name = $1, head_user_id = $2, code_prefix = $3, is_active = COALESCE($4, is_active)
+		WHERE code = $5
+	`, d.Name, d.HeadUserID, d.CodePrefix, active, d.Code)
+	return checkAffected(res, err)
+}
+
+// DeactivateDepartment is a soft delete so existing users keep a valid reference.
+func (r *Repository) DeactivateDepartment(ctx context.Context, code string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE departments SET is_active = FALSE WHERE code = $1`, code)
+	return checkAffected(res, err)
+}
+
+// ==========================
+// Branches
+// ==========================
+
+const branchSelectColumns = `id, name, COALESCE(address, ''), time_zone, is_active, created_at`
+
+func scanBranch(row interface{ Scan(dest ...any) error }) (*Branch, error) {
+	var b Branch
+	if err := row.Scan(&b.ID, &b.Name, &b.Address, &b.TimeZone, &b.IsActive, &b.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *Repository) ListBranches(ctx context.Context, includeInactive bool) ([]Branch, error) {
+	q := `SELECT ` + branchSelectColumns + ` FROM branches WHERE is_active OR $1 ORDER BY name`
+	rows, err := r.db.QueryContext(ctx, q, includeInactive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []Branch{}
+	for rows.Next() {
+		b, err := scanBranch(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *b)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) FindBranch(ctx context.Context, id int64) (*Branch, error) {
+	q := `SELECT ` + branchSelectColumns + ` FROM branches WHERE id = $1`
+	return scanBranch(r.db.QueryRowContext(ctx, q, id))
+}
+
+func (r *Repository) CreateBranch(ctx context.Context, b *Branch) error {
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO branches (name, address, time_zone)
+		VALUES ($1, $2, $3)
+		RETURNING id, is_active, created_at
+	`, b.Name, b.Address, b.TimeZone).Scan(&b.ID, &b.IsActive, &b.CreatedAt)
+}
+
+// UpdateBranch also renames the branch on every user that references the old
+// name, so fixing a typo fixes the reports too. active nil = tidak berubah.
+func (r *Repository) UpdateBranch(ctx context.Context, b *Branch, active *bool) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldName string
+	if err := tx.QueryRowContext(ctx, `SELECT name FROM branches WHERE id = $1 FOR UPDATE`, b.ID).Scan(&oldName); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE branches SET name = $1, address = $2, time_zone = $3, is_active = COALESCE($4, is_active) WHERE id = $5
+	`, b.Name, b.Address, b.TimeZone, active, b.ID); err != nil {
+		return err
+	}
+	if oldName != b.Name {
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET branch = $1 WHERE branch = $2`, b.Name, oldName); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *Repository) DeactivateBranch(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE branches SET is_active = FALSE WHERE id = $1`, id)
+	return checkAffected(res, err)
+}
+
+// ==========================
+// Job titles
+// ==========================
+
+const jobTitleSelectColumns = `id, title, COALESCE(grade, ''), is_active, created_at`
+
+func scanJobTitle(row interface{ Scan(dest ...any) error }) (*JobTitle, error) {
+	var j JobTitle
+	if err := row.Scan(&j.ID, &j.Title, &j.Grade, &j.IsActive, &j.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+func (r *Repository) ListJobTitles(ctx context.Context, includeInactive bool) ([]JobTitle, error) {
+	q := `SELECT ` + jobTitleSelectColumns + ` FROM job_titles WHERE is_active OR $1 ORDER BY title`
+	rows, err := r.db.QueryContext(ctx, q, includeInactive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []JobTitle{}
+	for rows.Next() {
+		j, err := scanJobTitle(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *j)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) FindJobTitle(ctx context.Context, id int64) (*JobTitle, error) {
+	q := `SELECT ` + jobTitleSelectColumns + ` FROM job_titles WHERE id = $1`
+	return scanJobTitle(r.db.QueryRowContext(ctx, q, id))
+}
+
+func (r *Repository) CreateJobTitle(ctx context.Context, j *JobTitle) error {
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO job_titles (title, grade)
+		VALUES ($1, NULLIF($2, ''))
+		RETURNING id, is_active, created_at
+	`, j.Title, j.Grade).Scan(&j.ID, &j.IsActive, &j.CreatedAt)
+}
+
+// UpdateJobTitle renames the title on users as well, like UpdateBranch.
+func (r *Repository) UpdateJobTitle(ctx context.Context, j *JobTitle, active *bool) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldTitle string
+	if err := tx.QueryRowContext(ctx, `SELECT title FROM job_titles WHERE id = $1 FOR UPDATE`, j.ID).Scan(&oldTitle); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE job_titles SET title = $1, grade = NULLIF($2, ''), is_active = COALESCE($3, is_active) WHERE id = $4
+	`, j.Title, j.Grade, active, j.ID); err != nil {
+		return err
+	}
+	if oldTitle != j.Title {
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET job_title = $1 WHERE job_title = $2`, j.Title, oldTitle); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *Repository) DeactivateJobTitle(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE job_titles SET is_active = FALSE WHERE id = $1`, id)
+	return checkAffected(res, err)
+}
+
+// ==========================
+// Catalog (dipakai user.Service untuk validasi EmployeeInput)
+// ==========================
+
+// DepartmentPrefix returns the employee-code prefix of an active department.
+func (r *Repository) DepartmentPrefix(ctx context.Context, code string) (string, bool, error) {
+	var prefix string
+	err := r.db.QueryRowContext(ctx, `SELECT code_prefix FROM departments WHERE code = $1 AND is_active`, code).Scan(&prefix)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return prefix, true, nil
+}
+
+func (r *Repository) BranchExists(ctx context.Context, name string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM branches WHERE name = $1 AND is_active)`, name).Scan(&ok)
+	return ok, err
+}
+
+func (r *Repository) JobTitleExists(ctx context.Context, title string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM job_titles WHERE title = $1 AND is_active)`, title).Scan(&ok)
+	return ok, err
+}
+
+func checkAffected(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
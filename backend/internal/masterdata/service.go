@@ -0,0 +1,222 @@
+package masterdata
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrNotFound  = errors.New("master data not found")
+	ErrDuplicate = errors.New("master data already exists")
+)
+
+// ValidationError is returned for invalid input; handler mengembalikan 400.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string { return e.Message }
+
+func invalid(msg string) error { return &ValidationError{Message: msg} }
+
+var codePattern = regexp.MustCompile(`^[A-Z0-9]{1,10}$`)
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// mapError menerjemahkan error database ke error paket ini.
+func mapError(err error) error {
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505":
+			return ErrDuplicate
+		case "23503":
+			return invalid("referenced user does not exist")
+		}
+	}
+	return err
+}
+
+// ==========================
+// Departments
+// ==========================
+
+func (d *Department) sanitize() error {
+	d.Code = strings.ToUpper(strings.TrimSpace(d.Code))
+	d.Name = strings.TrimSpace(d.Name)
+	d.CodePrefix = strings.ToUpper(strings.TrimSpace(d.CodePrefix))
+	if d.CodePrefix == "" {
+		d.CodePrefix = d.Code
+	}
+	if !codePattern.MatchString(d.Code) {
+		return invalid("code must be 1-10 letters or digits")
+	}
+	if !codePattern.MatchString(d.CodePrefix) {
+		return invalid("code_prefix must be 1-10 letters or digits")
+	}
+	if d.Name == "" {
+		return invalid("name is required")
+	}
+	return nil
+}
+
+func (s *Service) ListDepartments(ctx context.Context, includeInactive bool) ([]Department, error) {
+	return s.repo.ListDepartments(ctx, includeInactive)
+}
+
+func (s *Service) GetDepartment(ctx context.Context, code string) (*Department, error) {
+	d, err := s.repo.FindDepartment(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return d, nil
+}
+
+func (s *Service) CreateDepartment(ctx context.Context, d Department) (*Department, error) {
+	if err := d.sanitize(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateDepartment(ctx, &d); err != nil {
+		return nil, mapError(err)
+	}
+	return s.GetDepartment(ctx, d.Code)
+}
+
+// UpdateDepartment updates everything except the code itself, which is the
+// value stored on users.department. active nil = status aktif tidak berubah.
+func (s *Service) UpdateDepartment(ctx context.Context, code string, d Department, active *bool) (*Department, error) {
+	d.Code = code
+	if err := d.sanitize(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateDepartment(ctx, &d, active); err != nil {
+		return nil, mapError(err)
+	}
+	return s.GetDepartment(ctx, d.Code)
+}
+
+func (s *Service) DeactivateDepartment(ctx context.Context, code string) error {
+	return mapError(s.repo.DeactivateDepartment(ctx, strings.ToUpper(strings.TrimSpace(code))))
+}
+
+// ==========================
+// Branches
+// ==========================
+
+func (b *Branch) sanitize() error {
+	b.Name = strings.TrimSpace(b.Name)
+	b.Address = strings.TrimSpace(b.Address)
+	b.TimeZone = strings.TrimSpace(b.TimeZone)
+	if b.Name == "" {
+		return invalid("name is required")
+	}
+	if b.TimeZone == "" {
+		b.TimeZone = "Asia/Jakarta"
+	}
+	if _, err := time.LoadLocation(b.TimeZone); err != nil {
+		return invalid("unknown time_zone " + b.TimeZone)
+	}
+	return nil
+}
+
+func (s *Service) ListBranches(ctx context.Context, includeInactive bool) ([]Branch, error) {
+	return s.repo.ListBranches(ctx, includeInactive)
+}
+
+func (s *Service) GetBranch(ctx context.Context, id int64) (*Branch, error) {
+	b, err := s.repo.FindBranch(ctx, id)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return b, nil
+}
+
+func (s *Service) CreateBranch(ctx context.Context, b Branch) (*Branch, error) {
+	if err := b.sanitize(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateBranch(ctx, &b); err != nil {
+		return nil, mapError(err)
+	}
+	return &b, nil
+}
+
+func (s *Service) UpdateBranch(ctx context.Context, id int64, b Branch, active *bool) (*Branch, error) {
+	b.ID = id
+	if err := b.sanitize(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateBranch(ctx, &b, active); err != nil {
+		return nil, mapError(err)
+	}
+	return s.GetBranch(ctx, id)
+}
+
+func (s *Service) DeactivateBranch(ctx context.Context, id int64) error {
+	return mapError(s.repo.DeactivateBranch(ctx, id))
+}
+
+// ==========================
+// Job titles
+// ==========================
+
+func (j *JobTitle) sanitize() error {
+	j.Title = strings.TrimSpace(j.Title)
+	j.Grade = strings.ToUpper(strings.TrimSpace(j.Grade))
+	if j.Title == "" {
+		return invalid("title is required")
+	}
+	return nil
+}
+
+func (s *Service) ListJobTitles(ctx context.Context, includeInactive bool) ([]JobTitle, error) {
+	return s.repo.ListJobTitles(ctx, includeInactive)
+}
+
+func (s *Service) GetJobTitle(ctx context.Context, id int64) (*JobTitle, error) {
+	j, err := s.repo.FindJobTitle(ctx, id)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return j, nil
+}
+
+func (s *Service) CreateJobTitle(ctx context.Context, j JobTitle) (*JobTitle, error) {
+	if err := j.sanitize(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateJobTitle(ctx, &j); err != nil {
+		return nil, mapError(err)
+	}
+	return &j, nil
+}
+
+func (s *Service) UpdateJobTitle(ctx context.Context, id int64, j JobTitle, active *bool) (*JobTitle, error) {
+	j.ID = id
+	if err := j.sanitize(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateJobTitle(ctx, &j, active); err != nil {
+		return nil, mapError(err)
+	}
+	return s.GetJobTitle(ctx, id)
+}
+
+func (s *Service) DeactivateJobTitle(ctx context.Context, id int64) error {
+	return mapError(s.repo.DeactivateJobTitle(ctx, id))
+}
@@ -0,0 +1,119 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Aturan umum: resource tidak ada -> ErrNotFound (404),
+// resource ada tapi user tidak berhak -> ErrForbidden (403).
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrForbidden = errors.New("forbidden")
+)
+
+// PermissionChecker is satisfied by *rbac.Repository.
+type PermissionChecker interface {
+	HasAnyPermission(ctx context.Context, roles []string, codes ...string) (bool, error)
+}
+
+// MessageAction is a mutation performed on a single inbox message.
+type MessageAction int
+
+const (
+	// ActionMarkRead, ActionDelete and the inbox flags only make sense for
+	// the receiver: the row is the receiver's copy of the message.
+	ActionMarkRead MessageAction = iota
+	ActionDelete
+	ActionFlag
+	// ActionReply is allowed for both sides of the conversation.
+	ActionReply
+)
+
+// participants is the minimal view of a message needed for authorization.
+type participants struct {
+	SenderID   int64
+	ReceiverID int64
+}
+
+// checkMessage decides whether userID may perform action on a message.
+func checkMessage(p participants, userID int64, action MessageAction) error {
+	// ID 0 = user sudah dihapus (NULL), bukan peserta.
+	isReceiver := p.ReceiverID != 0 && p.ReceiverID == userID
+	isSender := p.SenderID != 0 && p.SenderID == userID
+
+	switch action {
+	case ActionReply:
+		if isReceiver || isSender {
+			return nil
+		}
+	default:
+		if isReceiver {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
+
+// authzStore is the part of the repository the authorizer reads.
+type authzStore interface {
+	messageParticipants(ctx context.Context, id int64) (participants, error)
+	announcementVisibility(ctx context.Context, id int64, dept string, roles []string) (exists, visible bool, err error)
+}
+
+// Authorizer guards message and announcement mutations.
+type Authorizer struct {
+	repo  authzStore
+	perms PermissionChecker
+}
+
+func NewAuthorizer(repo *Repository, perms PermissionChecker) *Authorizer {
+	return &Authorizer{repo: repo, perms: perms}
+}
+
+// Message returns nil when userID may perform action on message id.
+func (a *Authorizer) Message(ctx context.Context, id, userID int64, action MessageAction) error {
+	p, err := a.repo.messageParticipants(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		return err
+	}
+	return checkMessage(p, userID, action)
+}
+
+// ManageAnnouncement requires CREATE_ANNOUNCEMENTS and an active announcement.
+func (a *Authorizer) ManageAnnouncement(ctx context.Context, id int64, roles []string) error {
+	ok, err := a.perms.HasAnyPermission(ctx, roles, "CREATE_ANNOUNCEMENTS")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrForbidden
+	}
+	exists, _, err := a.repo.announcementVisibility(ctx, id, "", nil)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ReadAnnouncement requires the announcement to be active and targeted at the user.
+func (a *Authorizer) ReadAnnouncement(ctx context.Context, id int64, dept string, roles []string) error {
+	exists, visible, err := a.repo.announcementVisibility(ctx, id, dept, roles)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	if !visible {
+		return ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	alice   int64 = 1 // pengirim
+	bob     int64 = 2 // penerima
+	charlie int64 = 3 // orang luar / penerima broadcast lain
+)
+
+// fakeStore serves participants and announcement visibility from memory.
+type fakeStore struct {
+	messages      map[int64]participants
+	announcements map[int64]bool // id -> visible untuk user yang ditanya
+	err           error
+}
+
+func (f *fakeStore) messageParticipants(ctx context.Context, id int64) (participants, error) {
+	if f.err != nil {
+		return participants{}, f.err
+	}
+	p, ok := f.messages[id]
+	if !ok {
+		return participants{}, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func (f *fakeStore) announcementVisibility(ctx context.Context, id int64, dept string, roles []string) (bool, bool, error) {
+	if f.err != nil {
+		return false, false, f.err
+	}
+	visible, ok := f.announcements[id]
+	return ok, visible, nil
+}
+
+type fakePerms struct{ allowed bool }
+
+func (f fakePerms) HasAnyPermission(ctx context.Context, roles []string, codes ...string) (bool, error) {
+	return f.allowed, nil
+}
+
+func TestCheckMessage(t *testing.T) {
+	direct := participants{SenderID: alice, ReceiverID: bob}
+	// Broadcast: satu row per penerima, masing-masing dengan receiver sendiri.
+	broadcastToBob := participants{SenderID: alice, ReceiverID: bob}
+	// Pengirim yang sudah di-hard delete: sender_id NULL -> 0.
+	orphan := participants{SenderID: 0, ReceiverID: bob}
+
+	tests := []struct {
+		name   string
+		p      participants
+		user   int64
+		action MessageAction
+		want   error
+	}{
+		{"receiver marks read", direct, bob, ActionMarkRead, nil},
+		{"receiver deletes", direct, bob, ActionDelete, nil},
+		{"receiver flags", direct, bob, ActionFlag, nil},
+		{"receiver replies", direct, bob, ActionReply, nil},
+		{"sender replies", direct, alice, ActionReply, nil},
+		{"sender cannot mark read", direct, alice, ActionMarkRead, ErrForbidden},
+		{"sender cannot delete receiver copy", direct, alice, ActionDelete, ErrForbidden},
+		{"sender cannot flag", direct, alice, ActionFlag, ErrForbidden},
+		{"outsider cannot read", direct, charlie, ActionMarkRead, ErrForbidden},
+		{"outsider cannot delete", direct, charlie, ActionDelete, ErrForbidden},
+		{"outsider cannot reply", direct, charlie, ActionReply, ErrForbidden},
+		{"broadcast recipient marks own copy", broadcastToBob, bob, ActionMarkRead, nil},
+		{"other broadcast recipient cannot touch bob's copy", broadcastToBob, charlie, ActionDelete, ErrForbidden},
+		{"broadcast sender replies", broadcastToBob, alice, ActionReply, nil},
+		{"receiver of orphaned message still owns it", orphan, bob, ActionDelete, nil},
+		{"user 0 never matches an erased sender", orphan, 0, ActionReply, ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkMessage(tt.p, tt.user, tt.action); !errors.Is(got, tt.want) {
+				t.Fatalf("checkMessage = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizerMessage(t *testing.T) {
+	store := &fakeStore{messages: map[int64]participants{10: {SenderID: alice, ReceiverID: bob}}}
+	a := &Authorizer{repo: store}
+	ctx := context.Background()
+
+	if err := a.Message(ctx, 10, bob, ActionDelete); err != nil {
+		t.Fatalf("receiver: %v", err)
+	}
+	if err := a.Message(ctx, 10, charlie, ActionDelete); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("outsider: %v, want ErrForbidden", err)
+	}
+	// Pesan yang sudah dihapus (atau tidak pernah ada) -> 404, bukan 403.
+	if err := a.Message(ctx, 11, bob, ActionMarkRead); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("deleted message: %v, want ErrNotFound", err)
+	}
+
+	dbErr := errors.New("connection reset")
+	store.err = dbErr
+	if err := a.Message(ctx, 10, bob, ActionDelete); !errors.Is(err, dbErr) {
+		t.Fatalf("db error: %v, want passthrough", err)
+	}
+}
+
+func TestAuthorizerAnnouncements(t *testing.T) {
+	store := &fakeStore{announcements: map[int64]bool{1: true, 2: false}}
+	ctx := context.Background()
+
+	reader := &Authorizer{repo: store, perms: fakePerms{}}
+	if err := reader.ReadAnnouncement(ctx, 1, "IT", nil); err != nil {
+		t.Fatalf("visible: %v", err)
+	}
+	if err := reader.ReadAnnouncement(ctx, 2, "IT", nil); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("other department: %v, want ErrForbidden", err)
+	}
+	if err := reader.ReadAnnouncement(ctx, 3, "IT", nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing: %v, want ErrNotFound", err)
+	}
+	if err := reader.ManageAnnouncement(ctx, 1, nil); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("manage without permission: %v, want ErrForbidden", err)
+	}
+
+	manager := &Authorizer{repo: store, perms: fakePerms{allowed: true}}
+	if err := manager.ManageAnnouncement(ctx, 2, nil); err != nil {
+		t.Fatalf("manage: %v", err)
+	}
+	if err := manager.ManageAnnouncement(ctx, 3, nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("manage missing: %v, want ErrNotFound", err)
+	}
+}
+
+func TestAuthzErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{ErrNotFound, fiber.StatusNotFound},
+		{ErrForbidden, fiber.StatusForbidden},
+		{errors.New("boom"), fiber.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		var fe *fiber.Error
+		if !errors.As(authzError(tt.err, "failed"), &fe) || fe.Code != tt.want {
+			t.Errorf("authzError(%v) = %v, want status %d", tt.err, fe, tt.want)
+		}
+	}
+}
@@ -2,6 +2,10 @@ package messaging
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -14,20 +18,59 @@ type UserRepo interface {
 type Handler struct {
 	repo     *Repository
 	userRepo UserRepo
+	authz    *Authorizer
 }
 
-func NewHandler(repo *Repository, userRepo UserRepo) *Handler {
-	return &Handler{repo: repo, userRepo: userRepo}
+func NewHandler(repo *Repository, userRepo UserRepo, authz *Authorizer) *Handler {
+	return &Handler{repo: repo, userRepo: userRepo, authz: authz}
 }
 
-// GET /api/inbox
+// authzError maps authorization errors to 404/403, anything else to 500.
+func authzError(err error, fallback string) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, "not found")
+	case errors.Is(err, ErrForbidden):
+		return fiber.NewError(fiber.StatusForbidden, "insufficient permission")
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, fallback)
+}
+
+// GET /api/inbox?q=&unread=true&sender_id=&from=YYYY-MM-DD&to=YYYY-MM-DD&archived=true&starred=true&cursor=&limit=
 func (h *Handler) GetInbox(c *fiber.Ctx) error {
 	userID := c.Locals("userID").(int64)
-	messages, err := h.repo.GetInbox(c.Context(), userID)
+
+	f := InboxFilter{
+		Query:    strings.TrimSpace(c.Query("q")),
+		Unread:   c.QueryBool("unread"),
+		SenderID: int64(c.QueryInt("sender_id")),
+		Archived: c.QueryBool("archived"),
+		Starred:  c.QueryBool("starred"),
+		Cursor:   int64(c.QueryInt("cursor")),
+		Limit:    c.QueryInt("limit"),
+	}
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid from date (YYYY-MM-DD)")
+		}
+		f.From = &t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid to date (YYYY-MM-DD)")
+		}
+		// "to" inklusif: ambil sampai akhir hari tersebut.
+		t = t.AddDate(0, 0, 1)
+		f.To = &t
+	}
+
+	page, err := h.repo.GetInbox(c.Context(), userID, f)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch inbox")
 	}
-	return c.JSON(messages)
+	return c.JSON(page)
 }
 
 // POST /api/inbox
@@ -45,6 +88,13 @@ func (h *Handler) SendMessage(c *fiber.Ctx) error {
 
 	senderID := c.Locals("userID").(int64)
 
+	// Balasan hanya boleh untuk thread di mana user terlibat.
+	if req.ParentID != nil {
+		if err := h.authz.Message(c.Context(), *req.ParentID, senderID, ActionReply); err != nil {
+			return authzError(err, "failed to send message")
+		}
+	}
+
 	msg := &Message{
 		SenderID:   senderID,
 		ReceiverID: req.ReceiverID,
@@ -60,14 +110,84 @@ func (h *Handler) SendMessage(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(msg)
 }
 
-// POST /api/inbox/:id/read
+// POST /api/inbox/broadcast
+// Kirim satu pesan ke daftar user, departemen, cabang atau role sekaligus.
+func (h *Handler) SendBroadcast(c *fiber.Ctx) error {
+	var req struct {
+		BroadcastTarget
+		Subject string `json:"subject"`
+		Body    string `json:"body"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+
+	req.Subject = strings.TrimSpace(req.Subject)
+	req.Department = strings.ToUpper(strings.TrimSpace(req.Department))
+	req.Branch = strings.TrimSpace(req.Branch)
+	req.Role = strings.ToUpper(strings.TrimSpace(req.Role))
+	if req.Subject == "" || strings.TrimSpace(req.Body) == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "subject and body are required")
+	}
+	if req.BroadcastTarget.IsEmpty() {
+		return fiber.NewError(fiber.StatusBadRequest, "at least one of user_ids, department, branch or role is required")
+	}
+
+	b := &Broadcast{
+		SenderID: c.Locals("userID").(int64),
+		Subject:  req.Subject,
+		Body:     req.Body,
+		Target:   req.BroadcastTarget,
+	}
+	if err := h.repo.CreateBroadcast(c.Context(), b); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to send broadcast")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(b)
+}
+
+// GET /api/inbox/broadcasts
+func (h *Handler) GetMyBroadcasts(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int64)
+	list, err := h.repo.ListBroadcastsBySender(c.Context(), userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch broadcasts")
+	}
+	return c.JSON(list)
+}
+
+// GET /api/inbox/broadcasts/:id - delivery & read stats
+func (h *Handler) GetBroadcastStats(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int64)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+
+	stats, err := h.repo.GetBroadcastStats(c.Context(), int64(id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fiber.NewError(fiber.StatusNotFound, "broadcast not found")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch broadcast stats")
+	}
+	if stats.SenderID != userID {
+		return fiber.NewError(fiber.StatusNotFound, "broadcast not found")
+	}
+	return c.JSON(stats)
+}
+
+// PUT /api/inbox/:id/read
 func (h *Handler) MarkMessageRead(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int64)
 	id, err := c.ParamsInt("id")
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
 	}
 
-	// In real app, check permission if receiver_id == userID
+	if err := h.authz.Message(c.Context(), int64(id), userID, ActionMarkRead); err != nil {
+		return authzError(err, "failed to mark read")
+	}
 	if err := h.repo.MarkMessageRead(c.Context(), int64(id)); err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "failed to mark read")
 	}
@@ -75,6 +195,33 @@ func (h *Handler) MarkMessageRead(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusOK)
 }
 
+// PUT/DELETE /api/inbox/:id/archive
+func (h *Handler) ArchiveMessage(c *fiber.Ctx) error {
+	return h.setFlag(c, h.repo.SetArchived)
+}
+
+// PUT/DELETE /api/inbox/:id/star
+func (h *Handler) StarMessage(c *fiber.Ctx) error {
+	return h.setFlag(c, h.repo.SetStarred)
+}
+
+// setFlag sets the flag on PUT and clears it on DELETE.
+func (h *Handler) setFlag(c *fiber.Ctx, set func(ctx context.Context, id int64, value bool) error) error {
+	userID := c.Locals("userID").(int64)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+
+	if err := h.authz.Message(c.Context(), int64(id), userID, ActionFlag); err != nil {
+		return authzError(err, "failed to update message")
+	}
+	if err := set(c.Context(), int64(id), c.Method() != fiber.MethodDelete); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to update message")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
 // GET /api/announcements
 func (h *Handler) GetAnnouncements(c *fiber.Ctx) error {
 	userID := c.Locals("userID").(int64)
@@ -97,11 +244,19 @@ func (h *Handler) GetAnnouncements(c *fiber.Ctx) error {
 // POST /api/announcements/:id/read
 func (h *Handler) MarkAnnouncementRead(c *fiber.Ctx) error {
 	userID := c.Locals("userID").(int64)
+	roles, _ := c.Locals("roles").([]string)
 	id, err := c.ParamsInt("id")
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
 	}
 
+	dept, err := h.userRepo.GetDepartment(c.Context(), userID)
+	if err != nil {
+		dept = ""
+	}
+	if err := h.authz.ReadAnnouncement(c.Context(), int64(id), dept, roles); err != nil {
+		return authzError(err, "failed to mark announcement read")
+	}
 	if err := h.repo.MarkAnnouncementRead(c.Context(), userID, int64(id)); err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "failed to mark announcement read")
 	}
@@ -111,11 +266,14 @@ func (h *Handler) MarkAnnouncementRead(c *fiber.Ctx) error {
 
 // DELETE /api/announcements/:id
 func (h *Handler) DeleteAnnouncement(c *fiber.Ctx) error {
-	// Permission checks could be added (CREATE_ANNOUNCEMENTS)
+	roles, _ := c.Locals("roles").([]string)
 	id, err := c.ParamsInt("id")
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
 	}
+	if err := h.authz.ManageAnnouncement(c.Context(), int64(id), roles); err != nil {
+		return authzError(err, "failed to delete announcement")
+	}
 	if err := h.repo.DeleteAnnouncement(c.Context(), int64(id)); err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "failed to delete announcement")
 	}
@@ -129,6 +287,9 @@ func (h *Handler) DeleteMessage(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
 	}
 
+	if err := h.authz.Message(c.Context(), int64(id), userID, ActionDelete); err != nil {
+		return authzError(err, "failed to delete message")
+	}
 	if err := h.repo.DeleteMessage(c.Context(), int64(id), userID); err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "failed to delete message")
 	}
@@ -3,6 +3,8 @@ package messaging
 import (
 	"context"
 	"database/sql"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
@@ -25,10 +27,46 @@ type Message struct {
 	IsRead       bool      `json:"is_read"`
 	CreatedAt    time.Time `json:"created_at"`
 	ParentID     *int64    `json:"parent_id,omitempty"`
+	BroadcastID  *int64    `json:"broadcast_id,omitempty"`
+	IsArchived   bool      `json:"is_archived"`
+	IsStarred    bool      `json:"is_starred"`
 	SenderName   string    `json:"sender_name"`
 	ReceiverName string    `json:"receiver_name"`
 }
 
+// BroadcastTarget describes who receives a broadcast. All non-empty fields
+// are combined (union); only ACTIVE users are included and the sender is
+// never included.
+type BroadcastTarget struct {
+	UserIDs    []int64 `json:"user_ids"`
+	Department string  `json:"department"`
+	Branch     string  `json:"branch"`
+	Role       string  `json:"role"`
+}
+
+func (t BroadcastTarget) IsEmpty() bool {
+	return len(t.UserIDs) == 0 && t.Department == "" && t.Branch == "" && t.Role == ""
+}
+
+type Broadcast struct {
+	ID             int64           `json:"id"`
+	SenderID       int64           `json:"sender_id"`
+	Subject        string          `json:"subject"`
+	Body           string          `json:"body"`
+	Target         BroadcastTarget `json:"target"`
+	RecipientCount int             `json:"recipient_count"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// BroadcastStats summarises delivery and read status of a broadcast.
+type BroadcastStats struct {
+	Broadcast
+	Delivered int `json:"delivered"` // penerima saat dikirim
+	Read      int `json:"read"`
+	Unread    int `json:"unread"`
+	Deleted   int `json:"deleted"` // sudah dihapus penerima dari inbox
+}
+
 type Announcement struct {
 	ID                int64     `json:"id"`
 	Title             string    `json:"title"`
@@ -41,31 +79,87 @@ type Announcement struct {
 	IsRead            bool      `json:"is_read,omitempty"` // for user context
 }
 
-// GetInbox returns messages received by a user
-func (r *Repository) GetInbox(ctx context.Context, userID int64) ([]*Message, error) {
+// InboxFilter narrows down GetInbox. Zero values mean "no filter".
+type InboxFilter struct {
+	Query    string // full-text search over subject and body
+	Unread   bool
+	SenderID int64
+	From     *time.Time // created_at >= From
+	To       *time.Time // created_at < To
+	Archived bool       // false: inbox biasa, true: hanya yang diarsipkan
+	Starred  bool
+	// Cursor is the id of the last message of the previous page.
+	Cursor int64
+	Limit  int
+}
+
+// InboxPage is one page of GetInbox results.
+type InboxPage struct {
+	Items      []*Message `json:"items"`
+	NextCursor *int64     `json:"next_cursor"`
+}
+
+// GetInbox returns messages received by a user, newest first, one page at a
+// time (keyset pagination on id).
+func (r *Repository) GetInbox(ctx context.Context, userID int64, f InboxFilter) (*InboxPage, error) {
+	if f.Limit <= 0 || f.Limit > 100 {
+		f.Limit = 50
+	}
+
+	where := []string{"m.receiver_id = $1", "m.is_archived = $2"}
+	args := []any{userID, f.Archived}
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, strings.ReplaceAll(cond, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if f.Query != "" {
+		add("m.search_vector @@ websearch_to_tsquery('simple', ?)", f.Query)
+	}
+	if f.Unread {
+		where = append(where, "m.is_read = FALSE")
+	}
+	if f.Starred {
+		where = append(where, "m.is_starred = TRUE")
+	}
+	if f.SenderID > 0 {
+		add("m.sender_id = ?", f.SenderID)
+	}
+	if f.From != nil {
+		add("m.created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		add("m.created_at < ?", *f.To)
+	}
+	if f.Cursor > 0 {
+		add("m.id < ?", f.Cursor)
+	}
+	args = append(args, f.Limit+1)
+
 	q := `
 		SELECT 
 			m.id, m.sender_id, m.receiver_id, m.subject, m.body, m.is_read, m.created_at, m.parent_id,
-			s.name as sender_name, r.name as receiver_name
+			m.broadcast_id, m.is_archived, m.is_starred, s.name as sender_name, r.name as receiver_name
 		FROM messages m
 		JOIN users s ON m.sender_id = s.id
 		JOIN users r ON m.receiver_id = r.id
-		WHERE m.receiver_id = $1
-		ORDER BY m.created_at DESC
-	`
-	rows, err := r.db.QueryContext(ctx, q, userID)
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY m.id DESC
+		LIMIT $` + strconv.Itoa(len(args))
+
+	rows, err := r.db.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var messages []*Message
+	messages := []*Message{}
 	for rows.Next() {
 		var m Message
-		var parentID sql.NullInt64
+		var parentID, broadcastID sql.NullInt64
 		if err := rows.Scan(
 			&m.ID, &m.SenderID, &m.ReceiverID, &m.Subject, &m.Body, &m.IsRead, &m.CreatedAt, &parentID,
-			&m.SenderName, &m.ReceiverName,
+			&broadcastID, &m.IsArchived, &m.IsStarred, &m.SenderName, &m.ReceiverName,
 		); err != nil {
 			return nil, err
 		}
@@ -73,9 +167,35 @@ func (r *Repository) GetInbox(ctx context.Context, userID int64) ([]*Message, er
 			pid := parentID.Int64
 			m.ParentID = &pid
 		}
+		if broadcastID.Valid {
+			bid := broadcastID.Int64
+			m.BroadcastID = &bid
+		}
 		messages = append(messages, &m)
 	}
-	return messages, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	page := &InboxPage{Items: messages}
+	if len(messages) > f.Limit {
+		page.Items = messages[:f.Limit]
+		next := page.Items[f.Limit-1].ID
+		page.NextCursor = &next
+	}
+	return page, nil
+}
+
+// SetArchived archives or unarchives a message in the receiver's inbox.
+func (r *Repository) SetArchived(ctx context.Context, messageID int64, archived bool) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE messages SET is_archived = $1 WHERE id = $2", archived, messageID)
+	return err
+}
+
+// SetStarred stars or unstars a message in the receiver's inbox.
+func (r *Repository) SetStarred(ctx context.Context, messageID int64, starred bool) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE messages SET is_starred = $1 WHERE id = $2", starred, messageID)
+	return err
 }
 
 // SendMessage sends a new message
@@ -90,7 +210,17 @@ func (r *Repository) SendMessage(ctx context.Context, m *Message) error {
 
 // MarkMessageRead marks a message as read
 func (r *Repository) MarkMessageRead(ctx context.Context, messageID int64) error {
-	_, err := r.db.ExecContext(ctx, "UPDATE messages SET is_read = TRUE WHERE id = $1", messageID)
+	// Catat juga di broadcast_recipients supaya statistik read tetap ada
+	// setelah pesan dihapus.
+	_, err := r.db.ExecContext(ctx, `
+		WITH m AS (
+			UPDATE messages SET is_read = TRUE WHERE id = $1
+			RETURNING broadcast_id, receiver_id
+		)
+		UPDATE broadcast_recipients br SET read_at = NOW()
+		FROM m
+		WHERE br.broadcast_id = m.broadcast_id AND br.user_id = m.receiver_id AND br.read_at IS NULL
+	`, messageID)
 	return err
 }
 
@@ -100,7 +230,7 @@ func (r *Repository) GetAnnouncements(ctx context.Context, userID int64, dept st
 	// Also check if read
 	q := `
 		SELECT 
-			a.id, a.title, a.content, a.target_departments, a.target_roles, a.created_by, a.created_at,
+			a.id, a.title, a.content, a.target_departments, a.target_roles, COALESCE(a.created_by, 0), a.created_at,
 			EXISTS(SELECT 1 FROM announcement_reads ar WHERE ar.announcement_id = a.id AND ar.user_id = $1) as is_read
 		FROM announcements a
 		WHERE a.is_active = TRUE
@@ -170,3 +300,156 @@ func (r *Repository) DeleteMessage(ctx context.Context, messageID int64, userID
 	}
 	return nil
 }
+
+// CreateBroadcast expands the target into recipients and inserts one inbox row
+// per recipient, all grouped under a new broadcast id, in one transaction.
+func (r *Repository) CreateBroadcast(ctx context.Context, b *Broadcast) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	t := b.Target
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO broadcasts (sender_id, subject, body, target_user_ids, target_department, target_branch, target_role)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''))
+		RETURNING id, created_at
+	`, b.SenderID, b.Subject, b.Body, pq.Array(t.UserIDs), t.Department, t.Branch, t.Role,
+	).Scan(&b.ID, &b.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO messages (sender_id, receiver_id, subject, body, broadcast_id)
+		SELECT $1, u.id, $2, $3, $4
+		FROM users u
+		WHERE u.status = 'ACTIVE'
+		  AND u.id <> $1
+		  AND (
+			u.id = ANY($5)
+			OR ($6 <> '' AND u.department = $6)
+			OR ($7 <> '' AND u.branch = $7)
+			OR ($8 <> '' AND $8 = ANY(u.roles))
+		  )
+	`, b.SenderID, b.Subject, b.Body, b.ID, pq.Array(t.UserIDs), t.Department, t.Branch, t.Role)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	b.RecipientCount = int(n)
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO broadcast_recipients (broadcast_id, user_id)
+		SELECT broadcast_id, receiver_id FROM messages WHERE broadcast_id = $1
+	`, b.ID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE broadcasts SET recipient_count = $1 WHERE id = $2`, n, b.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+const broadcastSelectColumns = `
+	b.id, COALESCE(b.sender_id, 0), COALESCE(b.subject, ''), COALESCE(b.body, ''),
+	COALESCE(b.target_user_ids, '{}'), COALESCE(b.target_department, ''),
+	COALESCE(b.target_branch, ''), COALESCE(b.target_role, ''),
+	b.recipient_count, b.created_at
+`
+
+func scanBroadcast(row interface{ Scan(dest ...any) error }, extra ...any) (*Broadcast, error) {
+	var b Broadcast
+	var userIDs []int64
+	dest := []any{
+		&b.ID, &b.SenderID, &b.Subject, &b.Body,
+		pq.Array(&userIDs), &b.Target.Department,
+		&b.Target.Branch, &b.Target.Role,
+		&b.RecipientCount, &b.CreatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	b.Target.UserIDs = userIDs
+	return &b, nil
+}
+
+// ListBroadcastsBySender returns broadcasts sent by a user, newest first.
+func (r *Repository) ListBroadcastsBySender(ctx context.Context, senderID int64) ([]*Broadcast, error) {
+	q := `SELECT ` + broadcastSelectColumns + ` FROM broadcasts b WHERE b.sender_id = $1 ORDER BY b.created_at DESC`
+	rows, err := r.db.QueryContext(ctx, q, senderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Broadcast
+	for rows.Next() {
+		b, err := scanBroadcast(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// GetBroadcastStats returns a broadcast with its delivery and read counters.
+func (r *Repository) GetBroadcastStats(ctx context.Context, id int64) (*BroadcastStats, error) {
+	q := `
+		SELECT ` + broadcastSelectColumns + `,
+			COUNT(br.user_id),
+			COUNT(br.user_id) FILTER (WHERE br.read_at IS NOT NULL),
+			COUNT(br.user_id) FILTER (WHERE NOT EXISTS (
+				SELECT 1 FROM messages m WHERE m.broadcast_id = b.id AND m.receiver_id = br.user_id
+			))
+		FROM broadcasts b
+		LEFT JOIN broadcast_recipients br ON br.broadcast_id = b.id
+		WHERE b.id = $1
+		GROUP BY b.id
+	`
+	var st BroadcastStats
+	b, err := scanBroadcast(r.db.QueryRowContext(ctx, q, id), &st.Delivered, &st.Read, &st.Deleted)
+	if err != nil {
+		return nil, err
+	}
+	st.Broadcast = *b
+	st.Unread = st.Delivered - st.Read
+	return &st, nil
+}
+
+// messageParticipants returns sender and receiver of a message.
+func (r *Repository) messageParticipants(ctx context.Context, id int64) (participants, error) {
+	var p participants
+	var sender, receiver sql.NullInt64
+	err := r.db.QueryRowContext(ctx, `SELECT sender_id, receiver_id FROM messages WHERE id = $1`, id).Scan(&sender, &receiver)
+	p.SenderID = sender.Int64
+	p.ReceiverID = receiver.Int64
+	return p, err
+}
+
+// announcementVisibility reports whether an active announcement exists and
+// whether it targets the given department/roles (same rule as GetAnnouncements).
+func (r *Repository) announcementVisibility(ctx context.Context, id int64, dept string, roles []string) (exists, visible bool, err error) {
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(
+			(COALESCE(array_length(a.target_departments, 1), 0) = 0 AND COALESCE(array_length(a.target_roles, 1), 0) = 0)
+			OR ($2 = ANY(a.target_departments))
+			OR ($3 && a.target_roles),
+		FALSE)
+		FROM announcements a
+		WHERE a.id = $1 AND a.is_active = TRUE
+	`, id, dept, pq.Array(roles)).Scan(&visible)
+	if err == sql.ErrNoRows {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	return true, visible, nil
+}
@@ -0,0 +1,92 @@
+package photo
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func toFiberError(err error, fallback string) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, ErrTooLarge):
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, ErrInvalidImage), errors.Is(err, ErrInvalidSize):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, fallback)
+}
+
+// POST /api/me/photo (multipart: photo)
+func (h *Handler) UploadMyPhoto(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	fh, err := c.FormFile("photo")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "photo is required")
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "failed to read photo")
+	}
+	defer f.Close()
+
+	url, err := h.svc.Upload(c.Context(), userID, f)
+	if err != nil {
+		return toFiberError(err, "failed to save photo")
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"photo_url": url})
+}
+
+// DELETE /api/me/photo
+func (h *Handler) DeleteMyPhoto(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	if err := h.svc.Remove(c.Context(), userID); err != nil {
+		return toFiberError(err, "failed to delete photo")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GET /api/me/photo?size=256
+func (h *Handler) GetMyPhoto(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	return h.send(c, userID)
+}
+
+// GET /api/employees/:id/photo?size=64|256|512
+func (h *Handler) GetPhoto(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	return h.send(c, id)
+}
+
+func (h *Handler) send(c *fiber.Ctx, userID int64) error {
+	rc, err := h.svc.Open(c.Context(), userID, c.QueryInt("size", DefaultSize))
+	if err != nil {
+		return toFiberError(err, "failed to load photo")
+	}
+	c.Set("Content-Type", "image/jpeg")
+	// URL foto berubah (?v=) setiap upload, jadi aman di-cache browser.
+	c.Set("Cache-Control", "private, max-age=86400")
+	return c.SendStream(rc)
+}
@@ -0,0 +1,180 @@
+package photo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // register decoder
+	_ "image/png"  // register decoder
+)
+
+// maxPixels menolak gambar yang terlalu besar untuk di-decode (decompression bomb).
+const maxPixels = 40_000_000
+
+// decode reads a JPEG or PNG, applies the EXIF orientation and returns the
+// format name. Metadata tidak ikut karena gambar selalu di-encode ulang.
+func decode(data []byte) (image.Image, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrInvalidImage
+	}
+	if format != "jpeg" && format != "png" {
+		return nil, "", ErrInvalidImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, "", ErrInvalidImage
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrInvalidImage
+	}
+	if format == "jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+	return img, format, nil
+}
+
+// jpegOrientation returns the EXIF orientation tag (1-8) of a JPEG, or 1.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 { // start of scan / end of image
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return 1
+		}
+		seg := data[i+4 : i+2+size]
+		if marker == 0xE1 && len(seg) > 14 && string(seg[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(seg[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+func tiffOrientation(t []byte) int {
+	var order binary.ByteOrder
+	switch string(t[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(t[4:]))
+	if ifd+2 > len(t) {
+		return 1
+	}
+	n := int(order.Uint16(t[ifd:]))
+	for e := 0; e < n; e++ {
+		off := ifd + 2 + e*12
+		if off+12 > len(t) {
+			return 1
+		}
+		if order.Uint16(t[off:]) == 0x0112 {
+			o := int(order.Uint16(t[off+8:]))
+			if o >= 1 && o <= 8 {
+				return o
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// orient rotates/flips img so that it displays upright for EXIF orientation o.
+func orient(img image.Image, o int) image.Image {
+	if o <= 1 || o > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if o >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch o {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// cropSquare takes the centered square of img, flattened onto white so PNG
+// transparency survives the JPEG re-encode.
+func cropSquare(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, image.Pt(x0, y0), draw.Over)
+	return dst
+}
+
+// resize scales a square image down to size x size with a box filter
+// (rata-rata area). Tidak pernah memperbesar gambar.
+func resize(src *image.RGBA, size int) *image.RGBA {
+	side := src.Bounds().Dx()
+	if size >= side {
+		return src
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for dy := 0; dy < size; dy++ {
+		sy0, sy1 := dy*side/size, (dy+1)*side/size
+		for dx := 0; dx < size; dx++ {
+			sx0, sx1 := dx*side/size, (dx+1)*side/size
+			var r, g, bl, a, n uint32
+			for sy := sy0; sy < sy1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := sx0; sx < sx1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint32(p[0])
+					g += uint32(p[1])
+					bl += uint32(p[2])
+					a += uint32(p[3])
+					n++
+				}
+			}
+			i := dy*dst.Stride + dx*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(bl / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
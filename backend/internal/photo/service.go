@@ -0,0 +1,124 @@
+package photo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"io"
+	"time"
+
+	"hr-portal-backend/pkg/storage"
+)
+
+var (
+	ErrNotFound     = errors.New("photo not found")
+	ErrInvalidImage = errors.New("photo must be a JPEG or PNG image")
+	ErrTooLarge     = errors.New("photo is too large")
+	ErrInvalidSize  = errors.New("unknown photo size")
+)
+
+// Sizes are the square variants produced for every upload, in pixels.
+var Sizes = []int{512, 256, 64}
+
+// DefaultSize is served when no size is requested.
+const DefaultSize = 256
+
+// UserPhotos is satisfied by *user.Repository.
+type UserPhotos interface {
+	SetPhotoURL(ctx context.Context, userID int64, url string) error
+}
+
+type Service struct {
+	store    storage.Storage
+	users    UserPhotos
+	maxBytes int64
+}
+
+func NewService(store storage.Storage, users UserPhotos, maxBytes int64) *Service {
+	return &Service{store: store, users: users, maxBytes: maxBytes}
+}
+
+func storageKey(userID int64, size int) string {
+	return fmt.Sprintf("photos/%d/%d.jpg", userID, size)
+}
+
+func validSize(size int) bool {
+	for _, s := range Sizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// Upload processes an uploaded image (orientation fix, center square crop,
+// all Sizes as JPEG without metadata), stores the variants and points
+// users.photo_url at the authenticated photo route.
+func (s *Service) Upload(ctx context.Context, userID int64, r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, s.maxBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) > s.maxBytes {
+		return "", ErrTooLarge
+	}
+
+	img, _, err := decode(data)
+	if err != nil {
+		return "", err
+	}
+	square := cropSquare(img)
+
+	for _, size := range Sizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resize(square, size), &jpeg.Options{Quality: 85}); err != nil {
+			return "", err
+		}
+		if err := s.store.Put(ctx, storageKey(userID, size), &buf); err != nil {
+			return "", err
+		}
+	}
+
+	// ?v= supaya browser tidak menampilkan foto lama dari cache.
+	url := fmt.Sprintf("/api/employees/%d/photo?v=%d", userID, time.Now().Unix())
+	if err := s.users.SetPhotoURL(ctx, userID, url); err != nil {
+		return "", err
+	}
+	return url, nil
+}
+
+// Open returns one stored variant of a user's photo.
+func (s *Service) Open(ctx context.Context, userID int64, size int) (io.ReadCloser, error) {
+	if !validSize(size) {
+		return nil, ErrInvalidSize
+	}
+	rc, err := s.store.Open(ctx, storageKey(userID, size))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rc, nil
+}
+
+// Remove deletes all variants and clears users.photo_url.
+func (s *Service) Remove(ctx context.Context, userID int64) error {
+	if err := s.users.SetPhotoURL(ctx, userID, ""); err != nil {
+		return err
+	}
+	return s.DeleteFiles(ctx, userID)
+}
+
+// DeleteFiles deletes the stored variants only; dipakai juga saat hard delete
+// karyawan, ketika row users sudah dianonimkan atau dihapus.
+func (s *Service) DeleteFiles(ctx context.Context, userID int64) error {
+	for _, size := range Sizes {
+		if err := s.store.Delete(ctx, storageKey(userID, size)); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
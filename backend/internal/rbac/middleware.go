@@ -0,0 +1,21 @@
+package rbac
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequirePermission only lets the request through when the caller's roles
+// grant at least one of the given permission codes.
+func RequirePermission(repo *Repository, codes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		roles, _ := c.Locals("roles").([]string)
+		ok, err := repo.HasAnyPermission(c.Context(), roles, codes...)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "failed to check permission")
+		}
+		if !ok {
+			return fiber.NewError(fiber.StatusForbidden, "insufficient permission")
+		}
+		return c.Next()
+	}
+}
@@ -124,3 +124,46 @@ func BuildMenuTree(menus []Menu) []Menu {
 
 	return roots
 }
+
+// HasAnyPermission reports whether any of the roles grants one of codes.
+func (r *Repository) HasAnyPermission(ctx context.Context, roles []string, codes ...string) (bool, error) {
+	if len(roles) == 0 || len(codes) == 0 {
+		return false, nil
+	}
+	var ok bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM role_permissions
+			WHERE role_code = ANY($1) AND permission_code = ANY($2)
+		)
+	`, pq.Array(roles), pq.Array(codes)).Scan(&ok)
+	return ok, err
+}
+
+// UsersWithPermission returns active users whose roles grant one of codes,
+// mis. penerima digest HR.
+func (r *Repository) UsersWithPermission(ctx context.Context, codes ...string) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT u.id FROM users u
+		WHERE u.status = 'ACTIVE'
+		  AND EXISTS (
+			SELECT 1 FROM role_permissions rp
+			WHERE rp.permission_code = ANY($1) AND rp.role_code = ANY(u.roles)
+		  )
+		ORDER BY u.id
+	`, pq.Array(codes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
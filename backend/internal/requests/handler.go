@@ -2,6 +2,7 @@ package requests
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
@@ -68,11 +69,16 @@ func (h *Handler) GetMyRequests(c *fiber.Ctx) error {
 	return c.JSON(requests)
 }
 
+// GET /api/requests/approvals - semua lembur untuk APPROVE_OVERTIME, semua
+// jenis lain untuk APPROVE_LEAVE, ditambah pengajuan dari tim sendiri.
 func (h *Handler) GetPendingRequests(c *fiber.Ctx) error {
-	// TODO: Verify permission (APPROVE_LEAVE or APPROVE_OVERTIME)
-	// For now, assume protected route checks general access, but specific permission check is better.
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	roles, _ := c.Locals("roles").([]string)
 
-	requests, err := h.service.GetPendingRequests(c.Context())
+	requests, err := h.service.GetPendingRequests(c.Context(), userID, roles)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -91,7 +97,14 @@ func (h *Handler) ApproveRequest(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
 	}
 
-	if err := h.service.ApproveRequest(c.Context(), id, approverID); err != nil {
+	roles, _ := c.Locals("roles").([]string)
+	if err := h.service.ApproveRequest(c.Context(), id, approverID, roles); err != nil {
+		if errors.Is(err, ErrForbidden) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, ErrNotPending) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
@@ -117,7 +130,14 @@ func (h *Handler) RejectRequest(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
 	}
 
-	if err := h.service.RejectRequest(c.Context(), id, approverID, body.Reason); err != nil {
+	roles, _ := c.Locals("roles").([]string)
+	if err := h.service.RejectRequest(c.Context(), id, approverID, roles, body.Reason); err != nil {
+		if errors.Is(err, ErrForbidden) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, ErrNotPending) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
@@ -235,7 +255,7 @@ func (h *Handler) ExportProcessedByMonth(c *fiber.Ctx) error {
 	// Set headers
 	headers := []string{"ID", "Employee", "Type", "Start Date", "End Date", "Status", "Approver", "Updated At"}
 	for i, hname := range headers {
-		cell := fmt.Sprintf("%s1", string('A'+i))
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
 		f.SetCellValue(sheet, cell, hname)
 		f.SetCellStyle(sheet, cell, cell, headerStyle)
 	}
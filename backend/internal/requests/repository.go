@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type Repository struct {
@@ -87,9 +89,9 @@ func (r *Repository) FindByUserID(ctx context.Context, userID int64) ([]*Request
 	return requests, nil
 }
 
-// FindPending returns all pending requests (for admins/HR)
-// In a real app, this might be filtered by department or manager hierarchy
-func (r *Repository) FindPending(ctx context.Context) ([]*Request, error) {
+// FindPending returns pending requests: semua lembur kalau overtime, semua
+// jenis lain kalau other, ditambah pengajuan dari userIDs.
+func (r *Repository) FindPending(ctx context.Context, overtime, other bool, userIDs []int64) ([]*Request, error) {
 	q := `
 		SELECT 
 			r.id, r.user_id, r.type, r.start_date, r.end_date, r.reason, r.status, 
@@ -98,9 +100,10 @@ func (r *Repository) FindPending(ctx context.Context) ([]*Request, error) {
 		FROM requests r
 		JOIN users u ON r.user_id = u.id
 		WHERE r.status = 'PENDING'
+		  AND (($1 AND r.type = 'OVERTIME') OR ($2 AND r.type <> 'OVERTIME') OR r.user_id = ANY($3))
 		ORDER BY r.created_at ASC
 	`
-	rows, err := r.db.QueryContext(ctx, q)
+	rows, err := r.db.QueryContext(ctx, q, overtime, other, pq.Array(userIDs))
 	if err != nil {
 		return nil, err
 	}
@@ -155,14 +158,38 @@ func (r *Repository) FindByID(ctx context.Context, id int64) (*Request, error) {
 	return &req, nil
 }
 
-func (r *Repository) UpdateStatus(ctx context.Context, id int64, status string, approverID int64, rejectionReason *string) error {
+// BeginTx starts a transaction for status changes that must be committed
+// together with their side effects (e.g. the mail outbox).
+func (r *Repository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return r.db.BeginTx(ctx, nil)
+}
+
+// UpdateStatus decides a PENDING request; ErrNotPending jika sudah diputuskan.
+func (r *Repository) UpdateStatus(ctx context.Context, tx *sql.Tx, id int64, status string, approverID int64, rejectionReason *string) error {
 	q := `
 		UPDATE requests 
 		SET status = $1, approver_id = $2, rejection_reason = $3, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $4
+		WHERE id = $4 AND status = 'PENDING'
 	`
-	_, err := r.db.ExecContext(ctx, q, status, approverID, rejectionReason, id)
-	return err
+	res, err := tx.ExecContext(ctx, q, status, approverID, rejectionReason, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotPending
+	}
+	return nil
+}
+
+// UserName returns the display name of a user within tx.
+func (r *Repository) UserName(ctx context.Context, tx *sql.Tx, userID int64) (string, error) {
+	var name string
+	err := tx.QueryRowContext(ctx, `SELECT name FROM users WHERE id = $1`, userID).Scan(&name)
+	return name, err
 }
 
 type Summary struct {
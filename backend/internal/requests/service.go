@@ -2,16 +2,119 @@ package requests
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"slices"
 	"time"
+
+	"hr-portal-backend/internal/mail"
 )
 
+// ErrNotPending is returned when the request was already approved or
+// rejected, termasuk jika diputuskan bersamaan oleh approver lain.
+var ErrNotPending = errors.New("request is not pending")
+
+// ErrForbidden is returned when the approver may not decide the request.
+var ErrForbidden = errors.New("not allowed to decide this request")
+
+// Notifier queues an email inside the caller's transaction.
+type Notifier interface {
+	NotifyTx(ctx context.Context, tx *sql.Tx, n mail.Notification) error
+}
+
+// PermissionChecker is satisfied by *rbac.Repository.
+type PermissionChecker interface {
+	HasAnyPermission(ctx context.Context, roles []string, codes ...string) (bool, error)
+}
+
+// TeamScope is satisfied by *user.Service: bawahan langsung/tidak langsung.
+type TeamScope interface {
+	TeamMemberIDs(ctx context.Context, managerID int64, indirect bool) ([]int64, error)
+}
+
+// approvePermission returns the permission that lets an approver see and
+// decide every request of reqType; tanpa itu hanya pengajuan tim sendiri.
+// Lembur punya approver sendiri, jenis lain (cuti, izin, ...) ikut cuti.
+func approvePermission(reqType string) string {
+	if reqType == "OVERTIME" {
+		return "APPROVE_OVERTIME"
+	}
+	return "APPROVE_LEAVE"
+}
+
+// approverScope is what an approver may see and decide.
+type approverScope struct {
+	Overtime bool    // semua pengajuan lembur (APPROVE_OVERTIME)
+	Other    bool    // semua pengajuan selain lembur (APPROVE_LEAVE)
+	Team     []int64 // seluruh bawahan
+}
+
+// covers reports whether the scope includes req.
+func (sc *approverScope) covers(req *Request) bool {
+	if req.Type == "OVERTIME" {
+		if sc.Overtime {
+			return true
+		}
+	} else if sc.Other {
+		return true
+	}
+	return slices.Contains(sc.Team, req.UserID)
+}
+
 type Service struct {
-	repo *Repository
+	repo     *Repository
+	notifier Notifier
+	perms    PermissionChecker
+	team     TeamScope
 }
 
-func NewService(repo *Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo *Repository, notifier Notifier, perms PermissionChecker, team TeamScope) *Service {
+	return &Service{repo: repo, notifier: notifier, perms: perms, team: team}
+}
+
+// approvalScope checks the approve permission per request type and loads
+// the caller's team.
+func (s *Service) approvalScope(ctx context.Context, userID int64, roles []string) (*approverScope, error) {
+	var sc approverScope
+	var err error
+	if sc.Overtime, err = s.perms.HasAnyPermission(ctx, roles, approvePermission("OVERTIME")); err != nil {
+		return nil, err
+	}
+	if sc.Other, err = s.perms.HasAnyPermission(ctx, roles, approvePermission("LEAVE")); err != nil {
+		return nil, err
+	}
+	if sc.Overtime && sc.Other {
+		return &sc, nil
+	}
+	if sc.Team, err = s.team.TeamMemberIDs(ctx, userID, true); err != nil {
+		return nil, err
+	}
+	return &sc, nil
+}
+
+func (s *Service) canDecide(ctx context.Context, approverID int64, roles []string, req *Request) error {
+	sc, err := s.approvalScope(ctx, approverID, roles)
+	if err != nil {
+		return err
+	}
+	if !sc.covers(req) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// CanReview reports whether the approver may see and decide the request;
+// dipakai juga untuk akses lampiran pengajuan.
+func (s *Service) CanReview(ctx context.Context, approverID int64, roles []string, id int64) (bool, error) {
+	req, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	sc, err := s.approvalScope(ctx, approverID, roles)
+	if err != nil {
+		return false, err
+	}
+	return sc.covers(req), nil
 }
 
 func (s *Service) CreateRequest(ctx context.Context, userID int64, reqType string, startDate, endDate time.Time, reason string) (*Request, error) {
@@ -37,36 +140,88 @@ func (s *Service) GetMyRequests(ctx context.Context, userID int64) ([]*Request,
 	return s.repo.FindByUserID(ctx, userID)
 }
 
-func (s *Service) GetPendingRequests(ctx context.Context) ([]*Request, error) {
-	return s.repo.FindPending(ctx)
+// GetPendingRequests returns the pending requests the approver may decide.
+func (s *Service) GetPendingRequests(ctx context.Context, approverID int64, roles []string) ([]*Request, error) {
+	sc, err := s.approvalScope(ctx, approverID, roles)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindPending(ctx, sc.Overtime, sc.Other, sc.Team)
 }
 
-func (s *Service) ApproveRequest(ctx context.Context, id int64, approverID int64) error {
+func (s *Service) ApproveRequest(ctx context.Context, id int64, approverID int64, roles []string) error {
 	// Check if already processed?
 	req, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	if err := s.canDecide(ctx, approverID, roles, req); err != nil {
+		return err
+	}
 	if req.Status != "PENDING" {
-		return errors.New("request is not pending")
+		return ErrNotPending
 	}
 
-	return s.repo.UpdateStatus(ctx, id, "APPROVED", approverID, nil)
+	return s.decide(ctx, req, "APPROVED", approverID, nil)
 }
 
-func (s *Service) RejectRequest(ctx context.Context, id int64, approverID int64, reason string) error {
+func (s *Service) RejectRequest(ctx context.Context, id int64, approverID int64, roles []string, reason string) error {
 	req, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	if err := s.canDecide(ctx, approverID, roles, req); err != nil {
+		return err
+	}
 	if req.Status != "PENDING" {
-		return errors.New("request is not pending")
+		return ErrNotPending
 	}
 	if reason == "" {
 		return errors.New("rejection reason is required")
 	}
 
-	return s.repo.UpdateStatus(ctx, id, "REJECTED", approverID, &reason)
+	return s.decide(ctx, req, "REJECTED", approverID, &reason)
+}
+
+// decide updates the request status and queues the decision email in the
+// same transaction.
+func (s *Service) decide(ctx context.Context, req *Request, status string, approverID int64, rejectionReason *string) error {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.repo.UpdateStatus(ctx, tx, req.ID, status, approverID, rejectionReason); err != nil {
+		return err
+	}
+
+	if s.notifier != nil {
+		approverName, err := s.repo.UserName(ctx, tx, approverID)
+		if err != nil {
+			return err
+		}
+		event := mail.EventRequestApproved
+		data := map[string]any{
+			"Type":         req.Type,
+			"StartDate":    req.StartDate.Format("02 Jan 2006"),
+			"EndDate":      req.EndDate.Format("02 Jan 2006"),
+			"ApproverName": approverName,
+		}
+		if status == "REJECTED" {
+			event = mail.EventRequestRejected
+			data["RejectionReason"] = *rejectionReason
+		}
+		if err := s.notifier.NotifyTx(ctx, tx, mail.Notification{
+			Event:  event,
+			UserID: req.UserID,
+			Data:   data,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
 func (s *Service) GetProcessedBetween(ctx context.Context, from, to time.Time) ([]*Request, error) {
@@ -0,0 +1,25 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Every runs fn immediately and then on every tick of interval until ctx is
+// cancelled. Errors are logged and never stop the loop.
+func Every(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := fn(ctx); err != nil {
+			log.Printf("scheduler %s: %v", name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
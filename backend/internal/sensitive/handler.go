@@ -0,0 +1,159 @@
+package sensitive
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PermissionChecker is satisfied by *rbac.Repository.
+type PermissionChecker interface {
+	HasAnyPermission(ctx context.Context, roles []string, codes ...string) (bool, error)
+}
+
+type Handler struct {
+	svc   *Service
+	perms PermissionChecker
+}
+
+func NewHandler(svc *Service, perms PermissionChecker) *Handler {
+	return &Handler{svc: svc, perms: perms}
+}
+
+func toFiberError(err error, fallback string) error {
+	var vErr *ValidationError
+	switch {
+	case errors.As(err, &vErr):
+		return fiber.NewError(fiber.StatusBadRequest, vErr.Message)
+	case errors.Is(err, ErrUserNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, ErrNotConfigured):
+		return fiber.NewError(fiber.StatusServiceUnavailable, err.Error())
+	}
+	log.Printf("sensitive: %v", err)
+	return fiber.NewError(fiber.StatusInternalServerError, fallback)
+}
+
+func parseID(c *fiber.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	return id, nil
+}
+
+func parseInput(c *fiber.Ctx) (*Input, error) {
+	var in Input
+	if err := c.BodyParser(&in); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	return &in, nil
+}
+
+// GET /api/me/sensitive-data - data bank/NPWP/NIK saya (selalu di-mask)
+func (h *Handler) GetMine(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	rec, err := h.svc.Get(c.Context(), userID, userID, false)
+	if err != nil {
+		return toFiberError(err, "failed to fetch sensitive data")
+	}
+	return c.JSON(rec)
+}
+
+// PUT /api/me/sensitive-data - field yang tidak dikirim tidak diubah
+func (h *Handler) UpdateMine(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	in, err := parseInput(c)
+	if err != nil {
+		return err
+	}
+	rec, err := h.svc.Update(c.Context(), userID, userID, in)
+	if err != nil {
+		return toFiberError(err, "failed to update sensitive data")
+	}
+	return c.JSON(rec)
+}
+
+// GET /api/employees/:id/sensitive-data?unmask=true
+// unmask butuh permission UNMASK_SENSITIVE_DATA dan dicatat di audit log.
+func (h *Handler) Get(c *fiber.Ctx) error {
+	actorID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	unmask := c.QueryBool("unmask")
+	if unmask {
+		roles, _ := c.Locals("roles").([]string)
+		allowed, err := h.perms.HasAnyPermission(c.Context(), roles, "UNMASK_SENSITIVE_DATA")
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "failed to check permission")
+		}
+		if !allowed {
+			return fiber.NewError(fiber.StatusForbidden, "insufficient permission")
+		}
+	}
+	rec, err := h.svc.Get(c.Context(), actorID, id, unmask)
+	if err != nil {
+		return toFiberError(err, "failed to fetch sensitive data")
+	}
+	return c.JSON(rec)
+}
+
+// PUT /api/employees/:id/sensitive-data
+func (h *Handler) Update(c *fiber.Ctx) error {
+	actorID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	in, err := parseInput(c)
+	if err != nil {
+		return err
+	}
+	rec, err := h.svc.Update(c.Context(), actorID, id, in)
+	if err != nil {
+		return toFiberError(err, "failed to update sensitive data")
+	}
+	return c.JSON(rec)
+}
+
+// GET /api/sensitive-data/keys - key aktif dan jumlah record per key
+func (h *Handler) KeyStatus(c *fiber.Ctx) error {
+	st, err := h.svc.KeyStatus(c.Context())
+	if err != nil {
+		return toFiberError(err, "failed to fetch key status")
+	}
+	return c.JSON(st)
+}
+
+// POST /api/sensitive-data/keys/rotate - re-encrypt sekarang tanpa menunggu scheduler
+func (h *Handler) Rotate(c *fiber.Ctx) error {
+	actorID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	if _, err := h.svc.Rekey(c.Context(), actorID); err != nil {
+		return toFiberError(err, "failed to rotate encryption key")
+	}
+	st, err := h.svc.KeyStatus(c.Context())
+	if err != nil {
+		return toFiberError(err, "failed to fetch key status")
+	}
+	return c.JSON(st)
+}
@@ -0,0 +1,67 @@
+package sensitive
+
+import "time"
+
+// Record is the payroll identity data of one employee. Nilai bank_account,
+// npwp dan nik di-mask kecuali dibaca dengan permission UNMASK_SENSITIVE_DATA.
+type Record struct {
+	UserID            int64      `json:"user_id"`
+	BankName          string     `json:"bank_name"`
+	BankAccountHolder string     `json:"bank_account_holder"`
+	BankAccount       string     `json:"bank_account"`
+	NPWP              string     `json:"npwp"`
+	NIK               string     `json:"nik"`
+	Masked            bool       `json:"masked"`
+	UpdatedBy         *int64     `json:"updated_by,omitempty"`
+	UpdatedByName     string     `json:"updated_by_name,omitempty"`
+	UpdatedAt         *time.Time `json:"updated_at,omitempty"`
+}
+
+// Input updates the record. Field nil = tidak diubah, string kosong =
+// dihapus, karena client hanya pernah melihat nilai yang di-mask.
+type Input struct {
+	BankName          *string `json:"bank_name"`
+	BankAccountHolder *string `json:"bank_account_holder"`
+	BankAccount       *string `json:"bank_account"`
+	NPWP              *string `json:"npwp"`
+	NIK               *string `json:"nik"`
+}
+
+// KeyUsage is the number of records encrypted with one key.
+type KeyUsage struct {
+	KeyID  string `json:"key_id"`
+	Rows   int    `json:"rows"`
+	Active bool   `json:"active"`
+}
+
+// KeyStatus is shown on the key rotation page.
+type KeyStatus struct {
+	ActiveKey string     `json:"active_key"`
+	Keys      []KeyUsage `json:"keys"`
+	// Record yang masih memakai key lama dan menunggu re-encrypt.
+	PendingRotation int `json:"pending_rotation"`
+}
+
+// row is the stored form of a record; *_enc berisi ciphertext dari
+// crypto.Keyring.
+type row struct {
+	UserID            int64
+	BankName          string
+	BankAccountHolder string
+	BankAccountEnc    string
+	NPWPEnc           string
+	NIKEnc            string
+	KeyID             string
+	UpdatedBy         *int64
+	UpdatedByName     string
+	UpdatedAt         time.Time
+}
+
+// sealedNIK is a dependent NIK ciphertext stored outside
+// employee_sensitive_data: employee_dependents.nik_enc atau payload.nik
+// pengajuan perubahan tanggungan. Key id adalah prefix ciphertext.
+type sealedNIK struct {
+	ID     int64
+	UserID int64
+	Enc    string
+}
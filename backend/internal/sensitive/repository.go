@@ -0,0 +1,221 @@
+package sensitive
+
+import (
+	"context"
+	"database/sql"
+)
+
+// dbtx is satisfied by both *sql.DB and *sql.Tx.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type Repository struct {
+	db   dbtx
+	conn *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db, conn: db}
+}
+
+func (r *Repository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return r.conn.BeginTx(ctx, nil)
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx.
+func (r *Repository) WithTx(tx *sql.Tx) *Repository {
+	return &Repository{db: tx, conn: r.conn}
+}
+
+// LockUser locks the users row so concurrent updates of the record are
+// serialized (row sensitive data mungkin belum ada untuk di-lock).
+func (r *Repository) LockUser(ctx context.Context, userID int64) error {
+	var x int64
+	return r.db.QueryRowContext(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&x)
+}
+
+func (r *Repository) UserExists(ctx context.Context, userID int64) (bool, error) {
+	var ok bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&ok)
+	return ok, err
+}
+
+const rowSelect = `
+	SELECT s.user_id, COALESCE(s.bank_name, ''), COALESCE(s.bank_account_holder, ''),
+		COALESCE(s.bank_account_enc, ''), COALESCE(s.npwp_enc, ''), COALESCE(s.nik_enc, ''),
+		s.key_id, s.updated_by, COALESCE(u.name, ''), s.updated_at
+	FROM employee_sensitive_data s
+	LEFT JOIN users u ON u.id = s.updated_by
+`
+
+func scanRow(sc interface{ Scan(dest ...any) error }) (*row, error) {
+	var rw row
+	var updatedBy sql.NullInt64
+	err := sc.Scan(&rw.UserID, &rw.BankName, &rw.BankAccountHolder,
+		&rw.BankAccountEnc, &rw.NPWPEnc, &rw.NIKEnc,
+		&rw.KeyID, &updatedBy, &rw.UpdatedByName, &rw.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if updatedBy.Valid {
+		id := updatedBy.Int64
+		rw.UpdatedBy = &id
+	}
+	return &rw, nil
+}
+
+// Find returns the stored record, sql.ErrNoRows kalau belum pernah diisi.
+func (r *Repository) Find(ctx context.Context, userID int64) (*row, error) {
+	return scanRow(r.db.QueryRowContext(ctx, rowSelect+` WHERE s.user_id = $1`, userID))
+}
+
+func (r *Repository) Upsert(ctx context.Context, rw *row) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO employee_sensitive_data
+			(user_id, bank_name, bank_account_holder, bank_account_enc, npwp_enc, nik_enc, key_id, updated_by, updated_at)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7, $8, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			bank_name = EXCLUDED.bank_name,
+			bank_account_holder = EXCLUDED.bank_account_holder,
+			bank_account_enc = EXCLUDED.bank_account_enc,
+			npwp_enc = EXCLUDED.npwp_enc,
+			nik_enc = EXCLUDED.nik_enc,
+			key_id = EXCLUDED.key_id,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = NOW()
+	`, rw.UserID, rw.BankName, rw.BankAccountHolder, rw.BankAccountEnc, rw.NPWPEnc, rw.NIKEnc, rw.KeyID, rw.UpdatedBy)
+	return err
+}
+
+// ClaimStale locks up to limit records that are not encrypted with
+// activeKey. SKIP LOCKED supaya beberapa instance bisa jalan bersamaan.
+func (r *Repository) ClaimStale(ctx context.Context, activeKey string, limit int) ([]row, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT s.user_id, COALESCE(s.bank_name, ''), COALESCE(s.bank_account_holder, ''),
+			COALESCE(s.bank_account_enc, ''), COALESCE(s.npwp_enc, ''), COALESCE(s.nik_enc, ''),
+			s.key_id, s.updated_by, '', s.updated_at
+		FROM employee_sensitive_data s
+		WHERE s.key_id <> $1
+		ORDER BY s.user_id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, activeKey, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []row
+	for rows.Next() {
+		rw, err := scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *rw)
+	}
+	return out, rows.Err()
+}
+
+// Reencrypt replaces the ciphertexts without touching updated_by/updated_at;
+// rotasi key bukan perubahan data.
+func (r *Repository) Reencrypt(ctx context.Context, rw *row) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE employee_sensitive_data
+		SET bank_account_enc = NULLIF($2, ''), npwp_enc = NULLIF($3, ''), nik_enc = NULLIF($4, ''), key_id = $5
+		WHERE user_id = $1
+	`, rw.UserID, rw.BankAccountEnc, rw.NPWPEnc, rw.NIKEnc, rw.KeyID)
+	return err
+}
+
+// Ciphertext NIK tanggungan disimpan dengan format "<key id>:<data>";
+// plaintext lama (tanpa ':') dienkripsi job dependent, bukan rotasi key.
+const (
+	dependentNIKKey = `split_part(nik_enc, ':', 1)`
+	payloadNIKKey   = `split_part(payload->>'nik', ':', 1)`
+)
+
+// ClaimStaleDependentNIKs locks up to limit dependent NIKs not encrypted
+// with activeKey.
+func (r *Repository) ClaimStaleDependentNIKs(ctx context.Context, activeKey string, limit int) ([]sealedNIK, error) {
+	return r.claimNIKs(ctx, `
+		SELECT id, user_id, nik_enc FROM employee_dependents
+		WHERE nik_enc IS NOT NULL AND `+dependentNIKKey+` <> $1
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, activeKey, limit)
+}
+
+// ClaimStalePayloadNIKs locks up to limit dependent change requests whose
+// payload NIK is not encrypted with activeKey (status apa pun).
+func (r *Repository) ClaimStalePayloadNIKs(ctx context.Context, activeKey string, limit int) ([]sealedNIK, error) {
+	return r.claimNIKs(ctx, `
+		SELECT id, user_id, payload->>'nik' FROM dependent_change_requests
+		WHERE payload->>'nik' LIKE '%:%' AND `+payloadNIKKey+` <> $1
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, activeKey, limit)
+}
+
+func (r *Repository) claimNIKs(ctx context.Context, q, activeKey string, limit int) ([]sealedNIK, error) {
+	rows, err := r.db.QueryContext(ctx, q, activeKey, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []sealedNIK
+	for rows.Next() {
+		var n sealedNIK
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Enc); err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+// ReencryptDependentNIK replaces the NIK ciphertext of a dependent.
+func (r *Repository) ReencryptDependentNIK(ctx context.Context, id int64, enc string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE employee_dependents SET nik_enc = $2 WHERE id = $1`, id, enc)
+	return err
+}
+
+// ReencryptPayloadNIK replaces the NIK ciphertext inside a change request payload.
+func (r *Repository) ReencryptPayloadNIK(ctx context.Context, id int64, enc string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE dependent_change_requests SET payload = jsonb_set(payload, '{nik}', to_jsonb($2::text)) WHERE id = $1
+	`, id, enc)
+	return err
+}
+
+// KeyCounts returns the number of records per key id, termasuk NIK
+// tanggungan dan payload pengajuannya.
+func (r *Repository) KeyCounts(ctx context.Context) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT key_id, COUNT(*) FROM (
+			SELECT key_id FROM employee_sensitive_data
+			UNION ALL
+			SELECT `+dependentNIKKey+` FROM employee_dependents WHERE nik_enc IS NOT NULL
+			UNION ALL
+			SELECT `+payloadNIKKey+` FROM dependent_change_requests WHERE payload->>'nik' LIKE '%:%'
+		) k
+		GROUP BY key_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]int{}
+	for rows.Next() {
+		var id string
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		out[id] = n
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,461 @@
+package sensitive
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"hr-portal-backend/pkg/crypto"
+)
+
+var (
+	ErrUserNotFound  = errors.New("employee not found")
+	ErrNotConfigured = errors.New("sensitive data encryption is not configured")
+)
+
+// ValidationError is returned for invalid input; handler mengembalikan 400.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string { return e.Message }
+
+func invalid(msg string) error { return &ValidationError{Message: msg} }
+
+// Aksi audit log.
+const (
+	ActionUnmasked = "SENSITIVE_DATA_UNMASKED"
+	ActionUpdated  = "SENSITIVE_DATA_UPDATED"
+	ActionRekeyed  = "SENSITIVE_DATA_REKEYED"
+)
+
+// Jumlah record per transaksi saat rotasi key.
+const rekeyBatch = 100
+
+// AuditLogger is satisfied by *audit.Service.
+type AuditLogger interface {
+	LogTx(ctx context.Context, tx *sql.Tx, actorID int64, action, entity, entityID string, details any) error
+}
+
+type Service struct {
+	repo  *Repository
+	keys  *crypto.Keyring // nil = FIELD_ENCRYPTION_KEYS belum diset
+	audit AuditLogger
+}
+
+func NewService(repo *Repository, keys *crypto.Keyring, audit AuditLogger) *Service {
+	return &Service{repo: repo, keys: keys, audit: audit}
+}
+
+// aad binds a ciphertext to its field and employee.
+func aad(field string, userID int64) string {
+	return field + ":" + strconv.FormatInt(userID, 10)
+}
+
+// plain is a decrypted record.
+type plain struct {
+	BankAccount string
+	NPWP        string
+	NIK         string
+}
+
+func (s *Service) decrypt(rw *row) (*plain, error) {
+	var p plain
+	for _, f := range []struct {
+		name string
+		enc  string
+		dst  *string
+	}{
+		{"bank_account", rw.BankAccountEnc, &p.BankAccount},
+		{"npwp", rw.NPWPEnc, &p.NPWP},
+		{"nik", rw.NIKEnc, &p.NIK},
+	} {
+		if f.enc == "" {
+			continue
+		}
+		v, err := s.keys.Decrypt(f.enc, aad(f.name, rw.UserID))
+		if err != nil {
+			return nil, fmt.Errorf("decrypt %s of user %d: %w", f.name, rw.UserID, err)
+		}
+		*f.dst = v
+	}
+	return &p, nil
+}
+
+// encrypt seals p into rw with the active key.
+func (s *Service) encrypt(rw *row, p *plain) error {
+	for _, f := range []struct {
+		name  string
+		value string
+		dst   *string
+	}{
+		{"bank_account", p.BankAccount, &rw.BankAccountEnc},
+		{"npwp", p.NPWP, &rw.NPWPEnc},
+		{"nik", p.NIK, &rw.NIKEnc},
+	} {
+		*f.dst = ""
+		if f.value == "" {
+			continue
+		}
+		enc, err := s.keys.Encrypt(f.value, aad(f.name, rw.UserID))
+		if err != nil {
+			return err
+		}
+		*f.dst = enc
+	}
+	rw.KeyID = s.keys.ActiveKey()
+	return nil
+}
+
+// mask hides everything except the last four characters.
+func mask(v string) string {
+	if v == "" {
+		return ""
+	}
+	if len(v) <= 4 {
+		return strings.Repeat("*", len(v))
+	}
+	return strings.Repeat("*", len(v)-4) + v[len(v)-4:]
+}
+
+func toRecord(rw *row, p *plain, unmask bool) *Record {
+	rec := &Record{
+		UserID:            rw.UserID,
+		BankName:          rw.BankName,
+		BankAccountHolder: rw.BankAccountHolder,
+		BankAccount:       p.BankAccount,
+		NPWP:              p.NPWP,
+		NIK:               p.NIK,
+		UpdatedBy:         rw.UpdatedBy,
+		UpdatedByName:     rw.UpdatedByName,
+	}
+	if !rw.UpdatedAt.IsZero() {
+		t := rw.UpdatedAt
+		rec.UpdatedAt = &t
+	}
+	if !unmask {
+		rec.BankAccount = mask(rec.BankAccount)
+		rec.NPWP = mask(rec.NPWP)
+		rec.NIK = mask(rec.NIK)
+		rec.Masked = true
+	}
+	return rec
+}
+
+// load reads and decrypts the record; record kosong kalau belum pernah diisi.
+func (s *Service) load(ctx context.Context, repo *Repository, userID int64) (*row, *plain, error) {
+	rw, err := repo.Find(ctx, userID)
+	if err == sql.ErrNoRows {
+		ok, err := repo.UserExists(ctx, userID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			return nil, nil, ErrUserNotFound
+		}
+		return &row{UserID: userID}, &plain{}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := s.decrypt(rw)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rw, p, nil
+}
+
+// Get returns the record, masked by default. Pembacaan tanpa mask dicatat di
+// audit log; cek permission dilakukan handler.
+func (s *Service) Get(ctx context.Context, actorID, userID int64, unmask bool) (*Record, error) {
+	if s.keys == nil {
+		return nil, ErrNotConfigured
+	}
+	if !unmask {
+		rw, p, err := s.load(ctx, s.repo, userID)
+		if err != nil {
+			return nil, err
+		}
+		return toRecord(rw, p, false), nil
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	rw, p, err := s.load(ctx, s.repo.WithTx(tx), userID)
+	if err != nil {
+		return nil, err
+	}
+	id := strconv.FormatInt(userID, 10)
+	if err := s.audit.LogTx(ctx, tx, actorID, ActionUnmasked, "user", id, nil); err != nil {
+		return nil, err
+	}
+	// Data baru dikembalikan setelah audit entry tersimpan.
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return toRecord(rw, p, true), nil
+}
+
+// digits strips the separators people usually type (spasi, titik, strip).
+func digits(v string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '.', '-':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(v))
+}
+
+func allDigits(v string) bool {
+	for _, r := range v {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// apply validates in and writes it over rw/p; returns the changed fields.
+func apply(rw *row, p *plain, in *Input) ([]string, error) {
+	var changed []string
+	if in.BankName != nil {
+		v := strings.TrimSpace(*in.BankName)
+		if len(v) > 100 {
+			return nil, invalid("bank_name must be at most 100 characters")
+		}
+		if v != rw.BankName {
+			rw.BankName = v
+			changed = append(changed, "bank_name")
+		}
+	}
+	if in.BankAccountHolder != nil {
+		v := strings.TrimSpace(*in.BankAccountHolder)
+		if len(v) > 100 {
+			return nil, invalid("bank_account_holder must be at most 100 characters")
+		}
+		if v != rw.BankAccountHolder {
+			rw.BankAccountHolder = v
+			changed = append(changed, "bank_account_holder")
+		}
+	}
+	if in.BankAccount != nil {
+		v := digits(*in.BankAccount)
+		if v != "" && (!allDigits(v) || len(v) < 6 || len(v) > 20) {
+			return nil, invalid("bank_account must be 6-20 digits")
+		}
+		if v != p.BankAccount {
+			p.BankAccount = v
+			changed = append(changed, "bank_account")
+		}
+	}
+	if in.NPWP != nil {
+		// NPWP lama 15 digit, format baru 16 digit (sama dengan NIK).
+		v := digits(*in.NPWP)
+		if v != "" && (!allDigits(v) || (len(v) != 15 && len(v) != 16)) {
+			return nil, invalid("npwp must be 15 or 16 digits")
+		}
+		if v != p.NPWP {
+			p.NPWP = v
+			changed = append(changed, "npwp")
+		}
+	}
+	if in.NIK != nil {
+		v := digits(*in.NIK)
+		if v != "" && (!allDigits(v) || len(v) != 16) {
+			return nil, invalid("nik must be 16 digits")
+		}
+		if v != p.NIK {
+			p.NIK = v
+			changed = append(changed, "nik")
+		}
+	}
+	return changed, nil
+}
+
+// Update changes the record and returns it masked. Audit log hanya mencatat
+// nama field yang berubah, bukan nilainya.
+func (s *Service) Update(ctx context.Context, actorID, userID int64, in *Input) (*Record, error) {
+	if s.keys == nil {
+		return nil, ErrNotConfigured
+	}
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	if err := repo.LockUser(ctx, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	rw, p, err := s.load(ctx, repo, userID)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := apply(rw, p, in)
+	if err != nil {
+		return nil, err
+	}
+	if len(changed) > 0 {
+		// Semua field dienkripsi ulang dengan key aktif supaya satu row
+		// selalu memakai satu key.
+		if err := s.encrypt(rw, p); err != nil {
+			return nil, err
+		}
+		rw.UpdatedBy = &actorID
+		if err := repo.Upsert(ctx, rw); err != nil {
+			return nil, err
+		}
+		id := strconv.FormatInt(userID, 10)
+		if err := s.audit.LogTx(ctx, tx, actorID, ActionUpdated, "user", id, map[string]any{"fields": changed}); err != nil {
+			return nil, err
+		}
+		if rw, err = repo.Find(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return toRecord(rw, p, false), nil
+}
+
+// KeyStatus reports how many records use each key.
+func (s *Service) KeyStatus(ctx context.Context) (*KeyStatus, error) {
+	if s.keys == nil {
+		return nil, ErrNotConfigured
+	}
+	counts, err := s.repo.KeyCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	st := &KeyStatus{ActiveKey: s.keys.ActiveKey(), Keys: []KeyUsage{}}
+	for id, n := range counts {
+		active := id == st.ActiveKey
+		st.Keys = append(st.Keys, KeyUsage{KeyID: id, Rows: n, Active: active})
+		if !active {
+			st.PendingRotation += n
+		}
+	}
+	sort.Slice(st.Keys, func(i, j int) bool { return st.Keys[i].KeyID < st.Keys[j].KeyID })
+	return st, nil
+}
+
+// Rekey re-encrypts every record that still uses an old key with the active
+// key, termasuk NIK tanggungan. actorID 0 = dijalankan scheduler. Mengembalikan jumlah record.
+func (s *Service) Rekey(ctx context.Context, actorID int64) (int, error) {
+	if s.keys == nil {
+		return 0, ErrNotConfigured
+	}
+	total := 0
+	for {
+		n, err := s.rekeyBatch(ctx, actorID)
+		total += n
+		if err != nil || n < rekeyBatch {
+			return total, err
+		}
+	}
+}
+
+// dependentNIKField must match dependent.nikAAD: NIK tanggungan dienkripsi
+// dengan keyring yang sama dan ikut dirotasi di sini.
+const dependentNIKField = "dependent_nik"
+
+func (s *Service) rekeyBatch(ctx context.Context, actorID int64) (int, error) {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	rows, err := repo.ClaimStale(ctx, s.keys.ActiveKey(), rekeyBatch)
+	if err != nil {
+		return 0, err
+	}
+	from := map[string]int{}
+	for i := range rows {
+		rw := &rows[i]
+		p, err := s.decrypt(rw)
+		if err != nil {
+			return 0, err
+		}
+		from[rw.KeyID]++
+		if err := s.encrypt(rw, p); err != nil {
+			return 0, err
+		}
+		if err := repo.Reencrypt(ctx, rw); err != nil {
+			return 0, err
+		}
+	}
+	n := len(rows)
+	for _, src := range []struct {
+		claim func(context.Context, string, int) ([]sealedNIK, error)
+		save  func(context.Context, int64, string) error
+	}{
+		{repo.ClaimStaleDependentNIKs, repo.ReencryptDependentNIK},
+		{repo.ClaimStalePayloadNIKs, repo.ReencryptPayloadNIK},
+	} {
+		niks, err := src.claim(ctx, s.keys.ActiveKey(), rekeyBatch)
+		if err != nil {
+			return 0, err
+		}
+		for _, nik := range niks {
+			enc, err := s.rekeyNIK(nik)
+			if err != nil {
+				return 0, err
+			}
+			keyID, _, _ := strings.Cut(nik.Enc, ":")
+			from[keyID]++
+			if err := src.save(ctx, nik.ID, enc); err != nil {
+				return 0, err
+			}
+		}
+		n += len(niks)
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	details := map[string]any{"to": s.keys.ActiveKey(), "from": from, "rows": n}
+	if err := s.audit.LogTx(ctx, tx, actorID, ActionRekeyed, "encryption_key", s.keys.ActiveKey(), details); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// rekeyNIK re-encrypts a dependent NIK with the active key.
+func (s *Service) rekeyNIK(n sealedNIK) (string, error) {
+	v, err := s.keys.Decrypt(n.Enc, aad(dependentNIKField, n.UserID))
+	if err != nil {
+		return "", fmt.Errorf("decrypt dependent nik %d of user %d: %w", n.ID, n.UserID, err)
+	}
+	return s.keys.Encrypt(v, aad(dependentNIKField, n.UserID))
+}
+
+// RekeyPending is the scheduler job; tidak melakukan apa-apa kalau enkripsi
+// belum dikonfigurasi.
+func (s *Service) RekeyPending(ctx context.Context) error {
+	if s.keys == nil {
+		return nil
+	}
+	n, err := s.Rekey(ctx, 0)
+	if n > 0 {
+		log.Printf("sensitive: re-encrypted %d records with key %s", n, s.keys.ActiveKey())
+	}
+	return err
+}
@@ -0,0 +1,329 @@
+package skill
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func toFiberError(err error, fallback string) error {
+	var vErr *ValidationError
+	switch {
+	case errors.As(err, &vErr):
+		return fiber.NewError(fiber.StatusBadRequest, vErr.Message)
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrUserNotFound), errors.Is(err, ErrCertNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, ErrDuplicateName):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, fallback)
+}
+
+func parseParam(c *fiber.Ctx, name string) (int64, error) {
+	id, err := strconv.ParseInt(c.Params(name), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "invalid "+name)
+	}
+	return id, nil
+}
+
+func parseID(c *fiber.Ctx) (int64, error) {
+	return parseParam(c, "id")
+}
+
+func sessionUser(c *fiber.Ctx) (int64, error) {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return 0, fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	return userID, nil
+}
+
+// ==========================
+// Catalog
+// ==========================
+
+// GET /api/skills?include_inactive=true
+func (h *Handler) ListSkills(c *fiber.Ctx) error {
+	list, err := h.svc.ListSkills(c.Context(), c.QueryBool("include_inactive"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch skills")
+	}
+	return c.JSON(list)
+}
+
+// POST /api/skills
+func (h *Handler) CreateSkill(c *fiber.Ctx) error {
+	var in SkillInput
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	sk, err := h.svc.CreateSkill(c.Context(), &in)
+	if err != nil {
+		return toFiberError(err, "failed to create skill")
+	}
+	return c.Status(fiber.StatusCreated).JSON(sk)
+}
+
+// PUT /api/skills/:id
+func (h *Handler) UpdateSkill(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	var in SkillInput
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	sk, err := h.svc.UpdateSkill(c.Context(), id, &in)
+	if err != nil {
+		return toFiberError(err, "failed to update skill")
+	}
+	return c.JSON(sk)
+}
+
+// DELETE /api/skills/:id - nonaktifkan, data karyawan tetap ada
+func (h *Handler) DeleteSkill(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	if err := h.svc.DeactivateSkill(c.Context(), id); err != nil {
+		return toFiberError(err, "failed to deactivate skill")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GET /api/skills/search?skills=1,2&min_level=3&match=all|any&department=IT&certified=true&limit=50
+func (h *Handler) Search(c *fiber.Ctx) error {
+	var ids []int64
+	for _, part := range strings.Split(c.Query("skills"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "skills must be a comma separated list of skill ids")
+		}
+		ids = append(ids, id)
+	}
+	list, err := h.svc.Search(c.Context(), SearchFilter{
+		SkillIDs:   ids,
+		MinLevel:   c.QueryInt("min_level"),
+		MatchAll:   !strings.EqualFold(c.Query("match"), "any"),
+		Department: c.Query("department"),
+		Certified:  c.QueryBool("certified"),
+		Limit:      c.QueryInt("limit"),
+	})
+	if err != nil {
+		return toFiberError(err, "failed to search employees")
+	}
+	return c.JSON(list)
+}
+
+// ==========================
+// Self-service
+// ==========================
+
+// GET /api/me/skills - skill & sertifikat saya
+func (h *Handler) GetMine(c *fiber.Ctx) error {
+	userID, err := sessionUser(c)
+	if err != nil {
+		return err
+	}
+	p, err := h.svc.Profile(c.Context(), userID)
+	if err != nil {
+		return toFiberError(err, "failed to fetch skills")
+	}
+	return c.JSON(p)
+}
+
+// PUT /api/me/skills/:skillId  body: {"level": 3, "years_experience": 2, "notes": "..."}
+func (h *Handler) SetMine(c *fiber.Ctx) error {
+	userID, err := sessionUser(c)
+	if err != nil {
+		return err
+	}
+	return h.setSkill(c, userID)
+}
+
+// DELETE /api/me/skills/:skillId
+func (h *Handler) RemoveMine(c *fiber.Ctx) error {
+	userID, err := sessionUser(c)
+	if err != nil {
+		return err
+	}
+	return h.removeSkill(c, userID)
+}
+
+// POST /api/me/certifications
+func (h *Handler) CreateMyCertification(c *fiber.Ctx) error {
+	userID, err := sessionUser(c)
+	if err != nil {
+		return err
+	}
+	return h.createCert(c, userID, userID)
+}
+
+// PUT /api/me/certifications/:id
+func (h *Handler) UpdateMyCertification(c *fiber.Ctx) error {
+	userID, err := sessionUser(c)
+	if err != nil {
+		return err
+	}
+	return h.updateCert(c, userID)
+}
+
+// DELETE /api/me/certifications/:id
+func (h *Handler) DeleteMyCertification(c *fiber.Ctx) error {
+	userID, err := sessionUser(c)
+	if err != nil {
+		return err
+	}
+	return h.deleteCert(c, userID)
+}
+
+// ==========================
+// HRD
+// ==========================
+
+// GET /api/employees/:id/skills
+func (h *Handler) GetForEmployee(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	p, err := h.svc.Profile(c.Context(), id)
+	if err != nil {
+		return toFiberError(err, "failed to fetch skills")
+	}
+	return c.JSON(p)
+}
+
+// PUT /api/employees/:id/skills/:skillId
+func (h *Handler) SetForEmployee(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	return h.setSkill(c, id)
+}
+
+// DELETE /api/employees/:id/skills/:skillId
+func (h *Handler) RemoveForEmployee(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	return h.removeSkill(c, id)
+}
+
+// POST /api/employees/:id/certifications
+func (h *Handler) CreateCertification(c *fiber.Ctx) error {
+	actorID, err := sessionUser(c)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	return h.createCert(c, actorID, id)
+}
+
+// PUT /api/certifications/:id
+func (h *Handler) UpdateCertification(c *fiber.Ctx) error {
+	return h.updateCert(c, 0)
+}
+
+// DELETE /api/certifications/:id
+func (h *Handler) DeleteCertification(c *fiber.Ctx) error {
+	return h.deleteCert(c, 0)
+}
+
+// GET /api/certifications/expiring?days=60
+func (h *Handler) ListExpiring(c *fiber.Ctx) error {
+	list, err := h.svc.ListExpiring(c.Context(), c.QueryInt("days"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch expiring certifications")
+	}
+	return c.JSON(list)
+}
+
+func (h *Handler) setSkill(c *fiber.Ctx, userID int64) error {
+	skillID, err := parseParam(c, "skillId")
+	if err != nil {
+		return err
+	}
+	var in EmployeeSkillInput
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	p, err := h.svc.SetEmployeeSkill(c.Context(), userID, skillID, &in)
+	if err != nil {
+		return toFiberError(err, "failed to save skill")
+	}
+	return c.JSON(p)
+}
+
+func (h *Handler) removeSkill(c *fiber.Ctx, userID int64) error {
+	skillID, err := parseParam(c, "skillId")
+	if err != nil {
+		return err
+	}
+	if err := h.svc.RemoveEmployeeSkill(c.Context(), userID, skillID); err != nil {
+		return toFiberError(err, "failed to remove skill")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) createCert(c *fiber.Ctx, actorID, userID int64) error {
+	var in CertificationInput
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	cert, err := h.svc.CreateCertification(c.Context(), actorID, userID, &in)
+	if err != nil {
+		return toFiberError(err, "failed to create certification")
+	}
+	return c.Status(fiber.StatusCreated).JSON(cert)
+}
+
+// updateCert: ownerID 0 = HR, selain itu hanya sertifikat milik ownerID.
+func (h *Handler) updateCert(c *fiber.Ctx, ownerID int64) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	var in CertificationInput
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	cert, err := h.svc.UpdateCertification(c.Context(), id, ownerID, &in)
+	if err != nil {
+		return toFiberError(err, "failed to update certification")
+	}
+	return c.JSON(cert)
+}
+
+func (h *Handler) deleteCert(c *fiber.Ctx, ownerID int64) error {
+	id, err := parseID(c)
+	if err != nil {
+		return err
+	}
+	if err := h.svc.DeleteCertification(c.Context(), id, ownerID); err != nil {
+		return toFiberError(err, "failed to delete certification")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
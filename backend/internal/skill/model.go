@@ -0,0 +1,126 @@
+package skill
+
+import "time"
+
+// Level kemampuan 1-5.
+const (
+	MinLevel = 1
+	MaxLevel = 5
+)
+
+// LevelNames maps a proficiency level to its label.
+var LevelNames = map[int]string{
+	1: "BEGINNER",
+	2: "ELEMENTARY",
+	3: "INTERMEDIATE",
+	4: "ADVANCED",
+	5: "EXPERT",
+}
+
+// Jenis sertifikasi/pelatihan.
+const (
+	KindCertification = "CERTIFICATION"
+	KindTraining      = "TRAINING"
+)
+
+// Status sertifikat, dihitung dari expiry_date.
+const (
+	CertValid    = "VALID"
+	CertExpiring = "EXPIRING"
+	CertExpired  = "EXPIRED"
+)
+
+// Skill is an entry of the skills catalog.
+type Skill struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Category    string    `json:"category,omitempty"`
+	Description string    `json:"description,omitempty"`
+	IsActive    bool      `json:"is_active"`
+	Employees   int       `json:"employees"` // jumlah karyawan yang punya skill ini
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type SkillInput struct {
+	Name        string `json:"name"`
+	Category    string `json:"category"`
+	Description string `json:"description"`
+	IsActive    *bool  `json:"is_active"`
+}
+
+// EmployeeSkill is one skill of an employee.
+type EmployeeSkill struct {
+	SkillID   int64     `json:"skill_id"`
+	SkillName string    `json:"skill_name"`
+	Category  string    `json:"category,omitempty"`
+	Level     int       `json:"level"`
+	LevelName string    `json:"level_name"`
+	Years     *float64  `json:"years_experience,omitempty"`
+	Notes     string    `json:"notes,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type EmployeeSkillInput struct {
+	Level int      `json:"level"`
+	Years *float64 `json:"years_experience"`
+	Notes string   `json:"notes"`
+}
+
+// Certification is a certificate or completed training of an employee.
+type Certification struct {
+	ID               int64      `json:"id"`
+	UserID           int64      `json:"user_id"`
+	EmployeeCode     string     `json:"employee_code,omitempty"`
+	EmployeeName     string     `json:"employee_name,omitempty"`
+	Kind             string     `json:"kind"`
+	Name             string     `json:"name"`
+	Issuer           string     `json:"issuer"`
+	CredentialNumber string     `json:"credential_number,omitempty"`
+	SkillID          *int64     `json:"skill_id,omitempty"`
+	SkillName        string     `json:"skill_name,omitempty"`
+	IssueDate        *time.Time `json:"issue_date,omitempty"`
+	ExpiryDate       *time.Time `json:"expiry_date,omitempty"`
+	Status           string     `json:"status"` // VALID, EXPIRING, EXPIRED
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+type CertificationInput struct {
+	Kind             string `json:"kind"`
+	Name             string `json:"name"`
+	Issuer           string `json:"issuer"`
+	CredentialNumber string `json:"credential_number"`
+	SkillID          *int64 `json:"skill_id"`
+	IssueDate        string `json:"issue_date"`  // YYYY-MM-DD
+	ExpiryDate       string `json:"expiry_date"` // YYYY-MM-DD, kosong = tidak kedaluwarsa
+}
+
+// Profile is the skills page of one employee.
+type Profile struct {
+	Skills         []EmployeeSkill `json:"skills"`
+	Certifications []Certification `json:"certifications"`
+}
+
+// SearchFilter is used to find employees for staffing.
+type SearchFilter struct {
+	SkillIDs   []int64
+	MinLevel   int
+	MatchAll   bool   // true = harus punya semua skill
+	Department string // kosong = semua
+	Certified  bool   // hanya yang punya sertifikat valid untuk salah satu skill
+	Limit      int
+}
+
+// Match is one employee found by a skill search.
+type Match struct {
+	UserID       int64           `json:"user_id"`
+	EmployeeCode string          `json:"employee_code"`
+	Name         string          `json:"name"`
+	Department   string          `json:"department"`
+	JobTitle     string          `json:"job_title"`
+	Matched      int             `json:"matched"` // jumlah skill yang cocok
+	Score        int             `json:"score"`   // total level skill yang cocok
+	Skills       []EmployeeSkill `json:"skills"`
+	// Sertifikat valid yang terkait dengan skill yang dicari.
+	Certifications []string `json:"certifications"`
+}
@@ -0,0 +1,387 @@
+package skill
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// dbtx is satisfied by both *sql.DB and *sql.Tx.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type Repository struct {
+	db   dbtx
+	conn *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db, conn: db}
+}
+
+func (r *Repository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return r.conn.BeginTx(ctx, nil)
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx.
+func (r *Repository) WithTx(tx *sql.Tx) *Repository {
+	return &Repository{db: tx, conn: r.conn}
+}
+
+func (r *Repository) UserExists(ctx context.Context, userID int64) (bool, error) {
+	var ok bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&ok)
+	return ok, err
+}
+
+// ==========================
+// Catalog
+// ==========================
+
+const skillSelect = `
+	SELECT s.id, s.name, COALESCE(s.category, ''), COALESCE(s.description, ''), s.is_active,
+		(SELECT COUNT(*) FROM employee_skills es WHERE es.skill_id = s.id), s.created_at
+	FROM skills s
+`
+
+func scanSkill(row interface{ Scan(dest ...any) error }) (*Skill, error) {
+	var s Skill
+	err := row.Scan(&s.ID, &s.Name, &s.Category, &s.Description, &s.IsActive, &s.Employees, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *Repository) ListSkills(ctx context.Context, includeInactive bool) ([]Skill, error) {
+	where := ` WHERE s.is_active`
+	if includeInactive {
+		where = ``
+	}
+	rows, err := r.db.QueryContext(ctx, skillSelect+where+` ORDER BY s.category NULLS LAST, s.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Skill{}
+	for rows.Next() {
+		s, err := scanSkill(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *s)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) FindSkill(ctx context.Context, id int64) (*Skill, error) {
+	return scanSkill(r.db.QueryRowContext(ctx, skillSelect+` WHERE s.id = $1`, id))
+}
+
+func (r *Repository) CreateSkill(ctx context.Context, s *Skill) error {
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO skills (name, category, description, is_active)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4)
+		RETURNING id
+	`, s.Name, s.Category, s.Description, s.IsActive).Scan(&s.ID)
+}
+
+// UpdateSkill saves s; active nil = is_active tidak berubah.
+func (r *Repository) UpdateSkill(ctx context.Context, s *Skill, active *bool) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE skills SET name = $2, category = NULLIF($3, ''), description = NULLIF($4, ''),
+			is_active = COALESCE($5, is_active)
+		WHERE id = $1
+	`, s.ID, s.Name, s.Category, s.Description, active)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ==========================
+// Employee skills
+// ==========================
+
+func (r *Repository) ListEmployeeSkills(ctx context.Context, userID int64) ([]EmployeeSkill, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT s.id, s.name, COALESCE(s.category, ''), es.level, es.years_experience,
+			COALESCE(es.notes, ''), es.updated_at
+		FROM employee_skills es
+		JOIN skills s ON s.id = es.skill_id
+		WHERE es.user_id = $1
+		ORDER BY es.level DESC, s.name
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []EmployeeSkill{}
+	for rows.Next() {
+		var es EmployeeSkill
+		var years sql.NullFloat64
+		if err := rows.Scan(&es.SkillID, &es.SkillName, &es.Category, &es.Level, &years, &es.Notes, &es.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if years.Valid {
+			es.Years = &years.Float64
+		}
+		es.LevelName = LevelNames[es.Level]
+		out = append(out, es)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) UpsertEmployeeSkill(ctx context.Context, userID, skillID int64, in *EmployeeSkillInput) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO employee_skills (user_id, skill_id, level, years_experience, notes, updated_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NOW())
+		ON CONFLICT (user_id, skill_id) DO UPDATE SET
+			level = EXCLUDED.level,
+			years_experience = EXCLUDED.years_experience,
+			notes = EXCLUDED.notes,
+			updated_at = NOW()
+	`, userID, skillID, in.Level, in.Years, in.Notes)
+	return err
+}
+
+func (r *Repository) DeleteEmployeeSkill(ctx context.Context, userID, skillID int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM employee_skills WHERE user_id = $1 AND skill_id = $2`, userID, skillID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ==========================
+// Certifications
+// ==========================
+
+const certSelect = `
+	SELECT c.id, c.user_id, u.employee_code, u.name, c.kind, c.name, c.issuer,
+		COALESCE(c.credential_number, ''), c.skill_id, COALESCE(s.name, ''),
+		c.issue_date, c.expiry_date, c.created_at, c.updated_at
+	FROM employee_certifications c
+	JOIN users u ON u.id = c.user_id
+	LEFT JOIN skills s ON s.id = c.skill_id
+`
+
+func scanCert(row interface{ Scan(dest ...any) error }) (*Certification, error) {
+	var c Certification
+	var skillID sql.NullInt64
+	var issue, expiry sql.NullTime
+	err := row.Scan(&c.ID, &c.UserID, &c.EmployeeCode, &c.EmployeeName, &c.Kind, &c.Name, &c.Issuer,
+		&c.CredentialNumber, &skillID, &c.SkillName, &issue, &expiry, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if skillID.Valid {
+		id := skillID.Int64
+		c.SkillID = &id
+	}
+	if issue.Valid {
+		c.IssueDate = &issue.Time
+	}
+	if expiry.Valid {
+		c.ExpiryDate = &expiry.Time
+	}
+	return &c, nil
+}
+
+func (r *Repository) queryCerts(ctx context.Context, tail string, args ...any) ([]Certification, error) {
+	rows, err := r.db.QueryContext(ctx, certSelect+tail, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Certification{}
+	for rows.Next() {
+		c, err := scanCert(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *c)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) ListCertifications(ctx context.Context, userID int64) ([]Certification, error) {
+	return r.queryCerts(ctx, ` WHERE c.user_id = $1 ORDER BY c.expiry_date NULLS LAST, c.name`, userID)
+}
+
+func (r *Repository) FindCertification(ctx context.Context, id int64) (*Certification, error) {
+	return scanCert(r.db.QueryRowContext(ctx, certSelect+` WHERE c.id = $1`, id))
+}
+
+func (r *Repository) CreateCertification(ctx context.Context, c *Certification, createdBy int64) error {
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO employee_certifications
+			(user_id, kind, name, issuer, credential_number, skill_id, issue_date, expiry_date, created_by)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9)
+		RETURNING id
+	`, c.UserID, c.Kind, c.Name, c.Issuer, c.CredentialNumber, c.SkillID, c.IssueDate, c.ExpiryDate, createdBy).Scan(&c.ID)
+}
+
+// UpdateCertification saves the fields; reminder otomatis aktif lagi kalau
+// expiry_date berubah (lihat ClaimExpiringUnalerted).
+func (r *Repository) UpdateCertification(ctx context.Context, c *Certification) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE employee_certifications SET
+			kind = $2, name = $3, issuer = $4, credential_number = NULLIF($5, ''),
+			skill_id = $6, issue_date = $7, expiry_date = $8, updated_at = NOW()
+		WHERE id = $1
+	`, c.ID, c.Kind, c.Name, c.Issuer, c.CredentialNumber, c.SkillID, c.IssueDate, c.ExpiryDate)
+	return err
+}
+
+func (r *Repository) DeleteCertification(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM employee_certifications WHERE id = $1`, id)
+	return err
+}
+
+// ListExpiring returns certificates of active employees expiring on or
+// before today + days, yang sudah lewat juga ikut.
+func (r *Repository) ListExpiring(ctx context.Context, days int) ([]Certification, error) {
+	return r.queryCerts(ctx, `
+		WHERE c.expiry_date IS NOT NULL AND c.expiry_date <= CURRENT_DATE + $1::int AND u.status = 'ACTIVE'
+		ORDER BY c.expiry_date, c.id
+	`, days)
+}
+
+// ClaimExpiringUnalerted is ListExpiring limited to certificates not yet
+// alerted for their current expiry date, locked for the transaction.
+func (r *Repository) ClaimExpiringUnalerted(ctx context.Context, days int) ([]Certification, error) {
+	return r.queryCerts(ctx, `
+		WHERE c.expiry_date IS NOT NULL AND c.expiry_date <= CURRENT_DATE + $1::int AND u.status = 'ACTIVE'
+		  AND c.expiry_alerted_for IS DISTINCT FROM c.expiry_date
+		ORDER BY c.expiry_date, c.id
+		FOR UPDATE OF c SKIP LOCKED
+	`, days)
+}
+
+func (r *Repository) MarkAlerted(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE employee_certifications SET expiry_alerted_for = expiry_date WHERE id = $1`, id)
+	return err
+}
+
+// ==========================
+// Search
+// ==========================
+
+// Search finds active employees having the skills at MinLevel or above,
+// diurutkan dari yang paling cocok.
+func (r *Repository) Search(ctx context.Context, f SearchFilter) ([]Match, error) {
+	args := []any{pq.Array(f.SkillIDs), f.MinLevel}
+	where := []string{
+		"u.status = 'ACTIVE'",
+		"es.skill_id = ANY($1)",
+		"es.level >= $2",
+	}
+	if f.Department != "" {
+		args = append(args, f.Department)
+		where = append(where, fmt.Sprintf("u.department = $%d", len(args)))
+	}
+	if f.Certified {
+		where = append(where, `EXISTS (
+			SELECT 1 FROM employee_certifications c
+			WHERE c.user_id = u.id AND c.skill_id = ANY($1)
+			  AND (c.expiry_date IS NULL OR c.expiry_date >= CURRENT_DATE)
+		)`)
+	}
+	having := ""
+	if f.MatchAll {
+		args = append(args, len(f.SkillIDs))
+		having = fmt.Sprintf(" HAVING COUNT(*) = $%d", len(args))
+	}
+	args = append(args, f.Limit)
+	query := `
+		SELECT u.id, u.employee_code, u.name, COALESCE(u.department, ''), COALESCE(u.job_title, ''),
+			COUNT(*), SUM(es.level)
+		FROM users u
+		JOIN employee_skills es ON es.user_id = u.id
+		WHERE ` + strings.Join(where, " AND ") + `
+		GROUP BY u.id` + having + `
+		ORDER BY COUNT(*) DESC, SUM(es.level) DESC, u.name
+		LIMIT $` + fmt.Sprint(len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Match{}
+	for rows.Next() {
+		var m Match
+		if err := rows.Scan(&m.UserID, &m.EmployeeCode, &m.Name, &m.Department, &m.JobTitle, &m.Matched, &m.Score); err != nil {
+			return nil, err
+		}
+		m.Skills = []EmployeeSkill{}
+		m.Certifications = []string{}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// MatchDetails returns the searched skills and valid certificates of the
+// given employees, per user id.
+func (r *Repository) MatchDetails(ctx context.Context, userIDs, skillIDs []int64) (map[int64][]EmployeeSkill, map[int64][]string, error) {
+	skills := map[int64][]EmployeeSkill{}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT es.user_id, s.id, s.name, COALESCE(s.category, ''), es.level, es.years_experience, es.updated_at
+		FROM employee_skills es
+		JOIN skills s ON s.id = es.skill_id
+		WHERE es.user_id = ANY($1) AND es.skill_id = ANY($2)
+		ORDER BY es.level DESC, s.name
+	`, pq.Array(userIDs), pq.Array(skillIDs))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var uid int64
+		var es EmployeeSkill
+		var years sql.NullFloat64
+		if err := rows.Scan(&uid, &es.SkillID, &es.SkillName, &es.Category, &es.Level, &years, &es.UpdatedAt); err != nil {
+			return nil, nil, err
+		}
+		if years.Valid {
+			es.Years = &years.Float64
+		}
+		es.LevelName = LevelNames[es.Level]
+		skills[uid] = append(skills[uid], es)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	certs := map[int64][]string{}
+	crows, err := r.db.QueryContext(ctx, `
+		SELECT user_id, name FROM employee_certifications
+		WHERE user_id = ANY($1) AND skill_id = ANY($2)
+		  AND (expiry_date IS NULL OR expiry_date >= CURRENT_DATE)
+		ORDER BY name
+	`, pq.Array(userIDs), pq.Array(skillIDs))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer crows.Close()
+	for crows.Next() {
+		var uid int64
+		var name string
+		if err := crows.Scan(&uid, &name); err != nil {
+			return nil, nil, err
+		}
+		certs[uid] = append(certs[uid], name)
+	}
+	return skills, certs, crows.Err()
+}
@@ -0,0 +1,454 @@
+package skill
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"hr-portal-backend/internal/mail"
+)
+
+var (
+	ErrNotFound      = errors.New("skill not found")
+	ErrUserNotFound  = errors.New("employee not found")
+	ErrCertNotFound  = errors.New("certification not found")
+	ErrDuplicateName = errors.New("skill with this name already exists")
+)
+
+// ValidationError is returned for invalid input; handler mengembalikan 400.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string { return e.Message }
+
+func invalid(msg string) error { return &ValidationError{Message: msg} }
+
+// ManagePermission lets HR manage every employee's skills and receive the
+// certificate expiry digest.
+const ManagePermission = "MANAGE_EMPLOYEES"
+
+// Batas hasil pencarian skill.
+const (
+	DefaultSearchLimit = 50
+	MaxSearchLimit     = 200
+)
+
+// Notifier queues an email inside the caller's transaction.
+type Notifier interface {
+	NotifyPermissionTx(ctx context.Context, tx *sql.Tx, n mail.Notification, permissions ...string) (int, error)
+}
+
+type Service struct {
+	repo      *Repository
+	notifier  Notifier
+	alertDays int // sertifikat EXPIRING kalau kedaluwarsa dalam alertDays hari
+}
+
+func NewService(repo *Repository, notifier Notifier, alertDays int) *Service {
+	return &Service{repo: repo, notifier: notifier, alertDays: alertDays}
+}
+
+// today returns the current date at midnight UTC, matching how DATE columns
+// are scanned.
+func today() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func parseDate(field, v string) (*time.Time, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return nil, invalid("invalid " + field + " (YYYY-MM-DD)")
+	}
+	return &t, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// withStatus fills Status from the expiry date.
+func (s *Service) withStatus(list []Certification) []Certification {
+	now := today()
+	soon := now.AddDate(0, 0, s.alertDays)
+	for i := range list {
+		c := &list[i]
+		switch {
+		case c.ExpiryDate == nil:
+			c.Status = CertValid
+		case c.ExpiryDate.Before(now):
+			c.Status = CertExpired
+		case !c.ExpiryDate.After(soon):
+			c.Status = CertExpiring
+		default:
+			c.Status = CertValid
+		}
+	}
+	return list
+}
+
+func (s *Service) checkUser(ctx context.Context, userID int64) error {
+	ok, err := s.repo.UserExists(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// ==========================
+// Catalog
+// ==========================
+
+func (s *Service) ListSkills(ctx context.Context, includeInactive bool) ([]Skill, error) {
+	return s.repo.ListSkills(ctx, includeInactive)
+}
+
+func buildSkill(in *SkillInput) (*Skill, error) {
+	sk := &Skill{
+		Name:        strings.TrimSpace(in.Name),
+		Category:    strings.TrimSpace(in.Category),
+		Description: strings.TrimSpace(in.Description),
+		IsActive:    in.IsActive == nil || *in.IsActive,
+	}
+	if sk.Name == "" {
+		return nil, invalid("name is required")
+	}
+	if len(sk.Name) > 100 {
+		return nil, invalid("name must be at most 100 characters")
+	}
+	if len(sk.Category) > 50 {
+		return nil, invalid("category must be at most 50 characters")
+	}
+	return sk, nil
+}
+
+func (s *Service) CreateSkill(ctx context.Context, in *SkillInput) (*Skill, error) {
+	sk, err := buildSkill(in)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateSkill(ctx, sk); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrDuplicateName
+		}
+		return nil, err
+	}
+	return s.repo.FindSkill(ctx, sk.ID)
+}
+
+func (s *Service) UpdateSkill(ctx context.Context, id int64, in *SkillInput) (*Skill, error) {
+	sk, err := buildSkill(in)
+	if err != nil {
+		return nil, err
+	}
+	sk.ID = id
+	if err := s.repo.UpdateSkill(ctx, sk, in.IsActive); err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			return nil, ErrNotFound
+		case isUniqueViolation(err):
+			return nil, ErrDuplicateName
+		}
+		return nil, err
+	}
+	return s.repo.FindSkill(ctx, id)
+}
+
+// DeactivateSkill hides a skill from the catalog. Skill karyawan yang sudah
+// ada tetap tersimpan supaya riwayat tidak hilang.
+func (s *Service) DeactivateSkill(ctx context.Context, id int64) error {
+	sk, err := s.repo.FindSkill(ctx, id)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	off := false
+	return s.repo.UpdateSkill(ctx, sk, &off)
+}
+
+// ==========================
+// Employee skills & certifications
+// ==========================
+
+// Profile returns the skills and certifications of an employee.
+func (s *Service) Profile(ctx context.Context, userID int64) (*Profile, error) {
+	if err := s.checkUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	skills, err := s.repo.ListEmployeeSkills(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := s.repo.ListCertifications(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &Profile{Skills: skills, Certifications: s.withStatus(certs)}, nil
+}
+
+// SetEmployeeSkill adds or updates one skill of an employee.
+func (s *Service) SetEmployeeSkill(ctx context.Context, userID, skillID int64, in *EmployeeSkillInput) (*Profile, error) {
+	if in.Level < MinLevel || in.Level > MaxLevel {
+		return nil, invalid("level must be between 1 and 5")
+	}
+	if in.Years != nil && (*in.Years < 0 || *in.Years > 60) {
+		return nil, invalid("years_experience must be between 0 and 60")
+	}
+	in.Notes = strings.TrimSpace(in.Notes)
+	if len(in.Notes) > 500 {
+		return nil, invalid("notes must be at most 500 characters")
+	}
+	if err := s.checkUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	sk, err := s.repo.FindSkill(ctx, skillID)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !sk.IsActive {
+		return nil, invalid("skill " + sk.Name + " is no longer in the catalog")
+	}
+	if err := s.repo.UpsertEmployeeSkill(ctx, userID, skillID, in); err != nil {
+		return nil, err
+	}
+	return s.Profile(ctx, userID)
+}
+
+func (s *Service) RemoveEmployeeSkill(ctx context.Context, userID, skillID int64) error {
+	ok, err := s.repo.DeleteEmployeeSkill(ctx, userID, skillID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// buildCert validates the input into c.
+func (s *Service) buildCert(ctx context.Context, c *Certification, in *CertificationInput) error {
+	c.Kind = strings.ToUpper(strings.TrimSpace(in.Kind))
+	if c.Kind == "" {
+		c.Kind = KindCertification
+	}
+	c.Name = strings.TrimSpace(in.Name)
+	c.Issuer = strings.TrimSpace(in.Issuer)
+	c.CredentialNumber = strings.TrimSpace(in.CredentialNumber)
+	switch c.Kind {
+	case KindCertification, KindTraining:
+	default:
+		return invalid("kind must be CERTIFICATION or TRAINING")
+	}
+	if c.Name == "" || len(c.Name) > 150 {
+		return invalid("name is required (max 150 characters)")
+	}
+	if c.Issuer == "" || len(c.Issuer) > 150 {
+		return invalid("issuer is required (max 150 characters)")
+	}
+	if len(c.CredentialNumber) > 100 {
+		return invalid("credential_number must be at most 100 characters")
+	}
+	var err error
+	if c.IssueDate, err = parseDate("issue_date", in.IssueDate); err != nil {
+		return err
+	}
+	if c.ExpiryDate, err = parseDate("expiry_date", in.ExpiryDate); err != nil {
+		return err
+	}
+	if c.IssueDate != nil && c.IssueDate.After(today()) {
+		return invalid("issue_date cannot be in the future")
+	}
+	if c.IssueDate != nil && c.ExpiryDate != nil && c.ExpiryDate.Before(*c.IssueDate) {
+		return invalid("expiry_date must be after issue_date")
+	}
+	c.SkillID = nil
+	if in.SkillID != nil && *in.SkillID > 0 {
+		if _, err := s.repo.FindSkill(ctx, *in.SkillID); err != nil {
+			if err == sql.ErrNoRows {
+				return invalid("skill_id does not exist")
+			}
+			return err
+		}
+		c.SkillID = in.SkillID
+	}
+	return nil
+}
+
+func (s *Service) CreateCertification(ctx context.Context, actorID, userID int64, in *CertificationInput) (*Certification, error) {
+	c := &Certification{UserID: userID}
+	if err := s.buildCert(ctx, c, in); err != nil {
+		return nil, err
+	}
+	if err := s.checkUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateCertification(ctx, c, actorID); err != nil {
+		return nil, err
+	}
+	return s.findCert(ctx, c.ID, 0)
+}
+
+// findCert loads a certificate; ownerID > 0 membatasi ke milik karyawan itu
+// (sertifikat orang lain diperlakukan seperti tidak ada).
+func (s *Service) findCert(ctx context.Context, id, ownerID int64) (*Certification, error) {
+	c, err := s.repo.FindCertification(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, ErrCertNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ownerID > 0 && c.UserID != ownerID {
+		return nil, ErrCertNotFound
+	}
+	return &s.withStatus([]Certification{*c})[0], nil
+}
+
+// UpdateCertification changes a certificate; ownerID 0 = HR (semua karyawan).
+func (s *Service) UpdateCertification(ctx context.Context, id, ownerID int64, in *CertificationInput) (*Certification, error) {
+	c, err := s.findCert(ctx, id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.buildCert(ctx, c, in); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateCertification(ctx, c); err != nil {
+		return nil, err
+	}
+	return s.findCert(ctx, id, 0)
+}
+
+// DeleteCertification removes a certificate; ownerID 0 = HR.
+func (s *Service) DeleteCertification(ctx context.Context, id, ownerID int64) error {
+	if _, err := s.findCert(ctx, id, ownerID); err != nil {
+		return err
+	}
+	return s.repo.DeleteCertification(ctx, id)
+}
+
+// ListExpiring lists certificates of active employees expiring within days.
+func (s *Service) ListExpiring(ctx context.Context, days int) ([]Certification, error) {
+	if days <= 0 {
+		days = s.alertDays
+	}
+	list, err := s.repo.ListExpiring(ctx, days)
+	if err != nil {
+		return nil, err
+	}
+	return s.withStatus(list), nil
+}
+
+// AlertExpiring emails HR one digest of certificates expiring within
+// alertDays that were not alerted yet for their current expiry date.
+// Dijalankan harian.
+func (s *Service) AlertExpiring(ctx context.Context) error {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	certs, err := repo.ClaimExpiringUnalerted(ctx, s.alertDays)
+	if err != nil || len(certs) == 0 {
+		return err
+	}
+	rows := make([]map[string]any, 0, len(certs))
+	for _, c := range s.withStatus(certs) {
+		rows = append(rows, map[string]any{
+			"EmployeeCode":     c.EmployeeCode,
+			"EmployeeName":     c.EmployeeName,
+			"Certificate":      c.Name,
+			"Issuer":           c.Issuer,
+			"CredentialNumber": c.CredentialNumber,
+			"ExpiryDate":       c.ExpiryDate.Format("2006-01-02"),
+			"Expired":          c.Status == CertExpired,
+		})
+	}
+	sent, err := s.notifier.NotifyPermissionTx(ctx, tx, mail.Notification{
+		Event: mail.EventCertificationsExpiring,
+		Data:  map[string]any{"Certifications": rows, "Days": s.alertDays},
+	}, ManagePermission)
+	if err != nil || sent == 0 {
+		return err // belum ada penerima: jangan tandai, dicoba lagi besok
+	}
+	for _, c := range certs {
+		if err := repo.MarkAlerted(ctx, c.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ==========================
+// Search
+// ==========================
+
+// Search finds employees by skill for staffing.
+func (s *Service) Search(ctx context.Context, f SearchFilter) ([]Match, error) {
+	seen := map[int64]bool{}
+	ids := f.SkillIDs[:0:0]
+	for _, id := range f.SkillIDs {
+		if id > 0 && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, invalid("at least one skill is required")
+	}
+	f.SkillIDs = ids
+	if f.MinLevel == 0 {
+		f.MinLevel = MinLevel
+	}
+	if f.MinLevel < MinLevel || f.MinLevel > MaxLevel {
+		return nil, invalid("min_level must be between 1 and 5")
+	}
+	f.Department = strings.ToUpper(strings.TrimSpace(f.Department))
+	if f.Limit <= 0 {
+		f.Limit = DefaultSearchLimit
+	}
+	f.Limit = min(f.Limit, MaxSearchLimit)
+
+	matches, err := s.repo.Search(ctx, f)
+	if err != nil || len(matches) == 0 {
+		return matches, err
+	}
+	userIDs := make([]int64, len(matches))
+	for i, m := range matches {
+		userIDs[i] = m.UserID
+	}
+	skills, certs, err := s.repo.MatchDetails(ctx, userIDs, f.SkillIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range matches {
+		m := &matches[i]
+		if v := skills[m.UserID]; v != nil {
+			m.Skills = v
+		}
+		if v := certs[m.UserID]; v != nil {
+			m.Certifications = v
+		}
+	}
+	return matches, nil
+}
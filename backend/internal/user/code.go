@@ -0,0 +1,177 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCodePattern keeps the historical format, e.g. IT001.
+const DefaultCodePattern = "{DEPT}{SEQ:3}"
+
+const maxEmployeeCodeLen = 20 // users.employee_code VARCHAR(20)
+
+var ErrCodePattern = errors.New("invalid employee code pattern")
+
+// CodePattern is a parsed EMPLOYEE_CODE_PATTERN. Token yang didukung:
+// {DEPT} (code_prefix department), {YYYY}, {YY}, {MM} dan tepat satu
+// {SEQ:n} (nomor urut, di-pad n digit; {SEQ} = 3 digit).
+type CodePattern struct {
+	raw    string
+	before []codeToken
+	after  []codeToken
+	width  int
+}
+
+type codeToken struct {
+	literal string
+	name    string // DEPT, YYYY, YY, MM; kosong untuk literal
+}
+
+var codeTokenRe = regexp.MustCompile(`\{([A-Z]+)(?::(\d+))?\}`)
+
+// ParseCodePattern validates and parses a pattern; empty means DefaultCodePattern.
+func ParseCodePattern(s string) (CodePattern, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		s = DefaultCodePattern
+	}
+	p := CodePattern{raw: s}
+	seenSeq := false
+	last := 0
+	add := func(t codeToken) {
+		if seenSeq {
+			p.after = append(p.after, t)
+		} else {
+			p.before = append(p.before, t)
+		}
+	}
+
+	for _, m := range codeTokenRe.FindAllStringSubmatchIndex(s, -1) {
+		if m[0] > last {
+			add(codeToken{literal: s[last:m[0]]})
+		}
+		last = m[1]
+		name := s[m[2]:m[3]]
+		switch name {
+		case "SEQ":
+			if seenSeq {
+				return CodePattern{}, fmt.Errorf("%w: {SEQ} must appear once", ErrCodePattern)
+			}
+			p.width = 3
+			if m[4] >= 0 {
+				w, _ := strconv.Atoi(s[m[4]:m[5]])
+				if w < 1 || w > 9 {
+					return CodePattern{}, fmt.Errorf("%w: SEQ width must be 1-9", ErrCodePattern)
+				}
+				p.width = w
+			}
+			seenSeq = true
+		case "DEPT", "YYYY", "YY", "MM":
+			if m[4] >= 0 {
+				return CodePattern{}, fmt.Errorf("%w: {%s} takes no width", ErrCodePattern, name)
+			}
+			add(codeToken{name: name})
+		default:
+			return CodePattern{}, fmt.Errorf("%w: unknown token {%s}", ErrCodePattern, name)
+		}
+	}
+	if last < len(s) {
+		add(codeToken{literal: s[last:]})
+	}
+	if !seenSeq {
+		return CodePattern{}, fmt.Errorf("%w: {SEQ} is required", ErrCodePattern)
+	}
+	for _, t := range append(append([]codeToken{}, p.before...), p.after...) {
+		if strings.ContainsAny(t.literal, "{}") {
+			return CodePattern{}, fmt.Errorf("%w: unbalanced braces", ErrCodePattern)
+		}
+	}
+	return p, nil
+}
+
+func (p CodePattern) String() string { return p.raw }
+
+func renderTokens(tokens []codeToken, prefix string, now time.Time) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		switch t.name {
+		case "":
+			b.WriteString(t.literal)
+		case "DEPT":
+			b.WriteString(prefix)
+		case "YYYY":
+			b.WriteString(now.Format("2006"))
+		case "YY":
+			b.WriteString(now.Format("06"))
+		case "MM":
+			b.WriteString(now.Format("01"))
+		}
+	}
+	return b.String()
+}
+
+// codeScope is one sequence: everything around {SEQ} once rendered. Pattern
+// dengan {YY} otomatis mulai dari 1 lagi tiap tahun karena scope-nya berubah.
+type codeScope struct {
+	before, after string
+	width         int
+}
+
+func (p CodePattern) scope(prefix string, now time.Time) codeScope {
+	return codeScope{
+		before: renderTokens(p.before, prefix, now),
+		after:  renderTokens(p.after, prefix, now),
+		width:  p.width,
+	}
+}
+
+// key identifies the row in employee_code_sequences.
+func (c codeScope) key() string { return c.before + "{SEQ}" + c.after }
+
+// regex matches existing codes of this scope, capturing the number. Dipakai
+// untuk melanjutkan nomor dari kode lama saat sequence pertama kali dibuat.
+func (c codeScope) regex() string {
+	return "^" + regexp.QuoteMeta(c.before) + "([0-9]+)" + regexp.QuoteMeta(c.after) + "$"
+}
+
+func (c codeScope) format(n int64) string {
+	return c.before + fmt.Sprintf("%0*d", c.width, n) + c.after
+}
+
+// generateCode takes the next number of the department's sequence. Must run
+// inside the transaction that inserts the employee: the sequence row stays
+// locked until commit, so concurrent creators get distinct codes and a
+// rollback gives the number back. reserved berisi kode yang akan dipakai
+// baris lain di transaksi yang sama (import) dan dilewati.
+func (s *Service) generateCode(ctx context.Context, repo *Repository, department string, reserved map[string]bool) (string, error) {
+	prefix, err := s.codePrefix(ctx, department)
+	if err != nil {
+		return "", err
+	}
+	scope := s.codes.scope(prefix, time.Now())
+
+	// Lewati nomor yang sudah terpakai oleh kode yang diisi manual.
+	for i := 0; i < 1000; i++ {
+		n, err := repo.NextCodeSequence(ctx, scope.key(), scope.regex())
+		if err != nil {
+			return "", err
+		}
+		code := scope.format(n)
+		if len(code) > maxEmployeeCodeLen {
+			return "", fmt.Errorf("generated employee code %q is longer than %d characters", code, maxEmployeeCodeLen)
+		}
+		taken, err := repo.ExistingCodes(ctx, []string{code})
+		if err != nil {
+			return "", err
+		}
+		if !taken[code] && !reserved[code] {
+			return code, nil
+		}
+	}
+	return "", errors.New("no free employee code found")
+}
@@ -0,0 +1,363 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tipe custom field.
+const (
+	FieldText    = "TEXT"
+	FieldNumber  = "NUMBER"
+	FieldDate    = "DATE"
+	FieldBoolean = "BOOLEAN"
+	FieldSelect  = "SELECT"
+)
+
+const maxCustomTextLength = 500
+
+var customFieldKey = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// CustomField adalah row di tabel "custom_field_definitions": atribut
+// tambahan karyawan yang didefinisikan admin, nilainya di users.custom_fields.
+type CustomField struct {
+	Key        string   `json:"key"`
+	Label      string   `json:"label"`
+	FieldType  string   `json:"field_type"`
+	Options    []string `json:"options,omitempty"` // pilihan untuk SELECT
+	Pattern    string   `json:"pattern,omitempty"` // regex untuk TEXT
+	MinValue   *float64 `json:"min_value,omitempty"`
+	MaxValue   *float64 `json:"max_value,omitempty"`
+	Required   bool     `json:"required"`
+	Department string   `json:"department,omitempty"` // kosong = semua department
+	// VisibleRoles membatasi siapa yang bisa melihat nilainya; kosong = semua.
+	VisibleRoles []string `json:"visible_roles"`
+	SortOrder    int      `json:"sort_order"`
+	IsActive     bool     `json:"is_active"`
+}
+
+func (f *CustomField) visibleTo(roles []string) bool {
+	if len(f.VisibleRoles) == 0 {
+		return true
+	}
+	for _, r := range roles {
+		if slices.Contains(f.VisibleRoles, r) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *CustomField) appliesTo(department string) bool {
+	return f.Department == "" || f.Department == department
+}
+
+func (f *CustomField) sanitize() error {
+	f.Key = strings.ToLower(strings.TrimSpace(f.Key))
+	f.Label = strings.TrimSpace(f.Label)
+	f.FieldType = strings.ToUpper(strings.TrimSpace(f.FieldType))
+	f.Pattern = strings.TrimSpace(f.Pattern)
+	f.Department = strings.ToUpper(strings.TrimSpace(f.Department))
+	if !customFieldKey.MatchString(f.Key) {
+		return &ValidationError{Field: "key", Message: "key must be lowercase letters, digits or underscore (max 50)"}
+	}
+	if f.Label == "" {
+		return &ValidationError{Field: "label", Message: "label is required"}
+	}
+	switch f.FieldType {
+	case FieldText, FieldNumber, FieldDate, FieldBoolean:
+	case FieldSelect:
+		opts := f.Options[:0]
+		for _, o := range f.Options {
+			if o = strings.TrimSpace(o); o != "" && !slices.Contains(opts, o) {
+				opts = append(opts, o)
+			}
+		}
+		f.Options = opts
+		if len(f.Options) == 0 {
+			return &ValidationError{Field: "options", Message: "SELECT fields need at least one option"}
+		}
+	default:
+		return &ValidationError{Field: "field_type", Message: "field_type must be TEXT, NUMBER, DATE, BOOLEAN or SELECT"}
+	}
+	if f.FieldType != FieldSelect {
+		f.Options = nil
+	}
+	if f.Pattern != "" {
+		if f.FieldType != FieldText {
+			return &ValidationError{Field: "pattern", Message: "pattern only applies to TEXT fields"}
+		}
+		if _, err := regexp.Compile(f.Pattern); err != nil {
+			return &ValidationError{Field: "pattern", Message: "invalid pattern"}
+		}
+	}
+	if f.FieldType != FieldNumber {
+		f.MinValue, f.MaxValue = nil, nil
+	} else if f.MinValue != nil && f.MaxValue != nil && *f.MinValue > *f.MaxValue {
+		return &ValidationError{Field: "min_value", Message: "min_value must not exceed max_value"}
+	}
+	for i := range f.VisibleRoles {
+		f.VisibleRoles[i] = strings.ToUpper(strings.TrimSpace(f.VisibleRoles[i]))
+	}
+	if f.VisibleRoles == nil {
+		f.VisibleRoles = []string{}
+	}
+	return nil
+}
+
+// normalize checks one submitted value against the definition and returns it
+// in its stored JSON form. nil berarti kosong.
+func (f *CustomField) normalize(v any) (any, error) {
+	invalid := func(msg string) error {
+		return &ValidationError{Field: "custom_fields." + f.Key, Message: fmt.Sprintf("%s %s", f.Label, msg)}
+	}
+	if s, ok := v.(string); ok {
+		v = strings.TrimSpace(s)
+		if v == "" {
+			return nil, nil
+		}
+	}
+	if v == nil {
+		return nil, nil
+	}
+
+	switch f.FieldType {
+	case FieldText:
+		s, ok := v.(string)
+		if !ok {
+			return nil, invalid("must be text")
+		}
+		if len(s) > maxCustomTextLength {
+			return nil, invalid(fmt.Sprintf("must be at most %d characters", maxCustomTextLength))
+		}
+		if f.Pattern != "" && !regexp.MustCompile(f.Pattern).MatchString(s) {
+			return nil, invalid("has an invalid format")
+		}
+		return s, nil
+	case FieldNumber:
+		var n float64
+		switch x := v.(type) {
+		case float64:
+			n = x
+		case string:
+			var err error
+			if n, err = strconv.ParseFloat(x, 64); err != nil {
+				return nil, invalid("must be a number")
+			}
+		default:
+			return nil, invalid("must be a number")
+		}
+		if f.MinValue != nil && n < *f.MinValue {
+			return nil, invalid(fmt.Sprintf("must be at least %v", *f.MinValue))
+		}
+		if f.MaxValue != nil && n > *f.MaxValue {
+			return nil, invalid(fmt.Sprintf("must be at most %v", *f.MaxValue))
+		}
+		return n, nil
+	case FieldDate:
+		s, ok := v.(string)
+		if !ok {
+			return nil, invalid("must be a date (YYYY-MM-DD)")
+		}
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			return nil, invalid("must be a date (YYYY-MM-DD)")
+		}
+		return s, nil
+	case FieldBoolean:
+		switch x := v.(type) {
+		case bool:
+			return x, nil
+		case string:
+			b, err := strconv.ParseBool(x)
+			if err != nil {
+				return nil, invalid("must be true or false")
+			}
+			return b, nil
+		}
+		return nil, invalid("must be true or false")
+	case FieldSelect:
+		s, ok := v.(string)
+		if !ok || !slices.Contains(f.Options, s) {
+			return nil, invalid("must be one of " + strings.Join(f.Options, ", "))
+		}
+		return s, nil
+	}
+	return nil, invalid("has an unknown type")
+}
+
+// ==========================
+// Definitions
+// ==========================
+
+// ListCustomFields returns the definitions the roles may see. includeAll
+// (untuk admin) juga mengembalikan field nonaktif dan yang tersembunyi.
+func (s *Service) ListCustomFields(ctx context.Context, roles []string, includeAll bool) ([]CustomField, error) {
+	defs, err := s.repo.ListCustomFields(ctx, includeAll)
+	if err != nil {
+		return nil, err
+	}
+	if includeAll {
+		return defs, nil
+	}
+	out := []CustomField{}
+	for _, d := range defs {
+		if d.visibleTo(roles) {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+// SaveCustomField creates or updates a definition; active nil = aktif untuk
+// field baru, tidak berubah untuk yang sudah ada. Key dan tipe tidak bisa
+// diubah setelah dibuat supaya nilai yang tersimpan tetap valid.
+func (s *Service) SaveCustomField(ctx context.Context, f CustomField, active *bool) (*CustomField, error) {
+	if err := f.sanitize(); err != nil {
+		return nil, err
+	}
+	if f.Department != "" {
+		if _, ok, err := s.catalog.DepartmentPrefix(ctx, f.Department); err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, &ValidationError{Field: "department", Message: fmt.Sprintf("unknown department %q", f.Department)}
+		}
+	}
+	existing, err := s.repo.FindCustomField(ctx, f.Key)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.FieldType != f.FieldType {
+		return nil, &ValidationError{Field: "field_type", Message: "field_type cannot be changed"}
+	}
+	if err := s.repo.SaveCustomField(ctx, &f, active); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// DeactivateCustomField hides a field; nilai lama tetap tersimpan.
+func (s *Service) DeactivateCustomField(ctx context.Context, key string) error {
+	ok, err := s.repo.DeactivateCustomField(ctx, strings.ToLower(strings.TrimSpace(key)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ==========================
+// Values
+// ==========================
+
+// mergeCustomFields validates the submitted values for an employee of
+// department and merges them into current. Key yang tidak dikirim tidak
+// berubah; nilai null/kosong menghapus.
+func (s *Service) mergeCustomFields(ctx context.Context, department string, current, submitted map[string]any) (map[string]any, error) {
+	defs, err := s.repo.ListCustomFields(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	return mergeCustomValues(defs, department, current, submitted)
+}
+
+// mergeCustomValues is mergeCustomFields with the active definitions already
+// loaded (import memakai satu query untuk semua baris).
+func mergeCustomValues(defs []CustomField, department string, current, submitted map[string]any) (map[string]any, error) {
+	byKey := make(map[string]*CustomField, len(defs))
+	for i := range defs {
+		byKey[defs[i].Key] = &defs[i]
+	}
+
+	out := make(map[string]any, len(current)+len(submitted))
+	for k, v := range current {
+		out[k] = v
+	}
+	for k, v := range submitted {
+		def, ok := byKey[k]
+		if !ok {
+			return nil, &ValidationError{Field: "custom_fields." + k, Message: fmt.Sprintf("unknown custom field %q", k)}
+		}
+		nv, err := def.normalize(v)
+		if err != nil {
+			return nil, err
+		}
+		if nv == nil {
+			delete(out, k)
+		} else {
+			out[k] = nv
+		}
+	}
+	for _, def := range defs {
+		if def.Required && def.appliesTo(department) && out[def.Key] == nil {
+			return nil, &ValidationError{Field: "custom_fields." + def.Key, Message: def.Label + " is required"}
+		}
+	}
+	return out, nil
+}
+
+// ShowCustomFields fills CustomFields of each user with the values of the
+// active fields the roles may see. Tanpa ini nilai custom field tidak pernah
+// ikut ke JSON.
+func (s *Service) ShowCustomFields(ctx context.Context, roles []string, users ...*User) error {
+	defs, err := s.ListCustomFields(ctx, roles, false)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		u.CustomFields = map[string]any{}
+		for _, d := range defs {
+			if v, ok := u.customFields[d.Key]; ok {
+				u.CustomFields[d.Key] = v
+			}
+		}
+	}
+	return nil
+}
+
+// VisibleCustomFields returns the custom field values an employee sees on
+// their own profile (GET /api/me); dipakai export data pribadi.
+func (s *Service) VisibleCustomFields(ctx context.Context, userID int64) (map[string]any, error) {
+	u, err := s.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ShowCustomFields(ctx, u.Roles, u); err != nil {
+		return nil, err
+	}
+	return u.CustomFields, nil
+}
+
+// customFilter converts the cf.<key> filters into typed values for a JSONB
+// containment match. Field yang tidak boleh dilihat caller ditolak supaya
+// nilai tersembunyi tidak bisa ditebak lewat filter.
+func (s *Service) customFilter(ctx context.Context, roles []string, filters map[string]string) (map[string]any, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	defs, err := s.ListCustomFields(ctx, roles, false)
+	if err != nil {
+		return nil, err
+	}
+	match := map[string]any{}
+	for k, raw := range filters {
+		i := slices.IndexFunc(defs, func(d CustomField) bool { return d.Key == k })
+		if i < 0 {
+			return nil, &ValidationError{Field: "cf." + k, Message: fmt.Sprintf("unknown custom field %q", k)}
+		}
+		v, err := defs[i].normalize(raw)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			match[k] = v
+		}
+	}
+	return match, nil
+}
@@ -0,0 +1,190 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ExportColumn is one selectable column of the employee export.
+type ExportColumn struct {
+	Key       string
+	Header    string
+	Width     float64
+	Sensitive bool // hanya untuk caller dengan MANAGE_EMPLOYEES
+	value     func(u *User) any
+}
+
+func dateValue(t *time.Time) any {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+// exportColumns lists the columns in their default order.
+var exportColumns = []ExportColumn{
+	{Key: "employee_code", Header: "Employee Code", Width: 14, value: func(u *User) any { return u.EmployeeCode }},
+	{Key: "name", Header: "Name", Width: 26, value: func(u *User) any { return u.Name }},
+	{Key: "email", Header: "Email", Width: 30, value: func(u *User) any { return u.Email }},
+	{Key: "department", Header: "Department", Width: 12, value: func(u *User) any { return u.Department }},
+	{Key: "branch", Header: "Branch", Width: 18, value: func(u *User) any { return u.Branch }},
+	{Key: "job_title", Header: "Job Title", Width: 22, value: func(u *User) any { return u.JobTitle }},
+	{Key: "status", Header: "Status", Width: 12, value: func(u *User) any { return u.Status }},
+	{Key: "join_date", Header: "Join Date", Width: 12, value: func(u *User) any { return dateValue(u.JoinDate) }},
+	{Key: "roles", Header: "Roles", Width: 20, value: func(u *User) any { return strings.Join(u.Roles, ", ") }},
+	{Key: "gender", Header: "Gender", Width: 10, Sensitive: true, value: func(u *User) any { return u.Gender }},
+	{Key: "birth_date", Header: "Birth Date", Width: 12, Sensitive: true, value: func(u *User) any { return dateValue(u.BirthDate) }},
+	{Key: "phone", Header: "Phone", Width: 16, Sensitive: true, value: func(u *User) any { return u.Phone }},
+	{Key: "address", Header: "Address", Width: 36, Sensitive: true, value: func(u *User) any { return u.Address }},
+	{Key: "emergency_contact", Header: "Emergency Contact", Width: 22, Sensitive: true, value: func(u *User) any { return u.EmergencyContact }},
+	{Key: "emergency_phone", Header: "Emergency Phone", Width: 16, Sensitive: true, value: func(u *User) any { return u.EmergencyPhone }},
+}
+
+// SelectExportColumns resolves the requested column keys. An empty request
+// means every column the caller may see. ok is false when a sensitive column
+// is requested without permission.
+func SelectExportColumns(keys []string, allowSensitive bool) (cols []ExportColumn, ok bool, err error) {
+	if len(keys) == 0 {
+		for _, c := range exportColumns {
+			if !c.Sensitive || allowSensitive {
+				cols = append(cols, c)
+			}
+		}
+		return cols, true, nil
+	}
+
+	for _, k := range keys {
+		k = strings.ToLower(strings.TrimSpace(k))
+		var found *ExportColumn
+		for i := range exportColumns {
+			if exportColumns[i].Key == k {
+				found = &exportColumns[i]
+				break
+			}
+		}
+		if found == nil {
+			return nil, true, fmt.Errorf("unknown column %q", k)
+		}
+		if found.Sensitive && !allowSensitive {
+			return nil, false, nil
+		}
+		cols = append(cols, *found)
+	}
+	return cols, true, nil
+}
+
+// ExportEmployees returns every employee matching f (ignoring paging),
+// walking the list with the keyset cursor.
+func (s *Service) ExportEmployees(ctx context.Context, f ListFilter) ([]User, error) {
+	f.Page = 1
+	f.PageSize = maxPageSize
+	f.After = nil
+
+	var all []User
+	for {
+		page, err := s.ListEmployees(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page.Items...)
+		if page.NextCursor == "" {
+			return all, nil
+		}
+		if f.After, err = DecodeCursor(page.NextCursor); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// WriteEmployeesCSV renders users as CSV with the given columns.
+func WriteEmployeesCSV(users []User, cols []ExportColumn) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.Header
+	}
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	for i := range users {
+		rec := make([]string, len(cols))
+		for j, c := range cols {
+			rec[j] = fmt.Sprint(c.value(&users[i]))
+		}
+		if err := w.Write(rec); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// WriteEmployeesXLSX renders users as a bordered, print-ready sheet,
+// same style as the processed requests report.
+func WriteEmployeesXLSX(users []User, cols []ExportColumn) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := "Employees"
+	f.NewSheet(sheet)
+	f.DeleteSheet("Sheet1")
+
+	border := []excelize.Border{{Type: "left", Color: "000000", Style: 1}, {Type: "right", Color: "000000", Style: 1}, {Type: "top", Color: "000000", Style: 1}, {Type: "bottom", Color: "000000", Style: 1}}
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Size: 11},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#E5E7EB"}, Pattern: 1},
+		Border:    border,
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+	})
+	cellStyle, _ := f.NewStyle(&excelize.Style{
+		Border:    border,
+		Alignment: &excelize.Alignment{Vertical: "center"},
+	})
+
+	for i, c := range cols {
+		colName, err := excelize.ColumnNumberToName(i + 1)
+		if err != nil {
+			return nil, err
+		}
+		cell := colName + "1"
+		f.SetCellValue(sheet, cell, c.Header)
+		f.SetCellStyle(sheet, cell, cell, headerStyle)
+		f.SetColWidth(sheet, colName, colName, c.Width)
+	}
+
+	for r := range users {
+		for i, c := range cols {
+			cell, err := excelize.CoordinatesToCellName(i+1, r+2)
+			if err != nil {
+				return nil, err
+			}
+			f.SetCellValue(sheet, cell, c.value(&users[r]))
+		}
+	}
+	if len(users) > 0 {
+		last, _ := excelize.CoordinatesToCellName(len(cols), len(users)+1)
+		f.SetCellStyle(sheet, "A2", last, cellStyle)
+	}
+
+	// Freeze header row
+	f.SetPanes(sheet, &excelize.Panes{
+		Freeze:      true,
+		Split:       true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	})
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
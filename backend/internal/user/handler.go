@@ -1,32 +1,195 @@
 package user
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// PermissionChecker is satisfied by *rbac.Repository.
+type PermissionChecker interface {
+	HasAnyPermission(ctx context.Context, roles []string, codes ...string) (bool, error)
+}
+
 type Handler struct {
-	svc *Service
+	svc   *Service
+	perms PermissionChecker
+}
+
+func NewHandler(svc *Service, perms PermissionChecker) *Handler {
+	return &Handler{svc: svc, perms: perms}
+}
+
+// hasPermission checks the caller's roles for one of codes.
+func (h *Handler) hasPermission(c *fiber.Ctx, codes ...string) (bool, error) {
+	roles, _ := c.Locals("roles").([]string)
+	return h.perms.HasAnyPermission(c.Context(), roles, codes...)
 }
 
-func NewHandler(svc *Service) *Handler {
-	return &Handler{svc: svc}
+// showCustomFields adds the custom field values the caller may see.
+func (h *Handler) showCustomFields(c *fiber.Ctx, u *User) error {
+	roles, _ := c.Locals("roles").([]string)
+	if err := h.svc.ShowCustomFields(c.Context(), roles, u); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load custom fields")
+	}
+	return nil
+}
+
+// hideBirthDates removes birth_date of employees who opted out unless the
+// caller can manage employees.
+func (h *Handler) hideBirthDates(c *fiber.Ctx, users []User) error {
+	allowed, err := h.hasPermission(c, "MANAGE_EMPLOYEES")
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to check permission")
+	}
+	if allowed {
+		return nil
+	}
+	userID, _ := c.Locals("userID").(int64)
+	if err := h.svc.HideBirthDates(c.Context(), userID, users); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load celebration preferences")
+	}
+	return nil
 }
 
-// GET /api/employees
+// parseListFilter reads the list query parameters shared by
+// GET /api/employees and related endpoints.
+func parseListFilter(c *fiber.Ctx) (ListFilter, error) {
+	f := ListFilter{
+		Search:     c.Query("search"),
+		Department: c.Query("department"),
+		Branch:     c.Query("branch"),
+		Status:     c.Query("status"),
+		Role:       c.Query("role"),
+		Sort:       c.Query("sort"),
+		Desc:       strings.EqualFold(c.Query("dir"), "desc"),
+		Page:       c.QueryInt("page"),
+		PageSize:   c.QueryInt("page_size"),
+	}
+	if v := c.Query("joined_from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return f, fiber.NewError(fiber.StatusBadRequest, "invalid joined_from date (YYYY-MM-DD)")
+		}
+		f.JoinedFrom = &t
+	}
+	if v := c.Query("joined_to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return f, fiber.NewError(fiber.StatusBadRequest, "invalid joined_to date (YYYY-MM-DD)")
+		}
+		f.JoinedTo = &t
+	}
+	// Filter custom field: ?cf.shirt_size=L&cf.has_vehicle=true
+	c.Context().QueryArgs().VisitAll(func(k, v []byte) {
+		if key, ok := strings.CutPrefix(string(k), "cf."); ok && key != "" {
+			if f.CustomFields == nil {
+				f.CustomFields = map[string]string{}
+			}
+			f.CustomFields[strings.ToLower(key)] = string(v)
+		}
+	})
+	f.ViewerRoles, _ = c.Locals("roles").([]string)
+	if v := c.Query("cursor"); v != "" {
+		cur, err := DecodeCursor(v)
+		if err != nil {
+			return f, fiber.NewError(fiber.StatusBadRequest, "invalid cursor")
+		}
+		f.After = cur
+	}
+	return f, nil
+}
+
+// GET /api/employees?search=&department=&branch=&status=&role=&joined_from=&joined_to=&cf.<key>=
+//
+//	&sort=name&dir=asc&page=1&page_size=50 (atau &cursor=... dari next_cursor)
 func (h *Handler) ListEmployees(c *fiber.Ctx) error {
-	search := c.Query("search", "")
-	ctx := c.Context()
+	f, err := parseListFilter(c)
+	if err != nil {
+		return err
+	}
 
-	list, err := h.svc.ListEmployees(ctx, search)
+	page, err := h.svc.ListEmployees(c.Context(), f)
 	if err != nil {
+		var vErr *ValidationError
+		if errors.As(err, &vErr) {
+			return fiber.NewError(fiber.StatusBadRequest, vErr.Message)
+		}
 		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch employees")
 	}
-	return c.JSON(list)
+	if err := h.hideBirthDates(c, page.Items); err != nil {
+		return err
+	}
+	return c.JSON(page)
+}
+
+// GET /api/employees/export?format=xlsx|csv&columns=employee_code,name,...
+// Menerima filter yang sama dengan GET /api/employees (paging diabaikan).
+func (h *Handler) ExportEmployees(c *fiber.Ctx) error {
+	f, err := parseListFilter(c)
+	if err != nil {
+		return err
+	}
+	format := strings.ToLower(c.Query("format", "xlsx"))
+	if format != "xlsx" && format != "csv" {
+		return fiber.NewError(fiber.StatusBadRequest, "format must be xlsx or csv")
+	}
+
+	canSeeSensitive, err := h.hasPermission(c, "MANAGE_EMPLOYEES")
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to check permission")
+	}
+	var keys []string
+	if v := strings.TrimSpace(c.Query("columns")); v != "" {
+		keys = strings.Split(v, ",")
+	}
+	cols, allowed, err := SelectExportColumns(keys, canSeeSensitive)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if !allowed {
+		return fiber.NewError(fiber.StatusForbidden, "sensitive columns require MANAGE_EMPLOYEES")
+	}
+
+	users, err := h.svc.ExportEmployees(c.Context(), f)
+	if err != nil {
+		var vErr *ValidationError
+		if errors.As(err, &vErr) {
+			return fiber.NewError(fiber.StatusBadRequest, vErr.Message)
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch employees")
+	}
+
+	var (
+		data        []byte
+		contentType string
+	)
+	if format == "csv" {
+		data, err = WriteEmployeesCSV(users, cols)
+		contentType = "text/csv; charset=utf-8"
+	} else {
+		data, err = WriteEmployeesXLSX(users, cols)
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to generate export file")
+	}
+
+	filename := fmt.Sprintf("employees_%s.%s", time.Now().Format("20060102"), format)
+	c.Set("Content-Type", contentType)
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	return c.Send(data)
 }
 
 // GET /api/employees/next-code?department=IT
+// Hanya preview; kosongkan employee_code saat POST /api/employees agar kode
+// dibuat server secara atomik.
 func (h *Handler) GetNextEmployeeCode(c *fiber.Ctx) error {
 	department := c.Query("department", "")
 	if department == "" {
@@ -35,29 +198,85 @@ func (h *Handler) GetNextEmployeeCode(c *fiber.Ctx) error {
 
 	code, err := h.svc.GetNextEmployeeCode(c.Context(), department)
 	if err != nil {
+		var vErr *ValidationError
+		if errors.As(err, &vErr) {
+			return fiber.NewError(fiber.StatusBadRequest, vErr.Message)
+		}
 		return fiber.NewError(fiber.StatusInternalServerError, "failed to generate employee code")
 	}
 
 	return c.JSON(fiber.Map{
 		"employee_code": code,
 		"department":    department,
+		"preview":       true,
 	})
 }
 
 // POST /api/employees
 func (h *Handler) CreateEmployee(c *fiber.Ctx) error {
+	actorID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
 	var in EmployeeInput
 	if err := c.BodyParser(&in); err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 	}
 
-	emp, err := h.svc.CreateEmployee(c.Context(), in)
+	emp, err := h.svc.CreateEmployee(c.Context(), actorID, in)
 	if err != nil {
+		var vErr *ValidationError
+		if errors.As(err, &vErr) {
+			return fiber.NewError(fiber.StatusBadRequest, vErr.Message)
+		}
 		return fiber.NewError(fiber.StatusInternalServerError, "failed to create employee")
 	}
+	if err := h.showCustomFields(c, emp); err != nil {
+		return err
+	}
 	return c.Status(fiber.StatusCreated).JSON(emp)
 }
 
+// POST /api/employees/import?mode=dry-run|commit (multipart: file .xlsx/.csv)
+// Default mode dry-run: hanya validasi dan kembalikan laporan per baris.
+// Kolom custom field memakai header "cf.<key>".
+func (h *Handler) ImportEmployees(c *fiber.Ctx) error {
+	actorID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	mode := strings.ToLower(c.Query("mode", "dry-run"))
+	if mode != "dry-run" && mode != "commit" {
+		return fiber.NewError(fiber.StatusBadRequest, "mode must be dry-run or commit")
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "file is required")
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "failed to read file")
+	}
+	defer f.Close()
+
+	report, err := h.svc.ImportEmployees(c.Context(), actorID, f, fh.Filename, mode == "commit")
+	if err != nil {
+		if errors.Is(err, ErrImportFormat) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to import employees")
+	}
+
+	if mode == "commit" && !report.Committed {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(report)
+	}
+	if report.Committed {
+		return c.Status(fiber.StatusCreated).JSON(report)
+	}
+	return c.JSON(report)
+}
+
 // PUT /api/employees/:id
 func (h *Handler) UpdateEmployee(c *fiber.Ctx) error {
 	idStr := c.Params("id")
@@ -66,18 +285,32 @@ func (h *Handler) UpdateEmployee(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
 	}
 
+	actorID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
 	var in EmployeeInput
 	if err := c.BodyParser(&in); err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 	}
 
-	emp, err := h.svc.UpdateEmployee(c.Context(), id, in)
+	emp, err := h.svc.UpdateEmployee(c.Context(), actorID, id, in)
 	if err != nil {
+		var vErr *ValidationError
+		if errors.As(err, &vErr) {
+			return fiber.NewError(fiber.StatusBadRequest, vErr.Message)
+		}
 		if err == ErrNotFound {
 			return fiber.NewError(fiber.StatusNotFound, "employee not found")
 		}
+		if errors.Is(err, ErrInvalidTransition) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
 		return fiber.NewError(fiber.StatusInternalServerError, "failed to update employee")
 	}
+	if err := h.showCustomFields(c, emp); err != nil {
+		return err
+	}
 	return c.JSON(emp)
 }
 
@@ -89,35 +322,11 @@ func (h *Handler) DeleteEmployee(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
 	}
 
-	if err := h.svc.DeleteEmployee(c.Context(), id); err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "failed to delete employee")
-	}
-	return c.SendStatus(fiber.StatusNoContent)
-}
-
-// DELETE /api/employees/by-code/:code
-func (h *Handler) DeleteEmployeeByCode(c *fiber.Ctx) error {
-	code := c.Params("code")
-	if code == "" {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid employee code")
-	}
-	if err := h.svc.DeleteEmployeeByCode(c.Context(), code); err != nil {
-		if err == ErrNotFound {
-			return fiber.NewError(fiber.StatusNotFound, "employee not found")
-		}
-		return fiber.NewError(fiber.StatusInternalServerError, "failed to delete employee")
-	}
-	return c.SendStatus(fiber.StatusNoContent)
-}
-
-// DELETE /api/employees/:id/hard - permanent delete
-func (h *Handler) HardDeleteEmployee(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil || id <= 0 {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	actorID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
 	}
-	if err := h.svc.HardDeleteEmployee(c.Context(), id); err != nil {
+	if err := h.svc.DeleteEmployee(c.Context(), actorID, id); err != nil {
 		if err == ErrNotFound {
 			return fiber.NewError(fiber.StatusNotFound, "employee not found")
 		}
@@ -126,13 +335,17 @@ func (h *Handler) HardDeleteEmployee(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
-// DELETE /api/employees/by-code/:code/hard - permanent delete by code
-func (h *Handler) HardDeleteEmployeeByCode(c *fiber.Ctx) error {
+// DELETE /api/employees/by-code/:code
+func (h *Handler) DeleteEmployeeByCode(c *fiber.Ctx) error {
 	code := c.Params("code")
 	if code == "" {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid employee code")
 	}
-	if err := h.svc.HardDeleteEmployeeByCode(c.Context(), code); err != nil {
+	actorID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	if err := h.svc.DeleteEmployeeByCode(c.Context(), actorID, code); err != nil {
 		if err == ErrNotFound {
 			return fiber.NewError(fiber.StatusNotFound, "employee not found")
 		}
@@ -153,11 +366,16 @@ func (h *Handler) GetMyProfile(c *fiber.Ctx) error {
 	if err != nil {
 		return fiber.NewError(fiber.StatusNotFound, "user not found")
 	}
+	if err := h.showCustomFields(c, user); err != nil {
+		return err
+	}
 
 	return c.JSON(user)
 }
 
 // PUT /api/me - Update current user's profile
+// Field dengan policy APPROVAL tidak langsung tersimpan: response 202 berisi
+// profil terbaru dan pending_change untuk direview HRD.
 func (h *Handler) UpdateMyProfile(c *fiber.Ctx) error {
 	userID := c.Locals("userID")
 	id, ok := userID.(int64)
@@ -170,10 +388,483 @@ func (h *Handler) UpdateMyProfile(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
 	}
 
-	user, err := h.svc.UpdateProfile(c.Context(), id, &input)
+	user, pending, err := h.svc.UpdateProfile(c.Context(), id, &input)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		var vErr *ValidationError
+		if errors.As(err, &vErr) {
+			return fiber.NewError(fiber.StatusBadRequest, vErr.Message)
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to update profile")
+	}
+	if err := h.showCustomFields(c, user); err != nil {
+		return err
 	}
 
+	if pending != nil {
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"profile":        user,
+			"pending_change": pending,
+		})
+	}
 	return c.JSON(user)
 }
+
+// ==========================
+// Reporting lines & org chart
+// ==========================
+
+// PUT /api/employees/:id/manager  body: {"manager_id": 12} atau {"manager_id": null}
+func (h *Handler) SetManager(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	var body struct {
+		ManagerID *int64 `json:"manager_id"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+
+	u, err := h.svc.SetManager(c.Context(), id, body.ManagerID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return fiber.NewError(fiber.StatusNotFound, "employee not found")
+		case errors.Is(err, ErrInvalidManager):
+			return fiber.NewError(fiber.StatusBadRequest, "manager does not exist or would create a reporting cycle")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to update manager")
+	}
+	return c.JSON(u)
+}
+
+// canViewOrg: VIEW_EMPLOYEES boleh melihat siapa saja; selain itu hanya
+// diri sendiri dan tim (langsung maupun tidak langsung).
+func (h *Handler) canViewOrg(c *fiber.Ctx, id int64) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	if id == userID {
+		return nil
+	}
+	allowed, err := h.hasPermission(c, "VIEW_EMPLOYEES")
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to check permission")
+	}
+	if allowed {
+		return nil
+	}
+	team, err := h.svc.TeamMemberIDs(c.Context(), userID, true)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to check team")
+	}
+	if slices.Contains(team, id) {
+		return nil
+	}
+	return fiber.NewError(fiber.StatusForbidden, "insufficient permission")
+}
+
+// GET /api/employees/:id/reports - direct reports
+func (h *Handler) GetDirectReports(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	if err := h.canViewOrg(c, id); err != nil {
+		return err
+	}
+	list, err := h.svc.ListDirectReports(c.Context(), id)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch direct reports")
+	}
+	if err := h.hideBirthDates(c, list); err != nil {
+		return err
+	}
+	return c.JSON(list)
+}
+
+// GET /api/employees/:id/subtree - seluruh bawahan dalam bentuk tree
+func (h *Handler) GetSubtree(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	if err := h.canViewOrg(c, id); err != nil {
+		return err
+	}
+	tree, err := h.svc.Subtree(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, "employee not found")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch subtree")
+	}
+	return c.JSON(tree)
+}
+
+// GET /api/employees/:id/chain - rantai atasan, mulai dari atasan langsung
+func (h *Handler) GetManagementChain(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	if err := h.canViewOrg(c, id); err != nil {
+		return err
+	}
+	list, err := h.svc.ListManagementChain(c.Context(), id)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch management chain")
+	}
+	if err := h.hideBirthDates(c, list); err != nil {
+		return err
+	}
+	return c.JSON(list)
+}
+
+// GET /api/org-chart?department=IT
+func (h *Handler) GetOrgChart(c *fiber.Ctx) error {
+	department := strings.ToUpper(strings.TrimSpace(c.Query("department")))
+	if department == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "department query parameter is required")
+	}
+	tree, err := h.svc.OrgChart(c.Context(), department)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to build org chart")
+	}
+	return c.JSON(fiber.Map{
+		"department": department,
+		"roots":      tree,
+	})
+}
+
+// GET /api/me/team?scope=direct|all
+func (h *Handler) GetMyTeam(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	team, err := h.svc.Team(c.Context(), userID, c.Query("scope") == "all")
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch team")
+	}
+	if err := h.hideBirthDates(c, team); err != nil {
+		return err
+	}
+	return c.JSON(team)
+}
+
+// ==========================
+// Employment history
+// ==========================
+
+// POST /api/employees/:id/changes
+// body: {"department":"ACC","job_title":"","branch":"","status":"","effective_date":"2026-01-01","reason":"Mutasi"}
+func (h *Handler) ScheduleChange(c *fiber.Ctx) error {
+	actorID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	var in EmploymentChangeInput
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+
+	change, err := h.svc.ScheduleChange(c.Context(), actorID, id, in)
+	if err != nil {
+		var vErr *ValidationError
+		switch {
+		case errors.As(err, &vErr):
+			return fiber.NewError(fiber.StatusBadRequest, vErr.Message)
+		case errors.Is(err, ErrNotFound):
+			return fiber.NewError(fiber.StatusNotFound, "employee not found")
+		case errors.Is(err, ErrInvalidTransition):
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to record employment change")
+	}
+	return c.Status(fiber.StatusCreated).JSON(change)
+}
+
+// ==========================
+// Status lifecycle
+// ==========================
+
+func statusError(err error, fallback string) error {
+	var vErr *ValidationError
+	switch {
+	case errors.As(err, &vErr):
+		return fiber.NewError(fiber.StatusBadRequest, vErr.Message)
+	case errors.Is(err, ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, "employee not found")
+	case errors.Is(err, ErrInvalidTransition):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, fallback)
+}
+
+// POST /api/employees/:id/status  body: {"status":"SUSPENDED","reason":"..."}
+func (h *Handler) ChangeStatus(c *fiber.Ctx) error {
+	actorID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	var in StatusInput
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	emp, err := h.svc.ChangeStatus(c.Context(), actorID, id, in)
+	if err != nil {
+		return statusError(err, "failed to change employee status")
+	}
+	return c.JSON(emp)
+}
+
+// POST /api/employees/:id/restore  body: {"reason":"..."}
+func (h *Handler) Restore(c *fiber.Ctx) error {
+	actorID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	emp, err := h.svc.Restore(c.Context(), actorID, id, body.Reason)
+	if err != nil {
+		return statusError(err, "failed to restore employee")
+	}
+	return c.JSON(emp)
+}
+
+// GET /api/employees/:id/history
+func (h *Handler) GetHistory(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	list, err := h.svc.ListHistory(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, "employee not found")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch employment history")
+	}
+	return c.JSON(list)
+}
+
+// GET /api/employees/:id/position?date=2025-06-30 (default hari ini)
+func (h *Handler) GetPositionAt(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	date := today()
+	if v := c.Query("date"); v != "" {
+		if date, err = time.Parse("2006-01-02", v); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid date (YYYY-MM-DD)")
+		}
+	}
+
+	pos, err := h.svc.PositionAt(c.Context(), id, date)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return fiber.NewError(fiber.StatusNotFound, "employee not found")
+		case errors.Is(err, ErrNoPosition):
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch position")
+	}
+	return c.JSON(pos)
+}
+
+// ==========================
+// Profile change approval
+// ==========================
+
+func profileChangeError(err error, fallback string) error {
+	var vErr *ValidationError
+	switch {
+	case errors.As(err, &vErr):
+		return fiber.NewError(fiber.StatusBadRequest, vErr.Message)
+	case errors.Is(err, ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, "profile change request not found")
+	case errors.Is(err, ErrChangeNotPending):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, ErrReviewOwnChange):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, fallback)
+}
+
+// GET /api/profile-policies
+func (h *Handler) GetProfilePolicies(c *fiber.Ctx) error {
+	list, err := h.svc.ProfilePolicies(c.Context())
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch profile policies")
+	}
+	return c.JSON(list)
+}
+
+// PUT /api/profile-policies  body: [{"field":"phone","mode":"APPROVAL"}]
+func (h *Handler) UpdateProfilePolicies(c *fiber.Ctx) error {
+	var body []ProfileFieldPolicy
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	list, err := h.svc.UpdateProfilePolicies(c.Context(), body)
+	if err != nil {
+		return profileChangeError(err, "failed to update profile policies")
+	}
+	return c.JSON(list)
+}
+
+// GET /api/me/profile-changes
+func (h *Handler) GetMyProfileChanges(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	list, err := h.svc.ListMyProfileChanges(c.Context(), userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch profile changes")
+	}
+	return c.JSON(list)
+}
+
+// DELETE /api/me/profile-changes/:id - batalkan pengajuan yang masih pending
+func (h *Handler) CancelMyProfileChange(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	if err := h.svc.CancelProfileChange(c.Context(), userID, id); err != nil {
+		return profileChangeError(err, "failed to cancel profile change")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GET /api/profile-changes?status=PENDING
+func (h *Handler) GetProfileChanges(c *fiber.Ctx) error {
+	list, err := h.svc.ListProfileChanges(c.Context(), c.Query("status", "PENDING"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch profile changes")
+	}
+	return c.JSON(list)
+}
+
+// POST /api/profile-changes/:id/approve
+func (h *Handler) ApproveProfileChange(c *fiber.Ctx) error {
+	return h.reviewProfileChange(c, true)
+}
+
+// POST /api/profile-changes/:id/reject  body: {"note": "..."}
+func (h *Handler) RejectProfileChange(c *fiber.Ctx) error {
+	return h.reviewProfileChange(c, false)
+}
+
+func (h *Handler) reviewProfileChange(c *fiber.Ctx, approve bool) error {
+	reviewerID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid user session")
+	}
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	var body struct {
+		Note string `json:"note"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+		}
+	}
+
+	req, err := h.svc.ReviewProfileChange(c.Context(), reviewerID, id, approve, body.Note)
+	if err != nil {
+		return profileChangeError(err, "failed to review profile change")
+	}
+	return c.JSON(req)
+}
+
+// ==========================
+// Custom fields
+// ==========================
+
+// GET /api/custom-fields?include_all=true
+// Default hanya field aktif yang boleh dilihat caller; include_all (butuh
+// MANAGE_EMPLOYEES) untuk halaman admin.
+func (h *Handler) ListCustomFields(c *fiber.Ctx) error {
+	includeAll := c.QueryBool("include_all")
+	if includeAll {
+		ok, err := h.hasPermission(c, "MANAGE_EMPLOYEES")
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "failed to check permission")
+		}
+		if !ok {
+			return fiber.NewError(fiber.StatusForbidden, "insufficient permission")
+		}
+	}
+	roles, _ := c.Locals("roles").([]string)
+	list, err := h.svc.ListCustomFields(c.Context(), roles, includeAll)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch custom fields")
+	}
+	return c.JSON(list)
+}
+
+// PUT /api/custom-fields/:key
+// body: {"label":"Shirt size","field_type":"SELECT","options":["S","M","L"],"required":false,
+//
+//	"department":"","visible_roles":["HRD"],"sort_order":1,"is_active":true}
+func (h *Handler) SaveCustomField(c *fiber.Ctx) error {
+	var in struct {
+		CustomField
+		IsActive *bool `json:"is_active"` // tidak dikirim = tidak berubah
+	}
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid payload")
+	}
+	in.Key = c.Params("key")
+	f, err := h.svc.SaveCustomField(c.Context(), in.CustomField, in.IsActive)
+	if err != nil {
+		var vErr *ValidationError
+		if errors.As(err, &vErr) {
+			return fiber.NewError(fiber.StatusBadRequest, vErr.Message)
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to save custom field")
+	}
+	return c.JSON(f)
+}
+
+// DELETE /api/custom-fields/:key - nonaktifkan, nilai tetap tersimpan
+func (h *Handler) DeleteCustomField(c *fiber.Ctx) error {
+	if err := h.svc.DeactivateCustomField(c.Context(), c.Params("key")); err != nil {
+		if err == ErrNotFound {
+			return fiber.NewError(fiber.StatusNotFound, "custom field not found")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to delete custom field")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
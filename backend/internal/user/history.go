@@ -0,0 +1,299 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrNoPosition = errors.New("no position recorded on that date")
+
+// today returns the current date at midnight UTC, matching how DATE columns
+// are scanned.
+func today() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// positionChanged reports whether the fields tracked in employment_history differ.
+func positionChanged(a, b *User) bool {
+	return a.Department != b.Department || a.JobTitle != b.JobTitle ||
+		a.Branch != b.Branch || a.Status != b.Status
+}
+
+// snapshot builds an applied history row from the user's current position.
+func snapshot(u *User, effective time.Time, reason string, actorID *int64) *EmploymentChange {
+	return &EmploymentChange{
+		UserID:        u.ID,
+		Department:    u.Department,
+		JobTitle:      u.JobTitle,
+		Branch:        u.Branch,
+		Status:        u.Status,
+		EffectiveDate: effective,
+		Reason:        reason,
+		ChangedBy:     actorID,
+	}
+}
+
+// recordHire writes the initial history row of a newly created employee.
+func recordHire(ctx context.Context, repo *Repository, u *User, reason string, actorID *int64) error {
+	effective := today()
+	if u.JoinDate != nil {
+		effective = *u.JoinDate
+	}
+	return repo.InsertHistory(ctx, snapshot(u, effective, reason, actorID), true)
+}
+
+func (in *EmploymentChangeInput) sanitize() {
+	in.Department = strings.ToUpper(strings.TrimSpace(in.Department))
+	in.JobTitle = strings.TrimSpace(in.JobTitle)
+	in.Branch = strings.TrimSpace(in.Branch)
+	in.Status = strings.ToUpper(strings.TrimSpace(in.Status))
+	in.EffectiveDate = strings.TrimSpace(in.EffectiveDate)
+	in.Reason = strings.TrimSpace(in.Reason)
+}
+
+// ScheduleChange records a transfer/promotion/status change. Changes effective
+// today or earlier are applied right away; future-dated ones stay pending
+// until ApplyDueChanges picks them up. Tanggal mundur sebelum perubahan
+// terakhir yang sudah diterapkan ditolak, supaya tidak menimpa posisi yang
+// lebih baru.
+func (s *Service) ScheduleChange(ctx context.Context, actorID, userID int64, in EmploymentChangeInput) (*EmploymentChange, error) {
+	in.sanitize()
+	if in.Department == "" && in.JobTitle == "" && in.Branch == "" && in.Status == "" {
+		return nil, &ValidationError{Field: "department", Message: "at least one of department, job_title, branch or status is required"}
+	}
+	if in.Reason == "" {
+		return nil, &ValidationError{Field: "reason", Message: "reason is required"}
+	}
+	if in.Status != "" && !validStatus(in.Status) {
+		return nil, &ValidationError{Field: "status", Message: fmt.Sprintf("invalid status %q", in.Status)}
+	}
+	effective := today()
+	if in.EffectiveDate != "" {
+		t, err := time.Parse("2006-01-02", in.EffectiveDate)
+		if err != nil {
+			return nil, &ValidationError{Field: "effective_date", Message: "invalid effective_date (YYYY-MM-DD)"}
+		}
+		effective = t
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	current, err := repo.FindByID(userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	if !effective.After(today()) {
+		last, ok, err := repo.LastAppliedDate(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if ok && effective.Before(last) {
+			return nil, &ValidationError{Field: "effective_date", Message: fmt.Sprintf("effective_date is before the last applied change (%s)", last.Format("2006-01-02"))}
+		}
+	}
+
+	h := &EmploymentChange{
+		UserID:        userID,
+		Department:    in.Department,
+		JobTitle:      in.JobTitle,
+		Branch:        in.Branch,
+		Status:        in.Status,
+		EffectiveDate: effective,
+		Reason:        in.Reason,
+		ChangedBy:     &actorID,
+	}
+	if err := s.checkChange(ctx, current, h); err != nil {
+		return nil, err
+	}
+
+	if effective.After(today()) {
+		if err := repo.InsertHistory(ctx, h, false); err != nil {
+			return nil, err
+		}
+	} else {
+		u, err := repo.ApplyPosition(ctx, h)
+		if err != nil {
+			return nil, err
+		}
+		h = snapshot(u, effective, in.Reason, &actorID)
+		if err := repo.InsertHistory(ctx, h, true); err != nil {
+			return nil, err
+		}
+		if err := s.statusTransition(ctx, tx, current.Status, u); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// ApplyDueChanges applies pending changes whose effective date has arrived.
+// Dijalankan periodik lewat scheduler.Every.
+func (s *Service) ApplyDueChanges(ctx context.Context) error {
+	for {
+		n, err := s.applyDueBatch(ctx, 100)
+		if err != nil || n < 100 {
+			return err
+		}
+	}
+}
+
+// checkChange validates h against the current position: status transition
+// dan master data untuk field yang berubah.
+func (s *Service) checkChange(ctx context.Context, current *User, h *EmploymentChange) error {
+	if h.Status != "" && h.Status != current.Status && !canTransition(current.Status, h.Status) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, current.Status, h.Status)
+	}
+	// Department yang tidak diubah pakai nilai sekarang.
+	check := EmployeeInput{Department: h.Department, Branch: h.Branch, JobTitle: h.JobTitle}
+	if check.Department == "" {
+		check.Department = current.Department
+	}
+	errs, err := s.catalogErrors(ctx, &check, current)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return &errs[0]
+	}
+	return nil
+}
+
+func (s *Service) applyDueBatch(ctx context.Context, limit int) (int, error) {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	due, err := repo.ClaimDueChanges(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+	for i := range due {
+		before, err := repo.FindByID(due[i].UserID)
+		if err == sql.ErrNoRows {
+			continue // user sudah dihapus
+		}
+		if err != nil {
+			return 0, err
+		}
+		// Cek ulang: status atau master data bisa berubah sejak dijadwalkan.
+		if err := s.checkChange(ctx, before, &due[i]); err != nil {
+			var vErr *ValidationError
+			if !errors.As(err, &vErr) && !errors.Is(err, ErrInvalidTransition) {
+				return 0, err
+			}
+			if err := repo.SkipHistory(ctx, due[i].ID, err.Error()); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		u, err := repo.ApplyPosition(ctx, &due[i])
+		if err != nil {
+			return 0, err
+		}
+		if err := repo.MarkHistoryApplied(ctx, due[i].ID, u); err != nil {
+			return 0, err
+		}
+		if err := s.statusTransition(ctx, tx, before.Status, u); err != nil {
+			return 0, err
+		}
+	}
+	return len(due), tx.Commit()
+}
+
+// SetEmploymentStatusTx updates employment_status (PROBATION/CONFIRMED)
+// inside the caller's transaction. With deactivate the employee is also set
+// TERMINATED and the change is recorded in employment_history. Dipakai modul
+// kontrak saat evaluasi masa percobaan.
+func (s *Service) SetEmploymentStatusTx(ctx context.Context, tx *sql.Tx, actorID, userID int64, employmentStatus, reason string, deactivate bool) error {
+	repo := s.repo.WithTx(tx)
+	if err := repo.SetEmploymentStatus(ctx, userID, employmentStatus); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		return err
+	}
+	if !deactivate {
+		return nil
+	}
+	before, err := repo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	u, err := repo.ApplyPosition(ctx, &EmploymentChange{UserID: userID, Status: StatusTerminated})
+	if err != nil {
+		return err
+	}
+	if err := repo.InsertHistory(ctx, snapshot(u, today(), reason, &actorID), true); err != nil {
+		return err
+	}
+	return s.statusTransition(ctx, tx, before.Status, u)
+}
+
+// ListHistory returns the employment history of a user, oldest first.
+func (s *Service) ListHistory(ctx context.Context, userID int64) ([]EmploymentChange, error) {
+	if _, err := s.GetByID(userID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListHistory(ctx, userID)
+}
+
+// PositionAt answers "what was this person's position on date": the rows
+// effective up to date are folded in order, so pending rows that only carry
+// the changed fields overlay the earlier snapshot.
+func (s *Service) PositionAt(ctx context.Context, userID int64, date time.Time) (*EmploymentChange, error) {
+	if _, err := s.GetByID(userID); err != nil {
+		return nil, err
+	}
+	rows, err := s.repo.HistoryUntil(ctx, userID, date)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNoPosition
+	}
+
+	pos := rows[0]
+	for _, h := range rows[1:] {
+		if h.Department != "" {
+			pos.Department = h.Department
+		}
+		if h.JobTitle != "" {
+			pos.JobTitle = h.JobTitle
+		}
+		if h.Branch != "" {
+			pos.Branch = h.Branch
+		}
+		if h.Status != "" {
+			pos.Status = h.Status
+		}
+		pos.ID = h.ID
+		pos.EffectiveDate = h.EffectiveDate
+		pos.Reason = h.Reason
+		pos.ChangedBy = h.ChangedBy
+		pos.ChangedByName = h.ChangedByName
+		pos.AppliedAt = h.AppliedAt
+		pos.CreatedAt = h.CreatedAt
+	}
+	return &pos, nil
+}
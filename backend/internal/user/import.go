@@ -0,0 +1,347 @@
+package user
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"hr-portal-backend/pkg/crypto"
+)
+
+var ErrImportFormat = errors.New("unsupported import file")
+
+// importColumns maps accepted header names (lower case) to EmployeeInput fields.
+var importColumns = map[string]string{
+	"employee_code": "employee_code",
+	"code":          "employee_code",
+	"name":          "name",
+	"email":         "email",
+	"department":    "department",
+	"branch":        "branch",
+	"job_title":     "job_title",
+	"status":        "status",
+	"roles":         "roles",
+	"password":      "password",
+}
+
+// customColumnPrefix marks a custom field column, mis. "cf.shirt_size"
+// (sama dengan filter list).
+const customColumnPrefix = "cf."
+
+// ImportRowError is a validation problem on one row of the import file.
+// Row mengikuti nomor baris di file (header = baris 1).
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportReport is returned by both dry-run and commit mode.
+type ImportReport struct {
+	Committed bool             `json:"committed"`
+	TotalRows int              `json:"total_rows"`
+	ValidRows int              `json:"valid_rows"`
+	Errors    []ImportRowError `json:"errors"`
+	Created   []User           `json:"created,omitempty"`
+}
+
+type importRow struct {
+	line  int
+	input EmployeeInput
+	// customFields is the validated custom field values, diisi validateImport.
+	customFields map[string]any
+}
+
+// readImportFile parses a CSV or XLSX (first sheet) into rows keyed by header.
+func readImportFile(r io.Reader, filename string) ([]importRow, error) {
+	var records [][]string
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.TrimLeadingSpace = true
+		all, err := cr.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrImportFormat, err)
+		}
+		records = all
+	case ".xlsx":
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrImportFormat, err)
+		}
+		defer f.Close()
+		all, err := f.GetRows(f.GetSheetName(0))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrImportFormat, err)
+		}
+		records = all
+	default:
+		return nil, fmt.Errorf("%w: only .csv and .xlsx are accepted", ErrImportFormat)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrImportFormat)
+	}
+
+	header := make([]string, len(records[0]))
+	customKeys := make([]string, len(records[0]))
+	hasName, hasEmail := false, false
+	for i, h := range records[0] {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if key, ok := strings.CutPrefix(h, customColumnPrefix); ok && key != "" {
+			header[i], customKeys[i] = "custom", key
+			continue
+		}
+		field := importColumns[h]
+		header[i] = field
+		hasName = hasName || field == "name"
+		hasEmail = hasEmail || field == "email"
+	}
+	if !hasName || !hasEmail {
+		return nil, fmt.Errorf("%w: header must contain at least name and email", ErrImportFormat)
+	}
+
+	var rows []importRow
+	for i, rec := range records[1:] {
+		var in EmployeeInput
+		empty := true
+		for j, v := range rec {
+			if j >= len(header) || strings.TrimSpace(v) == "" {
+				continue
+			}
+			empty = false
+			switch header[j] {
+			case "employee_code":
+				in.EmployeeCode = v
+			case "name":
+				in.Name = v
+			case "email":
+				in.Email = v
+			case "department":
+				in.Department = v
+			case "branch":
+				in.Branch = v
+			case "job_title":
+				in.JobTitle = v
+			case "status":
+				in.Status = v
+			case "roles":
+				in.Roles = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ';' })
+			case "password":
+				in.Password = v
+			case "custom":
+				if in.CustomFields == nil {
+					in.CustomFields = map[string]any{}
+				}
+				in.CustomFields[customKeys[j]] = v
+			}
+		}
+		if empty {
+			continue
+		}
+		in.sanitize()
+		rows = append(rows, importRow{line: i + 2, input: in})
+	}
+	return rows, nil
+}
+
+// validateImport checks every row against the file itself and the database,
+// and fills the validated custom fields of each row.
+func (s *Service) validateImport(ctx context.Context, repo *Repository, rows []importRow) ([]ImportRowError, error) {
+	var errs []ImportRowError
+	fail := func(line int, field, msg string) {
+		errs = append(errs, ImportRowError{Row: line, Field: field, Message: msg})
+	}
+
+	var emails, codes []string
+	for _, r := range rows {
+		if r.input.Email != "" {
+			emails = append(emails, r.input.Email)
+		}
+		if r.input.EmployeeCode != "" {
+			codes = append(codes, r.input.EmployeeCode)
+		}
+	}
+	takenEmails, err := repo.ExistingEmails(ctx, emails)
+	if err != nil {
+		return nil, err
+	}
+	takenCodes, err := repo.ExistingCodes(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := repo.KnownRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	customDefs, err := repo.ListCustomFields(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	seenEmail := map[string]int{}
+	seenCode := map[string]int{}
+	for i := range rows {
+		r := &rows[i]
+		in := r.input
+		if in.Name == "" {
+			fail(r.line, "name", "name is required")
+		}
+		if in.Email == "" {
+			fail(r.line, "email", "email is required")
+		} else if _, err := mail.ParseAddress(in.Email); err != nil {
+			fail(r.line, "email", "invalid email address")
+		} else if takenEmails[in.Email] {
+			fail(r.line, "email", "email already registered")
+		} else if first, dup := seenEmail[in.Email]; dup {
+			fail(r.line, "email", fmt.Sprintf("duplicate email, first seen on row %d", first))
+		} else {
+			seenEmail[in.Email] = r.line
+		}
+
+		if in.EmployeeCode != "" {
+			if takenCodes[in.EmployeeCode] {
+				fail(r.line, "employee_code", "employee code already in use")
+			} else if first, dup := seenCode[in.EmployeeCode]; dup {
+				fail(r.line, "employee_code", fmt.Sprintf("duplicate employee code, first seen on row %d", first))
+			} else {
+				seenCode[in.EmployeeCode] = r.line
+			}
+		}
+
+		catErrs, err := s.catalogErrors(ctx, &in, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range catErrs {
+			fail(r.line, e.Field, e.Message)
+		}
+		for _, role := range in.Roles {
+			if !roles[role] {
+				fail(r.line, "roles", fmt.Sprintf("unknown role %q", role))
+			}
+		}
+		if !validStatus(in.Status) {
+			fail(r.line, "status", fmt.Sprintf("invalid status %q", in.Status))
+		}
+		// Sama dengan CreateEmployee: field wajib tidak bisa dilewati lewat import.
+		custom, err := mergeCustomValues(customDefs, in.Department, nil, in.CustomFields)
+		var vErr *ValidationError
+		switch {
+		case errors.As(err, &vErr):
+			fail(r.line, vErr.Field, vErr.Message)
+		case err != nil:
+			return nil, err
+		}
+		r.customFields = custom
+	}
+	return errs, nil
+}
+
+// ImportEmployees validates an XLSX/CSV file of employees. In dry-run mode
+// (commit=false) it only returns the per-row report. In commit mode all rows
+// are inserted in one transaction, or none if any row is invalid; blank
+// employee codes are generated from the configured code pattern.
+func (s *Service) ImportEmployees(ctx context.Context, actorID int64, r io.Reader, filename string, commit bool) (*ImportReport, error) {
+	rows, err := readImportFile(r, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{TotalRows: len(rows), Errors: []ImportRowError{}}
+	errs, err := s.validateImport(ctx, s.repo, rows)
+	if err != nil {
+		return nil, err
+	}
+	report.Errors = append(report.Errors, errs...)
+
+	invalid := map[int]bool{}
+	for _, e := range errs {
+		invalid[e.Row] = true
+	}
+	report.ValidRows = len(rows) - len(invalid)
+
+	if !commit || len(errs) > 0 {
+		return report, nil
+	}
+
+	// Password default cukup di-hash sekali untuk semua baris tanpa password.
+	defaultHash, err := crypto.HashPassword("changeme123")
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	txRepo := s.repo.WithTx(tx)
+
+	// Kode yang diisi manual di file dipesan dulu supaya kode yang dibuat
+	// untuk baris sebelumnya tidak bentrok dengannya.
+	reserved := map[string]bool{}
+	for _, row := range rows {
+		if row.input.EmployeeCode != "" {
+			reserved[row.input.EmployeeCode] = true
+		}
+	}
+
+	for _, row := range rows {
+		in := row.input
+		if in.EmployeeCode == "" {
+			code, err := s.generateCode(ctx, txRepo, in.Department, reserved)
+			if err != nil {
+				return nil, err
+			}
+			in.EmployeeCode = code
+		}
+		roles := in.Roles
+		if len(roles) == 0 {
+			roles = defaultRoles(in.Department)
+		}
+		hash := defaultHash
+		if in.Password != "" {
+			if hash, err = crypto.HashPassword(in.Password); err != nil {
+				return nil, err
+			}
+		}
+
+		u, err := txRepo.CreateEmployee(ctx, &User{
+			EmployeeCode: in.EmployeeCode,
+			Name:         in.Name,
+			Email:        in.Email,
+			Branch:       in.Branch,
+			JobTitle:     in.JobTitle,
+			Status:       in.Status,
+			Department:   in.Department,
+			Roles:        roles,
+			PasswordHash: hash,
+			customFields: row.customFields,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row.line, err)
+		}
+		if err := recordHire(ctx, txRepo, u, "Imported", &actorID); err != nil {
+			return nil, fmt.Errorf("row %d: %w", row.line, err)
+		}
+		if err := s.statusTransition(ctx, tx, "", u); err != nil {
+			return nil, fmt.Errorf("row %d: %w", row.line, err)
+		}
+		report.Created = append(report.Created, *u)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	report.Committed = true
+	return report, nil
+}
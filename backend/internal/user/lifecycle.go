@@ -0,0 +1,168 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Status karyawan.
+const (
+	StatusActive     = "ACTIVE"
+	StatusSuspended  = "SUSPENDED"
+	StatusResigned   = "RESIGNED"
+	StatusTerminated = "TERMINATED"
+)
+
+var (
+	ErrAccountInactive   = errors.New("account is not active")
+	ErrInvalidTransition = errors.New("status transition not allowed")
+)
+
+// statusTransitions lists the allowed target statuses per current status.
+// RESIGNED/TERMINATED hanya bisa kembali ke ACTIVE lewat restore.
+var statusTransitions = map[string][]string{
+	StatusActive:     {StatusSuspended, StatusResigned, StatusTerminated},
+	StatusSuspended:  {StatusActive, StatusResigned, StatusTerminated},
+	StatusResigned:   {StatusActive},
+	StatusTerminated: {StatusActive},
+}
+
+func validStatus(status string) bool {
+	_, ok := statusTransitions[status]
+	return ok
+}
+
+func canTransition(from, to string) bool {
+	return slices.Contains(statusTransitions[from], to)
+}
+
+// isExitStatus reports whether the status means the employee has left.
+func isExitStatus(status string) bool {
+	return status == StatusResigned || status == StatusTerminated
+}
+
+// Lifecycle is told, inside the same transaction, when an employee joins or
+// leaves. Dipenuhi oleh *checklist.Service (onboarding/offboarding).
+type Lifecycle interface {
+	OnboardTx(ctx context.Context, tx *sql.Tx, userID int64, department string) error
+	OffboardTx(ctx context.Context, tx *sql.Tx, userID int64, department string) error
+}
+
+// statusTransition runs the side effects of a status change; before kosong
+// berarti karyawan baru dibuat. Leaving ACTIVE revokes the user's sessions.
+func (s *Service) statusTransition(ctx context.Context, tx *sql.Tx, before string, u *User) error {
+	if before == StatusActive && u.Status != StatusActive {
+		if err := s.repo.WithTx(tx).RevokeSessions(ctx, u.ID); err != nil {
+			return err
+		}
+	}
+	if s.lifecycle == nil {
+		return nil
+	}
+	switch {
+	case before == "" && u.Status == StatusActive:
+		return s.lifecycle.OnboardTx(ctx, tx, u.ID, u.Department)
+	case before != "" && !isExitStatus(before) && isExitStatus(u.Status):
+		return s.lifecycle.OffboardTx(ctx, tx, u.ID, u.Department)
+	}
+	return nil
+}
+
+// StatusInput is the body of a status change.
+type StatusInput struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// ChangeStatus moves an employee to another status following
+// statusTransitions. The reason is recorded in employment_history.
+func (s *Service) ChangeStatus(ctx context.Context, actorID, id int64, in StatusInput) (*User, error) {
+	in.Status = strings.ToUpper(strings.TrimSpace(in.Status))
+	in.Reason = strings.TrimSpace(in.Reason)
+	if !validStatus(in.Status) {
+		return nil, &ValidationError{Field: "status", Message: fmt.Sprintf("invalid status %q", in.Status)}
+	}
+	if in.Reason == "" {
+		return nil, &ValidationError{Field: "reason", Message: "reason is required"}
+	}
+	return s.transition(ctx, actorID, in.Status, in.Reason, func(repo *Repository) (*User, error) {
+		return repo.FindByID(id)
+	})
+}
+
+// Restore reactivates a suspended, resigned or terminated employee.
+func (s *Service) Restore(ctx context.Context, actorID, id int64, reason string) (*User, error) {
+	return s.ChangeStatus(ctx, actorID, id, StatusInput{Status: StatusActive, Reason: reason})
+}
+
+// DeleteEmployee soft-deletes an employee (status TERMINATED), recording the
+// change in employment_history, revoking sessions and starting offboarding.
+func (s *Service) DeleteEmployee(ctx context.Context, actorID, id int64) error {
+	return s.softDelete(ctx, actorID, func(repo *Repository) (*User, error) {
+		return repo.FindByID(id)
+	})
+}
+
+func (s *Service) DeleteEmployeeByCode(ctx context.Context, actorID int64, code string) error {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return &ValidationError{Field: "employee_code", Message: "invalid employee code"}
+	}
+	return s.softDelete(ctx, actorID, func(repo *Repository) (*User, error) {
+		return repo.FindByCode(ctx, code)
+	})
+}
+
+func (s *Service) softDelete(ctx context.Context, actorID int64, find func(*Repository) (*User, error)) error {
+	_, err := s.transition(ctx, actorID, StatusTerminated, "Deactivated", find)
+	if errors.Is(err, ErrInvalidTransition) {
+		return nil // sudah keluar
+	}
+	return err
+}
+
+func (s *Service) transition(ctx context.Context, actorID int64, status, reason string, find func(*Repository) (*User, error)) (*User, error) {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	before, err := find(repo)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if !canTransition(before.Status, status) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, before.Status, status)
+	}
+	u, err := repo.ApplyPosition(ctx, &EmploymentChange{UserID: before.ID, Status: status})
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.InsertHistory(ctx, snapshot(u, today(), reason, &actorID), true); err != nil {
+		return nil, err
+	}
+	if err := s.statusTransition(ctx, tx, before.Status, u); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// SessionActive reports whether a token issued at issuedAt for the user is
+// still usable: the user must be ACTIVE and the token newer than the last
+// revocation. Dipanggil JWT middleware di setiap request.
+func (s *Service) SessionActive(ctx context.Context, userID int64, issuedAt time.Time) (bool, error) {
+	return s.repo.SessionActive(ctx, userID, issuedAt)
+}
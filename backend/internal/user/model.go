@@ -24,6 +24,17 @@ type User struct {
 	JoinDate         *time.Time `json:"join_date,omitempty"`
 	EmergencyContact string     `json:"emergency_contact,omitempty"`
 	EmergencyPhone   string     `json:"emergency_phone,omitempty"`
+
+	// Reporting line
+	ManagerID *int64 `json:"manager_id,omitempty"`
+
+	// PROBATION / CONFIRMED, diisi dari kontrak kerja
+	EmploymentStatus string `json:"employment_status,omitempty"`
+
+	// CustomFields hanya berisi field yang boleh dilihat caller; diisi oleh
+	// Service.ShowCustomFields dari customFields (semua nilai tersimpan).
+	CustomFields map[string]any `json:"custom_fields,omitempty"`
+	customFields map[string]any
 }
 
 // ProfileInput is used for updating user's own profile
@@ -36,3 +47,123 @@ type ProfileInput struct {
 	EmergencyContact string `json:"emergency_contact"`
 	EmergencyPhone   string `json:"emergency_phone"`
 }
+
+// ListFilter is the parsed query of GET /api/employees.
+type ListFilter struct {
+	Search     string
+	Department string
+	Branch     string
+	Status     string
+	Role       string
+	JoinedFrom *time.Time
+	JoinedTo   *time.Time
+	// CustomFields filter persis per key (query cf.<key>=value).
+	CustomFields map[string]string
+	// ViewerRoles are the caller's roles, for custom field visibility.
+	ViewerRoles []string
+	customMatch map[string]any // CustomFields yang sudah divalidasi & bertipe
+
+	Sort string // salah satu key di sortColumns, default employee_code
+	Desc bool
+
+	Page     int
+	PageSize int
+	// After is the decoded cursor; when set it takes precedence over Page.
+	After *ListCursor
+}
+
+// ListCursor points at the last row of the previous page. Sort/Desc
+// menyimpan urutan yang menghasilkan cursor; cursor tidak berlaku untuk
+// urutan lain.
+type ListCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Value string `json:"v"`
+	ID    int64  `json:"id"`
+}
+
+// EmployeePage is the response of GET /api/employees.
+type EmployeePage struct {
+	Items      []User `json:"items"`
+	Total      int    `json:"total"`
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// OrgNode is one employee in an org-chart tree.
+type OrgNode struct {
+	ID           int64      `json:"id"`
+	EmployeeCode string     `json:"employee_code"`
+	Name         string     `json:"name"`
+	JobTitle     string     `json:"job_title"`
+	Department   string     `json:"department"`
+	PhotoURL     string     `json:"photo_url,omitempty"`
+	ManagerID    *int64     `json:"manager_id,omitempty"`
+	Children     []*OrgNode `json:"children"`
+}
+
+// EmploymentChange is one row of employment_history: the position held
+// from EffectiveDate on. Pending (scheduled) rows have AppliedAt nil and
+// leave unchanged fields empty.
+type EmploymentChange struct {
+	ID            int64      `json:"id"`
+	UserID        int64      `json:"user_id"`
+	Department    string     `json:"department,omitempty"`
+	JobTitle      string     `json:"job_title,omitempty"`
+	Branch        string     `json:"branch,omitempty"`
+	Status        string     `json:"status,omitempty"`
+	EffectiveDate time.Time  `json:"effective_date"`
+	Reason        string     `json:"reason,omitempty"`
+	ChangedBy     *int64     `json:"changed_by,omitempty"`
+	ChangedByName string     `json:"changed_by_name,omitempty"`
+	AppliedAt     *time.Time `json:"applied_at,omitempty"`
+	SkippedReason string     `json:"skipped_reason,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// EmploymentChangeInput is the body of POST /api/employees/:id/changes.
+// Field kosong berarti tidak berubah.
+type EmploymentChangeInput struct {
+	Department    string `json:"department"`
+	JobTitle      string `json:"job_title"`
+	Branch        string `json:"branch"`
+	Status        string `json:"status"`
+	EffectiveDate string `json:"effective_date"` // YYYY-MM-DD, default hari ini
+	Reason        string `json:"reason"`
+}
+
+// Profile change policy modes.
+const (
+	PolicyImmediate = "IMMEDIATE"
+	PolicyApproval  = "APPROVAL"
+)
+
+// ProfileFieldPolicy says whether a self-service field needs HR review.
+type ProfileFieldPolicy struct {
+	Field string `json:"field"`
+	Mode  string `json:"mode"`
+}
+
+// FieldChange is one field of a profile change request, old and new value
+// side by side.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// ProfileChangeRequest is a pending (or reviewed) set of profile changes.
+type ProfileChangeRequest struct {
+	ID           int64         `json:"id"`
+	UserID       int64         `json:"user_id"`
+	UserName     string        `json:"user_name"`
+	EmployeeCode string        `json:"employee_code"`
+	Changes      []FieldChange `json:"changes"`
+	Status       string        `json:"status"` // PENDING, APPROVED, REJECTED, CANCELLED
+	ReviewedBy   *int64        `json:"reviewed_by,omitempty"`
+	ReviewerName string        `json:"reviewer_name,omitempty"`
+	ReviewNote   string        `json:"review_note,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+	ReviewedAt   *time.Time    `json:"reviewed_at,omitempty"`
+}
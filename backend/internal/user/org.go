@@ -0,0 +1,152 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+var ErrInvalidManager = errors.New("invalid manager")
+
+// SetManager assigns (or clears, when managerID is nil) the manager of an
+// employee. Assignments that would create a reporting cycle are rejected.
+func (s *Service) SetManager(ctx context.Context, id int64, managerID *int64) (*User, error) {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	if err := repo.LockReportingLines(ctx); err != nil {
+		return nil, err
+	}
+
+	if managerID != nil {
+		if *managerID == id {
+			return nil, ErrInvalidManager
+		}
+		if _, err := repo.FindByID(*managerID); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, ErrInvalidManager
+			}
+			return nil, err
+		}
+		// Kalau id ada di rantai atasan calon manager, hasilnya siklus.
+		cycle, err := repo.ChainContains(ctx, *managerID, id)
+		if err != nil {
+			return nil, err
+		}
+		if cycle {
+			return nil, ErrInvalidManager
+		}
+	}
+
+	if err := repo.SetManager(ctx, id, managerID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	u, err := repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return u, tx.Commit()
+}
+
+func (s *Service) ListDirectReports(ctx context.Context, managerID int64) ([]User, error) {
+	return s.repo.ListDirectReports(ctx, managerID)
+}
+
+func (s *Service) ListManagementChain(ctx context.Context, id int64) ([]User, error) {
+	return s.repo.ListManagementChain(ctx, id)
+}
+
+// Subtree returns the org tree below an employee (the employee is the root).
+func (s *Service) Subtree(ctx context.Context, id int64) (*OrgNode, error) {
+	root, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := s.repo.ListSubordinates(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	all := append([]User{*root}, subs...)
+	trees := buildOrgTree(all)
+	for _, t := range trees {
+		if t.ID == id {
+			return t, nil
+		}
+	}
+	return toOrgNode(root), nil
+}
+
+// OrgChart returns the active employees of a department as a forest: roots are
+// people without a manager or whose manager sits outside the department.
+func (s *Service) OrgChart(ctx context.Context, department string) ([]*OrgNode, error) {
+	users, err := s.ExportEmployees(ctx, ListFilter{Department: department, Status: "ACTIVE", Sort: "name"})
+	if err != nil {
+		return nil, err
+	}
+	return buildOrgTree(users), nil
+}
+
+// TeamMemberIDs is the "my team" scope: ids of the direct reports of
+// managerID, or of everyone below them when indirect is true. Dipakai juga
+// oleh modul lain yang perlu membatasi data ke tim seorang manager.
+func (s *Service) TeamMemberIDs(ctx context.Context, managerID int64, indirect bool) ([]int64, error) {
+	team, err := s.Team(ctx, managerID, indirect)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, len(team))
+	for i, u := range team {
+		ids[i] = u.ID
+	}
+	return ids, nil
+}
+
+// Team returns the direct reports of managerID, or the whole subtree.
+func (s *Service) Team(ctx context.Context, managerID int64, indirect bool) ([]User, error) {
+	if indirect {
+		return s.repo.ListSubordinates(ctx, managerID)
+	}
+	return s.repo.ListDirectReports(ctx, managerID)
+}
+
+func toOrgNode(u *User) *OrgNode {
+	return &OrgNode{
+		ID:           u.ID,
+		EmployeeCode: u.EmployeeCode,
+		Name:         u.Name,
+		JobTitle:     u.JobTitle,
+		Department:   u.Department,
+		PhotoURL:     u.PhotoURL,
+		ManagerID:    u.ManagerID,
+		Children:     []*OrgNode{},
+	}
+}
+
+// buildOrgTree links users to their managers; users whose manager is not in
+// the list become roots. Urutan input dipertahankan.
+func buildOrgTree(users []User) []*OrgNode {
+	nodes := make(map[int64]*OrgNode, len(users))
+	for i := range users {
+		nodes[users[i].ID] = toOrgNode(&users[i])
+	}
+
+	roots := []*OrgNode{}
+	for i := range users {
+		n := nodes[users[i].ID]
+		if n.ManagerID != nil {
+			if parent, ok := nodes[*n.ManagerID]; ok {
+				parent.Children = append(parent.Children, n)
+				continue
+			}
+		}
+		roots = append(roots, n)
+	}
+	return roots
+}
@@ -0,0 +1,312 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrChangeNotPending = errors.New("profile change request is no longer pending")
+	ErrReviewOwnChange  = errors.New("cannot review your own profile change")
+)
+
+// profileField is one self-service field. expr is the SQL assigned to the
+// column, with ? as the parameter placeholder. Field baru (mis. rekening
+// bank, NPWP) cukup ditambahkan di sini lalu diberi policy.
+type profileField struct {
+	key    string
+	column string
+	expr   string
+	get    func(u *User) string
+}
+
+var profileFields = []profileField{
+	{key: "name", column: "name", expr: "?", get: func(u *User) string { return u.Name }},
+	{key: "phone", column: "phone", expr: "?", get: func(u *User) string { return u.Phone }},
+	{key: "address", column: "address", expr: "?", get: func(u *User) string { return u.Address }},
+	{key: "birth_date", column: "birth_date", expr: "NULLIF(?, '')::date", get: func(u *User) string {
+		if u.BirthDate == nil {
+			return ""
+		}
+		return u.BirthDate.Format("2006-01-02")
+	}},
+	{key: "gender", column: "gender", expr: "?", get: func(u *User) string { return u.Gender }},
+	{key: "emergency_contact", column: "emergency_contact", expr: "?", get: func(u *User) string { return u.EmergencyContact }},
+	{key: "emergency_phone", column: "emergency_phone", expr: "?", get: func(u *User) string { return u.EmergencyPhone }},
+}
+
+func findProfileField(key string) *profileField {
+	for i := range profileFields {
+		if profileFields[i].key == key {
+			return &profileFields[i]
+		}
+	}
+	return nil
+}
+
+// values returns the submitted profile as field key -> value.
+func (in *ProfileInput) values() map[string]string {
+	return map[string]string{
+		"name":              in.Name,
+		"phone":             in.Phone,
+		"address":           in.Address,
+		"birth_date":        in.BirthDate,
+		"gender":            in.Gender,
+		"emergency_contact": in.EmergencyContact,
+		"emergency_phone":   in.EmergencyPhone,
+	}
+}
+
+// UpdateProfile updates the current user's own profile. Fields with an
+// APPROVAL policy that changed are not saved but collected into one pending
+// change request for HRD; the others are saved immediately. Perubahan yang
+// masih pending hanya diganti untuk field yang dikirim dengan nilai baru,
+// jadi menyimpan field lain tidak membatalkannya.
+func (s *Service) UpdateProfile(ctx context.Context, userID int64, input *ProfileInput) (*User, *ProfileChangeRequest, error) {
+	// Sanitize input
+	input.Name = strings.TrimSpace(input.Name)
+	input.Phone = strings.TrimSpace(input.Phone)
+	input.Address = strings.TrimSpace(input.Address)
+	input.BirthDate = strings.TrimSpace(input.BirthDate)
+	input.Gender = strings.ToUpper(strings.TrimSpace(input.Gender))
+	input.EmergencyContact = strings.TrimSpace(input.EmergencyContact)
+	input.EmergencyPhone = strings.TrimSpace(input.EmergencyPhone)
+
+	if input.Name == "" {
+		return nil, nil, &ValidationError{Field: "name", Message: "name is required"}
+	}
+	if input.BirthDate != "" {
+		if _, err := time.Parse("2006-01-02", input.BirthDate); err != nil {
+			return nil, nil, &ValidationError{Field: "birth_date", Message: "invalid birth_date (YYYY-MM-DD)"}
+		}
+	}
+
+	policies, err := s.repo.ProfilePolicies(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	current, err := repo.FindByID(userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+
+	open, err := repo.PendingProfileChanges(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	merged := map[string]FieldChange{}
+	for _, r := range open {
+		for _, c := range r.Changes {
+			merged[c.Field] = c
+		}
+	}
+
+	immediate := map[string]string{}
+	replaced := false
+	for key, v := range input.values() {
+		f := findProfileField(key)
+		old := f.get(current)
+		if old == v {
+			continue // form diisi nilai tersimpan: pending tetap
+		}
+		if policies[key] == PolicyApproval {
+			if c, ok := merged[key]; ok && c.New == v {
+				continue
+			}
+			merged[key] = FieldChange{Field: key, Old: old, New: v}
+			replaced = true
+		} else {
+			immediate[key] = v
+		}
+	}
+
+	u, err := repo.UpdateProfileFields(ctx, userID, immediate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Request lama diganti satu request berisi gabungan perubahan.
+	var req *ProfileChangeRequest
+	if replaced {
+		if err := repo.CancelPendingProfileChanges(ctx, userID); err != nil {
+			return nil, nil, err
+		}
+		pending := make([]FieldChange, 0, len(merged))
+		for _, c := range merged {
+			pending = append(pending, c)
+		}
+		sortFieldChanges(pending)
+		id, err := repo.CreateProfileChange(ctx, userID, pending)
+		if err != nil {
+			return nil, nil, err
+		}
+		if req, err = repo.FindProfileChange(ctx, id); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return u, req, nil
+}
+
+// sortFieldChanges orders changes like profileFields so the side-by-side
+// view is stable.
+func sortFieldChanges(changes []FieldChange) {
+	order := map[string]int{}
+	for i, f := range profileFields {
+		order[f.key] = i
+	}
+	for i := 1; i < len(changes); i++ {
+		for j := i; j > 0 && order[changes[j].Field] < order[changes[j-1].Field]; j-- {
+			changes[j], changes[j-1] = changes[j-1], changes[j]
+		}
+	}
+}
+
+// ProfilePolicies returns the policy of every self-service field.
+func (s *Service) ProfilePolicies(ctx context.Context) ([]ProfileFieldPolicy, error) {
+	modes, err := s.repo.ProfilePolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ProfileFieldPolicy, 0, len(profileFields))
+	for _, f := range profileFields {
+		mode := modes[f.key]
+		if mode == "" {
+			mode = PolicyImmediate
+		}
+		out = append(out, ProfileFieldPolicy{Field: f.key, Mode: mode})
+	}
+	return out, nil
+}
+
+// UpdateProfilePolicies changes the mode of the given fields.
+func (s *Service) UpdateProfilePolicies(ctx context.Context, policies []ProfileFieldPolicy) ([]ProfileFieldPolicy, error) {
+	for i := range policies {
+		p := &policies[i]
+		p.Field = strings.ToLower(strings.TrimSpace(p.Field))
+		p.Mode = strings.ToUpper(strings.TrimSpace(p.Mode))
+		if findProfileField(p.Field) == nil {
+			return nil, &ValidationError{Field: "field", Message: fmt.Sprintf("unknown profile field %q", p.Field)}
+		}
+		if p.Mode != PolicyImmediate && p.Mode != PolicyApproval {
+			return nil, &ValidationError{Field: "mode", Message: fmt.Sprintf("invalid mode %q", p.Mode)}
+		}
+	}
+	for _, p := range policies {
+		if err := s.repo.SetProfilePolicy(ctx, p.Field, p.Mode); err != nil {
+			return nil, err
+		}
+	}
+	return s.ProfilePolicies(ctx)
+}
+
+// ListProfileChanges lists requests for HRD review; status kosong = semua.
+func (s *Service) ListProfileChanges(ctx context.Context, status string) ([]ProfileChangeRequest, error) {
+	return s.repo.ListProfileChanges(ctx, strings.ToUpper(strings.TrimSpace(status)))
+}
+
+func (s *Service) ListMyProfileChanges(ctx context.Context, userID int64) ([]ProfileChangeRequest, error) {
+	return s.repo.ListProfileChangesByUser(ctx, userID)
+}
+
+// ReviewProfileChange approves (applying the new values) or rejects a
+// pending request.
+func (s *Service) ReviewProfileChange(ctx context.Context, reviewerID, id int64, approve bool, note string) (*ProfileChangeRequest, error) {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	if err := repo.LockProfileChange(ctx, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	req, err := repo.FindProfileChange(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status != "PENDING" {
+		return nil, ErrChangeNotPending
+	}
+	if req.UserID == reviewerID {
+		return nil, ErrReviewOwnChange
+	}
+
+	status := "REJECTED"
+	if approve {
+		status = "APPROVED"
+		values := map[string]string{}
+		for _, c := range req.Changes {
+			if findProfileField(c.Field) != nil {
+				values[c.Field] = c.New
+			}
+		}
+		if _, err := repo.UpdateProfileFields(ctx, req.UserID, values); err != nil {
+			return nil, err
+		}
+	}
+	if err := repo.SetProfileChangeStatus(ctx, id, status, &reviewerID, strings.TrimSpace(note)); err != nil {
+		return nil, err
+	}
+	if req, err = repo.FindProfileChange(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// CancelProfileChange lets the owner withdraw a pending request.
+func (s *Service) CancelProfileChange(ctx context.Context, userID, id int64) error {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	if err := repo.LockProfileChange(ctx, id); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		return err
+	}
+	req, err := repo.FindProfileChange(ctx, id)
+	if err != nil {
+		return err
+	}
+	// Request milik orang lain diperlakukan seperti tidak ada.
+	if req.UserID != userID {
+		return ErrNotFound
+	}
+	if req.Status != "PENDING" {
+		return ErrChangeNotPending
+	}
+	if err := repo.SetProfileChangeStatus(ctx, id, "CANCELLED", nil, ""); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -3,19 +3,41 @@ package user
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/lib/pq"
 )
 
+// dbtx is satisfied by both *sql.DB and *sql.Tx.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 // Repository membungkus akses ke tabel users.
 type Repository struct {
-	db *sql.DB
+	db   dbtx
+	conn *sql.DB
 }
 
 func NewRepository(db *sql.DB) *Repository {
-	return &Repository{db: db}
+	return &Repository{db: db, conn: db}
+}
+
+// BeginTx starts a transaction on the underlying connection pool.
+func (r *Repository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return r.conn.BeginTx(ctx, nil)
+}
+
+// WithTx returns a Repository whose queries all run inside tx.
+func (r *Repository) WithTx(tx *sql.Tx) *Repository {
+	return &Repository{db: tx, conn: r.conn}
 }
 
 // Kolom yang dipakai di semua SELECT.
@@ -38,7 +60,10 @@ const userSelectColumns = `
 	COALESCE(photo_url, ''),
 	join_date,
 	COALESCE(emergency_contact, ''),
-	COALESCE(emergency_phone, '')
+	COALESCE(emergency_phone, ''),
+	manager_id,
+	COALESCE(employment_status, ''),
+	COALESCE(custom_fields, '{}')
 `
 
 // helper untuk scan row menjadi struct User.
@@ -46,6 +71,8 @@ func scanUser(row interface{ Scan(dest ...any) error }) (*User, error) {
 	var u User
 	var roles []string
 	var birthDate, joinDate sql.NullTime
+	var managerID sql.NullInt64
+	var customFields []byte
 
 	err := row.Scan(
 		&u.ID,
@@ -66,10 +93,16 @@ func scanUser(row interface{ Scan(dest ...any) error }) (*User, error) {
 		&joinDate,
 		&u.EmergencyContact,
 		&u.EmergencyPhone,
+		&managerID,
+		&u.EmploymentStatus,
+		&customFields,
 	)
 	if err != nil {
 		return nil, err
 	}
+	if err := json.Unmarshal(customFields, &u.customFields); err != nil {
+		return nil, err
+	}
 	u.Roles = roles
 	if birthDate.Valid {
 		u.BirthDate = &birthDate.Time
@@ -77,6 +110,10 @@ func scanUser(row interface{ Scan(dest ...any) error }) (*User, error) {
 	if joinDate.Valid {
 		u.JoinDate = &joinDate.Time
 	}
+	if managerID.Valid {
+		mid := managerID.Int64
+		u.ManagerID = &mid
+	}
 	return &u, nil
 }
 
@@ -84,6 +121,11 @@ func scanUser(row interface{ Scan(dest ...any) error }) (*User, error) {
 // Query single
 // ==========================
 
+func (r *Repository) FindByCode(ctx context.Context, code string) (*User, error) {
+	q := `SELECT ` + userSelectColumns + ` FROM users WHERE employee_code = $1`
+	return scanUser(r.db.QueryRowContext(ctx, q, code))
+}
+
 func (r *Repository) FindByEmail(email string) (*User, error) {
 	q := `
 		SELECT ` + userSelectColumns + `
@@ -110,63 +152,124 @@ func (r *Repository) FindByID(id int64) (*User, error) {
 // Employees list (untuk page Employees)
 // ==========================
 
-func (r *Repository) ListEmployees(ctx context.Context, search string, limit, offset int) ([]User, error) {
-	search = strings.TrimSpace(search)
+// sortColumns whitelists sortable fields: SQL expression and the type used to
+// cast cursor values back for keyset comparison.
+var sortColumns = map[string]struct {
+	expr string
+	cast string
+}{
+	"employee_code": {"employee_code", "text"},
+	"name":          {"name", "text"},
+	"email":         {"email", "text"},
+	"department":    {"COALESCE(department, '')", "text"},
+	"branch":        {"COALESCE(branch, '')", "text"},
+	"job_title":     {"COALESCE(job_title, '')", "text"},
+	"status":        {"status", "text"},
+	"join_date":     {"COALESCE(join_date, DATE '0001-01-01')", "date"},
+	"id":            {"id", "bigint"},
+}
+
+// escapeLike escapes the LIKE wildcards in user input so "50%" or "a_b"
+// dicari apa adanya.
+var escapeLike = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace
 
-	// default paging kalau mau nanti diaktifkan
-	if limit <= 0 {
-		limit = 200
+// listWhere builds the WHERE clause (without cursor) for a filter.
+func listWhere(f ListFilter) (string, []any) {
+	where := []string{"TRUE"}
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, strings.ReplaceAll(cond, "?", "$"+strconv.Itoa(len(args))))
 	}
-	if offset < 0 {
-		offset = 0
+
+	if f.Search != "" {
+		add(`(
+			LOWER(name) LIKE ? ESCAPE '\'
+			OR LOWER(email) LIKE ? ESCAPE '\'
+			OR LOWER(employee_code) LIKE ? ESCAPE '\'
+			OR LOWER(COALESCE(branch, '')) LIKE ? ESCAPE '\'
+		)`, "%"+escapeLike(strings.ToLower(f.Search))+"%")
+	}
+	if f.Department != "" {
+		add("department = ?", f.Department)
+	}
+	if f.Branch != "" {
+		add("branch = ?", f.Branch)
+	}
+	if f.Status != "" {
+		add("status = ?", f.Status)
+	}
+	if f.Role != "" {
+		add("? = ANY(roles)", f.Role)
+	}
+	if f.JoinedFrom != nil {
+		add("join_date >= ?", *f.JoinedFrom)
 	}
+	if f.JoinedTo != nil {
+		add("join_date <= ?", *f.JoinedTo)
+	}
+	if len(f.customMatch) > 0 {
+		b, _ := json.Marshal(f.customMatch)
+		add("custom_fields @> ?::jsonb", string(b))
+	}
+	return strings.Join(where, " AND "), args
+}
 
-	var (
-		rows *sql.Rows
-		err  error
-	)
+// ListEmployees returns one page of employees plus the total matching count.
+// Kalau f.After di-set, pakai keyset pagination (lebih cepat untuk data besar),
+// selain itu pakai page/offset.
+func (r *Repository) ListEmployees(ctx context.Context, f ListFilter) ([]User, int, error) {
+	col, ok := sortColumns[f.Sort]
+	if !ok {
+		col = sortColumns["employee_code"]
+	}
+	dir, cmp := "ASC", ">"
+	if f.Desc {
+		dir, cmp = "DESC", "<"
+	}
 
-	if search == "" {
-		q := `
-			SELECT ` + userSelectColumns + `
-			FROM users
-			ORDER BY employee_code ASC, id ASC
-			LIMIT $1 OFFSET $2
-		`
-		rows, err = r.db.QueryContext(ctx, q, limit, offset)
-	} else {
-		like := "%" + strings.ToLower(search) + "%"
-		q := `
-			SELECT ` + userSelectColumns + `
-			FROM users
-			WHERE
-				LOWER(name) LIKE $1
-				OR LOWER(email) LIKE $1
-				OR LOWER(employee_code) LIKE $1
-				OR LOWER(COALESCE(branch, '')) LIKE $1
-			ORDER BY employee_code ASC, id ASC
-			LIMIT $2 OFFSET $3
-		`
-		rows, err = r.db.QueryContext(ctx, q, like, limit, offset)
+	where, args := listWhere(f)
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (f.Page - 1) * f.PageSize
+	if f.After != nil {
+		args = append(args, f.After.Value, f.After.ID)
+		n := len(args)
+		where += fmt.Sprintf(" AND (%s, id) %s (CAST($%d AS %s), $%d)", col.expr, cmp, n-1, col.cast, n)
+		offset = 0
 	}
+	args = append(args, f.PageSize, offset)
+	n := len(args)
+
+	q := `
+		SELECT ` + userSelectColumns + `
+		FROM users
+		WHERE ` + where + `
+		ORDER BY ` + col.expr + ` ` + dir + `, id ` + dir + `
+		LIMIT $` + strconv.Itoa(n-1) + ` OFFSET $` + strconv.Itoa(n)
 
+	rows, err := r.db.QueryContext(ctx, q, args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
-	var result []User
+	result := []User{}
 	for rows.Next() {
 		u, err := scanUser(rows)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		result = append(result, *u)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	return result, nil
+	return result, total, nil
 }
 
 // ==========================
@@ -184,11 +287,16 @@ func (r *Repository) CreateEmployee(ctx context.Context, u *User) (*User, error)
 			status,
 			department,
 			roles,
-			password_hash
+			password_hash,
+			custom_fields
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING ` + userSelectColumns + `
 	`
+	cf, err := customFieldsJSON(u)
+	if err != nil {
+		return nil, err
+	}
 	row := r.db.QueryRowContext(
 		ctx,
 		q,
@@ -201,10 +309,18 @@ func (r *Repository) CreateEmployee(ctx context.Context, u *User) (*User, error)
 		u.Department,
 		pq.Array(u.Roles),
 		u.PasswordHash,
+		cf,
 	)
 	return scanUser(row)
 }
 
+func customFieldsJSON(u *User) ([]byte, error) {
+	if u.customFields == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(u.customFields)
+}
+
 func (r *Repository) UpdateEmployee(ctx context.Context, u *User) (*User, error) {
 	q := `
 		UPDATE users
@@ -217,10 +333,15 @@ func (r *Repository) UpdateEmployee(ctx context.Context, u *User) (*User, error)
 			status        = $6,
 			department    = $7,
 			roles         = $8,
-			password_hash = COALESCE(NULLIF($9, ''), password_hash)
-		WHERE id = $10
+			password_hash = COALESCE(NULLIF($9, ''), password_hash),
+			custom_fields = $10
+		WHERE id = $11
 		RETURNING ` + userSelectColumns + `
 	`
+	cf, err := customFieldsJSON(u)
+	if err != nil {
+		return nil, err
+	}
 	row := r.db.QueryRowContext(
 		ctx,
 		q,
@@ -233,29 +354,174 @@ func (r *Repository) UpdateEmployee(ctx context.Context, u *User) (*User, error)
 		u.Department,
 		pq.Array(u.Roles),
 		u.PasswordHash,
+		cf,
 		u.ID,
 	)
 	return scanUser(row)
 }
 
-func (r *Repository) DeleteEmployee(ctx context.Context, id int64) error {
-	// Soft delete: mark as INACTIVE to avoid FK constraint issues
-	q := `UPDATE users SET status = 'INACTIVE' WHERE id = $1`
-	res, err := r.db.ExecContext(ctx, q, id)
+// NextCodeSequence atomically increments the employee-code sequence of
+// scope and returns the new value. A new scope starts after the highest
+// existing code matching codeRegex (capture group = number), so codes created
+// before the sequence existed are not reissued.
+func (r *Repository) NextCodeSequence(ctx context.Context, scope, codeRegex string) (int64, error) {
+	q := `
+		INSERT INTO employee_code_sequences (scope, last_value)
+		VALUES ($1, (
+			SELECT COALESCE(MAX(CAST(SUBSTRING(employee_code FROM $2) AS BIGINT)), 0) + 1
+			FROM users
+			WHERE employee_code ~ $2
+		))
+		ON CONFLICT (scope) DO UPDATE
+		SET last_value = employee_code_sequences.last_value + 1, updated_at = NOW()
+		RETURNING last_value
+	`
+	var n int64
+	err := r.db.QueryRowContext(ctx, q, scope, codeRegex).Scan(&n)
+	return n, err
+}
+
+// PeekCodeSequence returns the value NextCodeSequence would return, without
+// consuming it.
+func (r *Repository) PeekCodeSequence(ctx context.Context, scope, codeRegex string) (int64, error) {
+	q := `
+		SELECT COALESCE(
+			(SELECT last_value FROM employee_code_sequences WHERE scope = $1),
+			(SELECT COALESCE(MAX(CAST(SUBSTRING(employee_code FROM $2) AS BIGINT)), 0)
+			 FROM users WHERE employee_code ~ $2)
+		) + 1
+	`
+	var n int64
+	err := r.db.QueryRowContext(ctx, q, scope, codeRegex).Scan(&n)
+	return n, err
+}
+
+// GetDepartment returns only the department code for a user
+func (r *Repository) GetDepartment(ctx context.Context, userID int64) (string, error) {
+	var dept sql.NullString
+	err := r.db.QueryRowContext(ctx, "SELECT department FROM users WHERE id = $1", userID).Scan(&dept)
 	if err != nil {
-		return err
+		return "", err
 	}
-	affected, _ := res.RowsAffected()
-	if affected == 0 {
-		return sql.ErrNoRows
+	return dept.String, nil
+}
+
+// ==========================
+// Lookup untuk validasi import
+// ==========================
+
+// existing returns which of values already exist in column (email or employee_code).
+func (r *Repository) existing(ctx context.Context, column string, values []string) (map[string]bool, error) {
+	found := map[string]bool{}
+	if len(values) == 0 {
+		return found, nil
 	}
-	return nil
+	rows, err := r.db.QueryContext(ctx, `SELECT `+column+` FROM users WHERE `+column+` = ANY($1)`, pq.Array(values))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		found[v] = true
+	}
+	return found, rows.Err()
+}
+
+// ExistingEmails returns the subset of emails already registered.
+func (r *Repository) ExistingEmails(ctx context.Context, emails []string) (map[string]bool, error) {
+	return r.existing(ctx, "email", emails)
+}
+
+// ExistingCodes returns the subset of employee codes already in use.
+func (r *Repository) ExistingCodes(ctx context.Context, codes []string) (map[string]bool, error) {
+	return r.existing(ctx, "employee_code", codes)
+}
+
+// HiddenBirthdays returns which of ids opted out of showing their birthday
+// (celebration_preferences.hide_birthday).
+func (r *Repository) HiddenBirthdays(ctx context.Context, ids []int64) (map[int64]bool, error) {
+	hidden := map[int64]bool{}
+	if len(ids) == 0 {
+		return hidden, nil
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT user_id FROM celebration_preferences
+		WHERE user_id = ANY($1) AND hide_birthday
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		hidden[id] = true
+	}
+	return hidden, rows.Err()
+}
+
+// KnownRoles returns role codes from the roles table and RBAC mapping.
+func (r *Repository) KnownRoles(ctx context.Context) (map[string]bool, error) {
+	return r.stringSet(ctx, `
+		SELECT code FROM roles
+		UNION
+		SELECT DISTINCT role_code FROM role_permissions
+	`)
+}
+
+func (r *Repository) stringSet(ctx context.Context, q string) (map[string]bool, error) {
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	set := map[string]bool{}
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		set[v] = true
+	}
+	return set, rows.Err()
+}
+
+// ==========================
+// Reporting lines (manager_id)
+// ==========================
+
+// LockReportingLines serialises manager changes for the rest of the current
+// transaction so two concurrent updates cannot form a cycle together.
+func (r *Repository) LockReportingLines(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('users.manager_id'))`)
+	return err
+}
+
+// ChainContains reports whether target appears in the management chain
+// starting at (and including) start.
+func (r *Repository) ChainContains(ctx context.Context, start, target int64) (bool, error) {
+	var found bool
+	err := r.db.QueryRowContext(ctx, `
+		WITH RECURSIVE chain AS (
+			SELECT id, manager_id, 1 AS depth FROM users WHERE id = $1
+			UNION ALL
+			SELECT u.id, u.manager_id, c.depth + 1
+			FROM users u JOIN chain c ON u.id = c.manager_id
+			WHERE c.depth < 100
+		)
+		SELECT EXISTS(SELECT 1 FROM chain WHERE id = $2)
+	`, start, target).Scan(&found)
+	return found, err
 }
 
-// DeleteEmployeeByCode performs soft delete by employee code
-func (r *Repository) DeleteEmployeeByCode(ctx context.Context, code string) error {
-	q := `UPDATE users SET status = 'INACTIVE' WHERE employee_code = $1`
-	res, err := r.db.ExecContext(ctx, q, code)
+func (r *Repository) SetManager(ctx context.Context, id int64, managerID *int64) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET manager_id = $1 WHERE id = $2`, managerID, id)
 	if err != nil {
 		return err
 	}
@@ -266,108 +532,528 @@ func (r *Repository) DeleteEmployeeByCode(ctx context.Context, code string) erro
 	return nil
 }
 
-// HardDeleteEmployee permanently deletes a user by id
-func (r *Repository) HardDeleteEmployee(ctx context.Context, id int64) error {
-	q := `DELETE FROM users WHERE id = $1`
-	res, err := r.db.ExecContext(ctx, q, id)
+func (r *Repository) queryUsers(ctx context.Context, q string, args ...any) ([]User, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *u)
+	}
+	return result, rows.Err()
+}
+
+// ListDirectReports returns employees whose manager is managerID.
+func (r *Repository) ListDirectReports(ctx context.Context, managerID int64) ([]User, error) {
+	q := `SELECT ` + userSelectColumns + ` FROM users WHERE manager_id = $1 ORDER BY name, id`
+	return r.queryUsers(ctx, q, managerID)
+}
+
+// ListSubordinates returns every employee below managerID, at any depth.
+func (r *Repository) ListSubordinates(ctx context.Context, managerID int64) ([]User, error) {
+	q := `
+		WITH RECURSIVE sub AS (
+			SELECT id, 1 AS depth FROM users WHERE manager_id = $1
+			UNION ALL
+			SELECT u.id, s.depth + 1
+			FROM users u JOIN sub s ON u.manager_id = s.id
+			WHERE s.depth < 100
+		)
+		SELECT ` + userSelectColumns + `
+		FROM users
+		WHERE id IN (SELECT id FROM sub)
+		ORDER BY name, id
+	`
+	return r.queryUsers(ctx, q, managerID)
+}
+
+// ListManagementChain returns the managers above id, nearest first.
+func (r *Repository) ListManagementChain(ctx context.Context, id int64) ([]User, error) {
+	q := `
+		WITH RECURSIVE chain AS (
+			SELECT manager_id AS mid, 1 AS depth FROM users WHERE id = $1 AND manager_id IS NOT NULL
+			UNION ALL
+			SELECT u.manager_id, c.depth + 1
+			FROM users u JOIN chain c ON u.id = c.mid
+			WHERE u.manager_id IS NOT NULL AND c.depth < 100
+		)
+		SELECT ` + userSelectColumns + `
+		FROM users JOIN chain ON users.id = chain.mid
+		ORDER BY chain.depth
+	`
+	return r.queryUsers(ctx, q, id)
+}
+
+// ==========================
+// Employment history
+// ==========================
+
+const historySelectColumns = `
+	h.id, h.user_id,
+	COALESCE(h.department, ''), COALESCE(h.job_title, ''), COALESCE(h.branch, ''), COALESCE(h.status, ''),
+	h.effective_date, COALESCE(h.reason, ''), h.changed_by, COALESCE(a.name, ''),
+	h.applied_at, COALESCE(h.skipped_reason, ''), h.created_at
+`
+
+func scanHistory(row interface{ Scan(dest ...any) error }) (*EmploymentChange, error) {
+	var h EmploymentChange
+	var changedBy sql.NullInt64
+	var appliedAt sql.NullTime
+	err := row.Scan(
+		&h.ID, &h.UserID,
+		&h.Department, &h.JobTitle, &h.Branch, &h.Status,
+		&h.EffectiveDate, &h.Reason, &changedBy, &h.ChangedByName,
+		&appliedAt, &h.SkippedReason, &h.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if changedBy.Valid {
+		id := changedBy.Int64
+		h.ChangedBy = &id
+	}
+	if appliedAt.Valid {
+		h.AppliedAt = &appliedAt.Time
+	}
+	return &h, nil
+}
+
+func (r *Repository) queryHistory(ctx context.Context, where string, args ...any) ([]EmploymentChange, error) {
+	q := `
+		SELECT ` + historySelectColumns + `
+		FROM employment_history h
+		LEFT JOIN users a ON a.id = h.changed_by
+		WHERE ` + where + `
+		ORDER BY h.effective_date, h.id
+	`
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []EmploymentChange{}
+	for rows.Next() {
+		h, err := scanHistory(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *h)
+	}
+	return out, rows.Err()
+}
+
+// RevokeSessions invalidates every token issued until now.
+func (r *Repository) RevokeSessions(ctx context.Context, userID int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET tokens_valid_after = NOW() WHERE id = $1`, userID)
+	return err
+}
+
+// SessionActive reports whether the user is ACTIVE and issuedAt is not
+// before the last revocation. JWT iat hanya presisi detik, jadi
+// tokens_valid_after dibulatkan ke bawah.
+func (r *Repository) SessionActive(ctx context.Context, userID int64, issuedAt time.Time) (bool, error) {
+	var ok bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT status = 'ACTIVE'
+			AND (tokens_valid_after IS NULL OR $2 >= date_trunc('second', tokens_valid_after))
+		FROM users WHERE id = $1
+	`, userID, issuedAt).Scan(&ok)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return ok, err
+}
+
+// SetEmploymentStatus sets users.employment_status; empty clears it.
+func (r *Repository) SetEmploymentStatus(ctx context.Context, userID int64, status string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET employment_status = NULLIF($1, '') WHERE id = $2`, status, userID)
 	if err != nil {
 		return err
 	}
-	affected, _ := res.RowsAffected()
-	if affected == 0 {
+	if n, _ := res.RowsAffected(); n == 0 {
 		return sql.ErrNoRows
 	}
 	return nil
 }
 
-// HardDeleteEmployeeByCode permanently deletes a user by employee code
-func (r *Repository) HardDeleteEmployeeByCode(ctx context.Context, code string) error {
-	q := `DELETE FROM users WHERE employee_code = $1`
-	res, err := r.db.ExecContext(ctx, q, code)
+// InsertHistory stores h. applied=false schedules it for the history job.
+func (r *Repository) InsertHistory(ctx context.Context, h *EmploymentChange, applied bool) error {
+	q := `
+		INSERT INTO employment_history
+			(user_id, department, job_title, branch, status, effective_date, reason, changed_by, applied_at)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6, NULLIF($7, ''), $8,
+			CASE WHEN $9 THEN NOW() END)
+		RETURNING id, applied_at, created_at
+	`
+	var appliedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, q,
+		h.UserID, h.Department, h.JobTitle, h.Branch, h.Status,
+		h.EffectiveDate, h.Reason, h.ChangedBy, applied,
+	).Scan(&h.ID, &appliedAt, &h.CreatedAt)
 	if err != nil {
 		return err
 	}
-	affected, _ := res.RowsAffected()
-	if affected == 0 {
-		return sql.ErrNoRows
+	if appliedAt.Valid {
+		h.AppliedAt = &appliedAt.Time
 	}
 	return nil
 }
 
-// GetNextEmployeeCode generates the next employee code for a department.
-// Format: PREFIX + 3-digit number (e.g., IT001, ADM002)
-func (r *Repository) GetNextEmployeeCode(ctx context.Context, department string) (string, error) {
-	department = strings.ToUpper(strings.TrimSpace(department))
-	if department == "" {
-		department = "EMP" // default prefix
-	}
+// ListHistory returns all rows of a user, oldest first, including pending ones.
+func (r *Repository) ListHistory(ctx context.Context, userID int64) ([]EmploymentChange, error) {
+	return r.queryHistory(ctx, "h.user_id = $1", userID)
+}
+
+// HistoryUntil returns the rows of a user effective on or before date,
+// tanpa perubahan yang dilewati.
+func (r *Repository) HistoryUntil(ctx context.Context, userID int64, date time.Time) ([]EmploymentChange, error) {
+	return r.queryHistory(ctx, "h.user_id = $1 AND h.effective_date <= $2 AND h.skipped_reason IS NULL", userID, date)
+}
+
+// LastAppliedDate returns the latest effective date among applied rows of a
+// user; ok=false jika belum ada.
+func (r *Repository) LastAppliedDate(ctx context.Context, userID int64) (time.Time, bool, error) {
+	var d sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+		SELECT MAX(effective_date) FROM employment_history
+		WHERE user_id = $1 AND applied_at IS NOT NULL AND skipped_reason IS NULL
+	`, userID).Scan(&d)
+	return d.Time, d.Valid, err
+}
 
-	// Find the max number for this department prefix
+// ClaimDueChanges locks pending rows whose effective date has arrived.
+// Harus dipanggil di dalam transaksi (lihat WithTx).
+func (r *Repository) ClaimDueChanges(ctx context.Context, limit int) ([]EmploymentChange, error) {
 	q := `
-		SELECT COALESCE(MAX(
-			CAST(SUBSTRING(employee_code FROM LENGTH($1) + 1) AS INTEGER)
-		), 0)
-		FROM users
-		WHERE employee_code LIKE $1 || '%'
-		  AND employee_code ~ ('^' || $1 || '[0-9]+$')
+		SELECT ` + historySelectColumns + `
+		FROM employment_history h
+		LEFT JOIN users a ON a.id = h.changed_by
+		WHERE h.applied_at IS NULL AND h.skipped_reason IS NULL AND h.effective_date <= CURRENT_DATE
+		ORDER BY h.effective_date, h.id
+		LIMIT $1
+		FOR UPDATE OF h SKIP LOCKED
 	`
-
-	var maxNum int
-	err := r.db.QueryRowContext(ctx, q, department).Scan(&maxNum)
+	rows, err := r.db.QueryContext(ctx, q, limit)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	defer rows.Close()
 
-	// Generate next code with 3-digit padding
-	nextCode := department + fmt.Sprintf("%03d", maxNum+1)
-	return nextCode, nil
+	var out []EmploymentChange
+	for rows.Next() {
+		h, err := scanHistory(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *h)
+	}
+	return out, rows.Err()
 }
 
-// UpdateProfile updates user's own profile fields
-func (r *Repository) UpdateProfile(ctx context.Context, userID int64, input *ProfileInput) (*User, error) {
+// ApplyPosition writes the non-empty position fields of h to the user and
+// returns the updated user.
+func (r *Repository) ApplyPosition(ctx context.Context, h *EmploymentChange) (*User, error) {
 	q := `
 		UPDATE users
-		SET
-			name = $1,
-			phone = $2,
-			address = $3,
-			birth_date = $4,
-			gender = $5,
-			emergency_contact = $6,
-			emergency_phone = $7
-		WHERE id = $8
+		SET department = COALESCE(NULLIF($1, ''), department),
+			job_title  = COALESCE(NULLIF($2, ''), job_title),
+			branch     = COALESCE(NULLIF($3, ''), branch),
+			status     = COALESCE(NULLIF($4, ''), status)
+		WHERE id = $5
 		RETURNING ` + userSelectColumns + `
 	`
+	return scanUser(r.db.QueryRowContext(ctx, q, h.Department, h.JobTitle, h.Branch, h.Status, h.UserID))
+}
 
-	// Parse birth_date
-	var birthDate interface{}
-	if input.BirthDate != "" {
-		birthDate = input.BirthDate
-	} else {
-		birthDate = nil
+// MarkHistoryApplied fills a pending row with the resulting snapshot.
+func (r *Repository) MarkHistoryApplied(ctx context.Context, id int64, u *User) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE employment_history
+		SET department = NULLIF($1, ''), job_title = NULLIF($2, ''), branch = NULLIF($3, ''),
+			status = NULLIF($4, ''), applied_at = NOW()
+		WHERE id = $5
+	`, u.Department, u.JobTitle, u.Branch, u.Status, id)
+	return err
+}
+
+// SkipHistory marks a pending row as not applied, with the reason.
+func (r *Repository) SkipHistory(ctx context.Context, id int64, reason string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE employment_history SET skipped_reason = $1 WHERE id = $2`, reason, id)
+	return err
+}
+
+// SetPhotoURL sets (or clears, with "") users.photo_url.
+func (r *Repository) SetPhotoURL(ctx context.Context, userID int64, url string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET photo_url = NULLIF($1, '') WHERE id = $2`, url, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
 	}
+	return nil
+}
 
-	row := r.db.QueryRowContext(
-		ctx,
-		q,
-		input.Name,
-		input.Phone,
-		input.Address,
-		birthDate,
-		input.Gender,
-		input.EmergencyContact,
-		input.EmergencyPhone,
-		userID,
-	)
-	return scanUser(row)
+// ==========================
+// Profile changes
+// ==========================
+
+// UpdateProfileFields writes self-service profile fields (keys of
+// profileFields) and returns the updated user.
+func (r *Repository) UpdateProfileFields(ctx context.Context, userID int64, values map[string]string) (*User, error) {
+	var sets []string
+	var args []any
+	for _, f := range profileFields {
+		v, ok := values[f.key]
+		if !ok {
+			continue
+		}
+		args = append(args, v)
+		sets = append(sets, fmt.Sprintf("%s = %s", f.column, strings.ReplaceAll(f.expr, "?", "$"+strconv.Itoa(len(args)))))
+	}
+	if len(sets) == 0 {
+		return r.FindByID(userID)
+	}
+	args = append(args, userID)
+	q := `UPDATE users SET ` + strings.Join(sets, ", ") + ` WHERE id = $` + strconv.Itoa(len(args)) +
+		` RETURNING ` + userSelectColumns
+	return scanUser(r.db.QueryRowContext(ctx, q, args...))
 }
 
-// GetDepartment returns only the department code for a user
-func (r *Repository) GetDepartment(ctx context.Context, userID int64) (string, error) {
-	var dept sql.NullString
-	err := r.db.QueryRowContext(ctx, "SELECT department FROM users WHERE id = $1", userID).Scan(&dept)
+func (r *Repository) ProfilePolicies(ctx context.Context) (map[string]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT field, mode FROM profile_field_policies`)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return dept.String, nil
+	defer rows.Close()
+	out := map[string]string{}
+	for rows.Next() {
+		var field, mode string
+		if err := rows.Scan(&field, &mode); err != nil {
+			return nil, err
+		}
+		out[field] = mode
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) SetProfilePolicy(ctx context.Context, field, mode string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO profile_field_policies (field, mode) VALUES ($1, $2)
+		ON CONFLICT (field) DO UPDATE SET mode = EXCLUDED.mode
+	`, field, mode)
+	return err
+}
+
+const profileChangeSelect = `
+	SELECT p.id, p.user_id, u.name, u.employee_code, p.changes, p.status,
+		p.reviewed_by, COALESCE(rv.name, ''), COALESCE(p.review_note, ''), p.created_at, p.reviewed_at
+	FROM profile_change_requests p
+	JOIN users u ON u.id = p.user_id
+	LEFT JOIN users rv ON rv.id = p.reviewed_by
+`
+
+func scanProfileChange(row interface{ Scan(dest ...any) error }) (*ProfileChangeRequest, error) {
+	var p ProfileChangeRequest
+	var changes []byte
+	var reviewedBy sql.NullInt64
+	var reviewedAt sql.NullTime
+	err := row.Scan(&p.ID, &p.UserID, &p.UserName, &p.EmployeeCode, &changes, &p.Status,
+		&reviewedBy, &p.ReviewerName, &p.ReviewNote, &p.CreatedAt, &reviewedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(changes, &p.Changes); err != nil {
+		return nil, err
+	}
+	if reviewedBy.Valid {
+		id := reviewedBy.Int64
+		p.ReviewedBy = &id
+	}
+	if reviewedAt.Valid {
+		p.ReviewedAt = &reviewedAt.Time
+	}
+	return &p, nil
+}
+
+func (r *Repository) queryProfileChanges(ctx context.Context, where string, args ...any) ([]ProfileChangeRequest, error) {
+	rows, err := r.db.QueryContext(ctx, profileChangeSelect+` WHERE `+where+` ORDER BY p.created_at DESC, p.id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []ProfileChangeRequest{}
+	for rows.Next() {
+		p, err := scanProfileChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *p)
+	}
+	return out, rows.Err()
+}
+
+// CreateProfileChange stores a PENDING request and returns its id.
+func (r *Repository) CreateProfileChange(ctx context.Context, userID int64, changes []FieldChange) (int64, error) {
+	b, err := json.Marshal(changes)
+	if err != nil {
+		return 0, err
+	}
+	var id int64
+	err = r.db.QueryRowContext(ctx, `
+		INSERT INTO profile_change_requests (user_id, changes) VALUES ($1, $2) RETURNING id
+	`, userID, b).Scan(&id)
+	return id, err
+}
+
+// PendingProfileChanges locks and returns the user's open requests.
+func (r *Repository) PendingProfileChanges(ctx context.Context, userID int64) ([]ProfileChangeRequest, error) {
+	_, err := r.db.ExecContext(ctx, `
+		SELECT id FROM profile_change_requests WHERE user_id = $1 AND status = 'PENDING' FOR UPDATE
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return r.queryProfileChanges(ctx, "p.user_id = $1 AND p.status = 'PENDING'", userID)
+}
+
+// CancelPendingProfileChanges cancels the user's open requests; dipanggil
+// saat user mengirim perubahan baru yang menggantikannya.
+func (r *Repository) CancelPendingProfileChanges(ctx context.Context, userID int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE profile_change_requests SET status = 'CANCELLED', reviewed_at = NOW()
+		WHERE user_id = $1 AND status = 'PENDING'
+	`, userID)
+	return err
+}
+
+func (r *Repository) FindProfileChange(ctx context.Context, id int64) (*ProfileChangeRequest, error) {
+	return scanProfileChange(r.db.QueryRowContext(ctx, profileChangeSelect+` WHERE p.id = $1`, id))
+}
+
+// LockProfileChange locks the request row for the rest of the transaction.
+func (r *Repository) LockProfileChange(ctx context.Context, id int64) error {
+	var x int64
+	return r.db.QueryRowContext(ctx, `SELECT id FROM profile_change_requests WHERE id = $1 FOR UPDATE`, id).Scan(&x)
+}
+
+func (r *Repository) ListProfileChanges(ctx context.Context, status string) ([]ProfileChangeRequest, error) {
+	if status == "" {
+		return r.queryProfileChanges(ctx, "TRUE")
+	}
+	return r.queryProfileChanges(ctx, "p.status = $1", status)
+}
+
+func (r *Repository) ListProfileChangesByUser(ctx context.Context, userID int64) ([]ProfileChangeRequest, error) {
+	return r.queryProfileChanges(ctx, "p.user_id = $1", userID)
+}
+
+// SetProfileChangeStatus closes a request. reviewerID nil = dibatalkan pemilik.
+func (r *Repository) SetProfileChangeStatus(ctx context.Context, id int64, status string, reviewerID *int64, note string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE profile_change_requests
+		SET status = $1, reviewed_by = $2, review_note = NULLIF($3, ''), reviewed_at = NOW()
+		WHERE id = $4
+	`, status, reviewerID, note, id)
+	return err
+}
+
+// ==========================
+// Custom fields
+// ==========================
+
+const customFieldSelect = `
+	SELECT key, label, field_type, COALESCE(options, '{}'), COALESCE(pattern, ''), min_value, max_value,
+		required, COALESCE(department, ''), COALESCE(visible_roles, '{}'), sort_order, is_active
+	FROM custom_field_definitions
+`
+
+func scanCustomField(row interface{ Scan(dest ...any) error }) (*CustomField, error) {
+	var f CustomField
+	var minValue, maxValue sql.NullFloat64
+	err := row.Scan(&f.Key, &f.Label, &f.FieldType, pq.Array(&f.Options), &f.Pattern, &minValue, &maxValue,
+		&f.Required, &f.Department, pq.Array(&f.VisibleRoles), &f.SortOrder, &f.IsActive)
+	if err != nil {
+		return nil, err
+	}
+	if minValue.Valid {
+		f.MinValue = &minValue.Float64
+	}
+	if maxValue.Valid {
+		f.MaxValue = &maxValue.Float64
+	}
+	if f.VisibleRoles == nil {
+		f.VisibleRoles = []string{}
+	}
+	return &f, nil
+}
+
+func (r *Repository) ListCustomFields(ctx context.Context, includeInactive bool) ([]CustomField, error) {
+	rows, err := r.db.QueryContext(ctx, customFieldSelect+`
+		WHERE is_active OR $1
+		ORDER BY sort_order, key
+	`, includeInactive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []CustomField{}
+	for rows.Next() {
+		f, err := scanCustomField(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *f)
+	}
+	return out, rows.Err()
+}
+
+// FindCustomField returns nil when the key is not defined.
+func (r *Repository) FindCustomField(ctx context.Context, key string) (*CustomField, error) {
+	f, err := scanCustomField(r.db.QueryRowContext(ctx, customFieldSelect+` WHERE key = $1`, key))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return f, err
+}
+
+// SaveCustomField upserts f. active nil = aktif untuk field baru, tidak
+// berubah untuk field yang sudah ada.
+func (r *Repository) SaveCustomField(ctx context.Context, f *CustomField, active *bool) error {
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO custom_field_definitions
+			(key, label, field_type, options, pattern, min_value, max_value, required, department, visible_roles, sort_order, is_active)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, NULLIF($9, ''), $10, $11, COALESCE($12, TRUE))
+		ON CONFLICT (key) DO UPDATE SET
+			label = EXCLUDED.label,
+			options = EXCLUDED.options,
+			pattern = EXCLUDED.pattern,
+			min_value = EXCLUDED.min_value,
+			max_value = EXCLUDED.max_value,
+			required = EXCLUDED.required,
+			department = EXCLUDED.department,
+			visible_roles = EXCLUDED.visible_roles,
+			sort_order = EXCLUDED.sort_order,
+			is_active = COALESCE($12, custom_field_definitions.is_active)
+		RETURNING is_active
+	`, f.Key, f.Label, f.FieldType, pq.Array(f.Options), f.Pattern, f.MinValue, f.MaxValue,
+		f.Required, f.Department, pq.Array(f.VisibleRoles), f.SortOrder, active).Scan(&f.IsActive)
+}
+
+func (r *Repository) DeactivateCustomField(ctx context.Context, key string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE custom_field_definitions SET is_active = FALSE WHERE key = $1`, key)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
 }
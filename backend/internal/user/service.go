@@ -3,8 +3,13 @@ package user
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"hr-portal-backend/pkg/crypto"
 )
@@ -14,12 +19,31 @@ var (
 	ErrNotFound           = errors.New("user not found")
 )
 
+// Catalog is the department/branch/job title master data the employee
+// input is validated against. Dipenuhi oleh *masterdata.Repository.
+type Catalog interface {
+	DepartmentPrefix(ctx context.Context, code string) (prefix string, ok bool, err error)
+	BranchExists(ctx context.Context, name string) (bool, error)
+	JobTitleExists(ctx context.Context, title string) (bool, error)
+}
+
+// ValidationError is an invalid EmployeeInput field; handler mengembalikan 400.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string { return e.Field + ": " + e.Message }
+
 type Service struct {
-	repo *Repository
+	repo      *Repository
+	catalog   Catalog
+	codes     CodePattern
+	lifecycle Lifecycle
 }
 
-func NewService(r *Repository) *Service {
-	return &Service{repo: r}
+func NewService(r *Repository, catalog Catalog, codes CodePattern, lifecycle Lifecycle) *Service {
+	return &Service{repo: r, catalog: catalog, codes: codes, lifecycle: lifecycle}
 }
 
 // ==========================
@@ -38,6 +62,10 @@ func (s *Service) Authenticate(email, password string) (*User, error) {
 	if err := crypto.ComparePassword(u.PasswordHash, password); err != nil {
 		return nil, ErrInvalidCredentials
 	}
+	// Dicek setelah password supaya status akun tidak bocor ke orang lain.
+	if u.Status != StatusActive {
+		return nil, ErrAccountInactive
+	}
 	return u, nil
 }
 
@@ -62,10 +90,14 @@ type EmployeeInput struct {
 	Email        string   `json:"email"`
 	Branch       string   `json:"branch"`
 	JobTitle     string   `json:"job_title"`
-	Status       string   `json:"status"`     // ACTIVE / INACTIVE
+	Status       string   `json:"status"`     // ACTIVE / SUSPENDED / RESIGNED / TERMINATED
 	Department   string   `json:"department"` // ADM, IT, ACC, etc.
 	Roles        []string `json:"roles"`
 	Password     string   `json:"password"`
+	ChangeReason string   `json:"change_reason"` // dicatat di employment history
+	// CustomFields di-merge ke nilai yang ada; null menghapus. Tidak dikirim
+	// (nil) berarti tidak berubah.
+	CustomFields map[string]any `json:"custom_fields"`
 }
 
 func (in *EmployeeInput) sanitize() {
@@ -77,6 +109,7 @@ func (in *EmployeeInput) sanitize() {
 	in.Status = strings.ToUpper(strings.TrimSpace(in.Status))
 	in.Department = strings.ToUpper(strings.TrimSpace(in.Department))
 	in.Password = strings.TrimSpace(in.Password)
+	in.ChangeReason = strings.TrimSpace(in.ChangeReason)
 	for i := range in.Roles {
 		in.Roles[i] = strings.ToUpper(strings.TrimSpace(in.Roles[i]))
 	}
@@ -85,27 +118,235 @@ func (in *EmployeeInput) sanitize() {
 	}
 }
 
-func (s *Service) ListEmployees(ctx context.Context, search string) ([]User, error) {
-	return s.repo.ListEmployees(ctx, search, 0, 0)
+// catalogErrors checks department, branch and job title against the master
+// tables. Branch dan job title boleh kosong, department wajib. Nilai yang sama
+// dengan prev (data tersimpan) tidak dicek ulang, jadi karyawan di master
+// yang sudah nonaktif tetap bisa diedit selama field itu tidak diubah.
+func (s *Service) catalogErrors(ctx context.Context, in *EmployeeInput, prev *User) ([]ValidationError, error) {
+	var errs []ValidationError
+	if in.Department == "" {
+		errs = append(errs, ValidationError{Field: "department", Message: "department is required"})
+	} else if prev == nil || in.Department != prev.Department {
+		_, ok, err := s.catalog.DepartmentPrefix(ctx, in.Department)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			errs = append(errs, ValidationError{Field: "department", Message: fmt.Sprintf("unknown department %q", in.Department)})
+		}
+	}
+	if in.Branch != "" && (prev == nil || in.Branch != prev.Branch) {
+		ok, err := s.catalog.BranchExists(ctx, in.Branch)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			errs = append(errs, ValidationError{Field: "branch", Message: fmt.Sprintf("unknown branch %q", in.Branch)})
+		}
+	}
+	if in.JobTitle != "" && (prev == nil || in.JobTitle != prev.JobTitle) {
+		ok, err := s.catalog.JobTitleExists(ctx, in.JobTitle)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			errs = append(errs, ValidationError{Field: "job_title", Message: fmt.Sprintf("unknown job title %q", in.JobTitle)})
+		}
+	}
+	return errs, nil
+}
+
+// validateInput returns the first status or catalog problem of in as
+// *ValidationError. prev adalah data tersimpan (nil untuk karyawan baru).
+func (s *Service) validateInput(ctx context.Context, in *EmployeeInput, prev *User) error {
+	if !validStatus(in.Status) {
+		return &ValidationError{Field: "status", Message: fmt.Sprintf("invalid status %q", in.Status)}
+	}
+	errs, err := s.catalogErrors(ctx, in, prev)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return &errs[0]
+	}
+	return nil
 }
 
-func (s *Service) CreateEmployee(ctx context.Context, in EmployeeInput) (*User, error) {
-	in.sanitize()
+const (
+	defaultPageSize = 50
+	maxPageSize     = 1000
+)
 
-	// Tentukan roles default / otomatis untuk IT & HR
-	var roles []string
-	if len(in.Roles) > 0 {
-		roles = in.Roles
-	} else {
-		switch in.Department {
-		case "IT":
-			roles = []string{"ADMIN", "IT"}
-		case "HR":
-			roles = []string{"ADMIN", "HRD"}
-		default:
-			roles = []string{"EMPLOYEE"}
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor and DecodeCursor turn a ListCursor into an opaque string.
+func EncodeCursor(c ListCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (*ListCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c ListCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// checkCursor rejects a cursor made for another sort order, or whose value
+// cannot be cast to the sort column (cursor buatan tangan).
+func checkCursor(c *ListCursor, sort string, desc bool) error {
+	if c.Sort != sort || c.Desc != desc {
+		return &ValidationError{Field: "cursor", Message: "cursor does not match the current sort, start from the first page"}
+	}
+	var err error
+	switch sortColumns[sort].cast {
+	case "date":
+		_, err = time.Parse("2006-01-02", c.Value)
+	case "bigint":
+		_, err = strconv.ParseInt(c.Value, 10, 64)
+	}
+	if err != nil {
+		return &ValidationError{Field: "cursor", Message: "invalid cursor"}
+	}
+	return nil
+}
+
+// sortValue returns the value of the sort field of u, in the same form the
+// cursor comparison in ListEmployees expects.
+func sortValue(u *User, field string) string {
+	switch field {
+	case "name":
+		return u.Name
+	case "email":
+		return u.Email
+	case "department":
+		return u.Department
+	case "branch":
+		return u.Branch
+	case "job_title":
+		return u.JobTitle
+	case "status":
+		return u.Status
+	case "join_date":
+		if u.JoinDate == nil {
+			return "0001-01-01"
+		}
+		return u.JoinDate.Format("2006-01-02")
+	case "id":
+		return strconv.FormatInt(u.ID, 10)
+	}
+	return u.EmployeeCode
+}
+
+// defaultRoles menentukan roles default / otomatis untuk IT & HR.
+func defaultRoles(department string) []string {
+	switch department {
+	case "IT":
+		return []string{"ADMIN", "IT"}
+	case "HR":
+		return []string{"ADMIN", "HRD"}
+	default:
+		return []string{"EMPLOYEE"}
+	}
+}
+
+func (s *Service) ListEmployees(ctx context.Context, f ListFilter) (*EmployeePage, error) {
+	f.Search = strings.TrimSpace(f.Search)
+	f.Department = strings.ToUpper(strings.TrimSpace(f.Department))
+	f.Branch = strings.TrimSpace(f.Branch)
+	f.Status = strings.ToUpper(strings.TrimSpace(f.Status))
+	f.Role = strings.ToUpper(strings.TrimSpace(f.Role))
+	var err error
+	if f.customMatch, err = s.customFilter(ctx, f.ViewerRoles, f.CustomFields); err != nil {
+		return nil, err
+	}
+	if _, ok := sortColumns[f.Sort]; !ok {
+		f.Sort = "employee_code"
+	}
+	if f.After != nil {
+		if err := checkCursor(f.After, f.Sort, f.Desc); err != nil {
+			return nil, err
+		}
+	}
+	if f.PageSize <= 0 {
+		f.PageSize = defaultPageSize
+	}
+	if f.PageSize > maxPageSize {
+		f.PageSize = maxPageSize
+	}
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+
+	items, total, err := s.repo.ListEmployees(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	ptrs := make([]*User, len(items))
+	for i := range items {
+		ptrs[i] = &items[i]
+	}
+	if err := s.ShowCustomFields(ctx, f.ViewerRoles, ptrs...); err != nil {
+		return nil, err
+	}
+
+	page := &EmployeePage{
+		Items:    items,
+		Total:    total,
+		Page:     f.Page,
+		PageSize: f.PageSize,
+	}
+	if f.After != nil {
+		page.Page = 0 // tidak relevan saat pakai cursor
+	}
+	if len(items) == f.PageSize {
+		last := &items[len(items)-1]
+		page.NextCursor = EncodeCursor(ListCursor{Sort: f.Sort, Desc: f.Desc, Value: sortValue(last, f.Sort), ID: last.ID})
+	}
+	return page, nil
+}
+
+// HideBirthDates clears birth_date of employees who opted out, kecuali milik
+// viewer sendiri. Dipanggil untuk caller tanpa MANAGE_EMPLOYEES.
+func (s *Service) HideBirthDates(ctx context.Context, viewerID int64, users []User) error {
+	ids := make([]int64, 0, len(users))
+	for _, u := range users {
+		if u.BirthDate != nil && u.ID != viewerID {
+			ids = append(ids, u.ID)
+		}
+	}
+	hidden, err := s.repo.HiddenBirthdays(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range users {
+		if hidden[users[i].ID] {
+			users[i].BirthDate = nil
 		}
 	}
+	return nil
+}
+
+// CreateEmployee inserts the employee and its initial employment history row.
+func (s *Service) CreateEmployee(ctx context.Context, actorID int64, in EmployeeInput) (*User, error) {
+	in.sanitize()
+	if err := s.validateInput(ctx, &in, nil); err != nil {
+		return nil, err
+	}
+	customFields, err := s.mergeCustomFields(ctx, in.Department, nil, in.CustomFields)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := in.Roles
+	if len(roles) == 0 {
+		roles = defaultRoles(in.Department)
+	}
 
 	// Hash password: pakai input jika ada, kalau tidak default
 	pwd := in.Password
@@ -127,21 +368,77 @@ func (s *Service) CreateEmployee(ctx context.Context, in EmployeeInput) (*User,
 		Department:   in.Department,
 		Roles:        roles,
 		PasswordHash: hash,
+		customFields: customFields,
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	// Kode kosong = dibuat server, di transaksi yang sama dengan INSERT.
+	if u.EmployeeCode == "" {
+		if u.EmployeeCode, err = s.generateCode(ctx, repo, in.Department, nil); err != nil {
+			return nil, err
+		}
 	}
 
-	return s.repo.CreateEmployee(ctx, u)
+	created, err := repo.CreateEmployee(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordHire(ctx, repo, created, "Hired", &actorID); err != nil {
+		return nil, err
+	}
+	if err := s.statusTransition(ctx, tx, "", created); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return created, nil
 }
 
-func (s *Service) UpdateEmployee(ctx context.Context, id int64, in EmployeeInput) (*User, error) {
+// UpdateEmployee saves the employee. Perubahan department, job title, branch
+// atau status dicatat ke employment_history dengan tanggal efektif hari ini;
+// gunakan ScheduleChange untuk perubahan dengan tanggal efektif lain.
+// Status kosong = tidak berubah; perubahan status wajib disertai
+// change_reason, sama seperti ChangeStatus.
+func (s *Service) UpdateEmployee(ctx context.Context, actorID, id int64, in EmployeeInput) (*User, error) {
+	keepStatus := strings.TrimSpace(in.Status) == ""
 	in.sanitize()
 
-	existing, err := s.repo.FindByID(id)
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	repo := s.repo.WithTx(tx)
+
+	existing, err := repo.FindByID(id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrNotFound
 		}
 		return nil, err
 	}
+	before := *existing
+	if keepStatus {
+		in.Status = before.Status
+	}
+	if err := s.validateInput(ctx, &in, &before); err != nil {
+		return nil, err
+	}
+	if in.Status != before.Status {
+		if !canTransition(before.Status, in.Status) {
+			return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, before.Status, in.Status)
+		}
+		if in.ChangeReason == "" {
+			return nil, &ValidationError{Field: "change_reason", Message: "change_reason is required when changing status"}
+		}
+	}
 
 	existing.EmployeeCode = in.EmployeeCode
 	existing.Name = in.Name
@@ -153,6 +450,11 @@ func (s *Service) UpdateEmployee(ctx context.Context, id int64, in EmployeeInput
 	if len(in.Roles) > 0 {
 		existing.Roles = in.Roles
 	}
+	if in.CustomFields != nil {
+		if existing.customFields, err = s.mergeCustomFields(ctx, in.Department, existing.customFields, in.CustomFields); err != nil {
+			return nil, err
+		}
+	}
 	if in.Password != "" {
 		hash, err := crypto.HashPassword(in.Password)
 		if err != nil {
@@ -161,53 +463,52 @@ func (s *Service) UpdateEmployee(ctx context.Context, id int64, in EmployeeInput
 		existing.PasswordHash = hash
 	}
 
-	return s.repo.UpdateEmployee(ctx, existing)
-}
-
-// GetNextEmployeeCode returns the next available employee code for a department.
-func (s *Service) GetNextEmployeeCode(ctx context.Context, department string) (string, error) {
-	return s.repo.GetNextEmployeeCode(ctx, department)
-}
-
-func (s *Service) DeleteEmployee(ctx context.Context, id int64) error {
-	return s.repo.DeleteEmployee(ctx, id)
-}
-
-func (s *Service) DeleteEmployeeByCode(ctx context.Context, code string) error {
-	code = strings.ToUpper(strings.TrimSpace(code))
-	if code == "" {
-		return errors.New("invalid employee code")
+	updated, err := repo.UpdateEmployee(ctx, existing)
+	if err != nil {
+		return nil, err
 	}
-	return s.repo.DeleteEmployeeByCode(ctx, code)
-}
-
-// HardDeleteEmployee permanently deletes a user by id
-func (s *Service) HardDeleteEmployee(ctx context.Context, id int64) error {
-	return s.repo.HardDeleteEmployee(ctx, id)
+	if positionChanged(&before, updated) {
+		reason := in.ChangeReason
+		if reason == "" {
+			reason = "Employee data updated"
+		}
+		if err := repo.InsertHistory(ctx, snapshot(updated, today(), reason, &actorID), true); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.statusTransition(ctx, tx, before.Status, updated); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return updated, nil
 }
 
-// HardDeleteEmployeeByCode permanently deletes a user by employee code
-func (s *Service) HardDeleteEmployeeByCode(ctx context.Context, code string) error {
-	code = strings.ToUpper(strings.TrimSpace(code))
-	if code == "" {
-		return errors.New("invalid employee code")
+// codePrefix looks up the employee-code prefix of a department.
+func (s *Service) codePrefix(ctx context.Context, department string) (string, error) {
+	department = strings.ToUpper(strings.TrimSpace(department))
+	prefix, ok, err := s.catalog.DepartmentPrefix(ctx, department)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", &ValidationError{Field: "department", Message: fmt.Sprintf("unknown department %q", department)}
 	}
-	return s.repo.HardDeleteEmployeeByCode(ctx, code)
+	return prefix, nil
 }
 
-// UpdateProfile updates the current user's own profile
-func (s *Service) UpdateProfile(ctx context.Context, userID int64, input *ProfileInput) (*User, error) {
-	// Sanitize input
-	input.Name = strings.TrimSpace(input.Name)
-	input.Phone = strings.TrimSpace(input.Phone)
-	input.Address = strings.TrimSpace(input.Address)
-	input.Gender = strings.ToUpper(strings.TrimSpace(input.Gender))
-	input.EmergencyContact = strings.TrimSpace(input.EmergencyContact)
-	input.EmergencyPhone = strings.TrimSpace(input.EmergencyPhone)
-
-	if input.Name == "" {
-		return nil, errors.New("name is required")
+// GetNextEmployeeCode previews the code the next employee of a department
+// would get. Nomor tidak dipesan; kode final ditentukan saat CreateEmployee.
+func (s *Service) GetNextEmployeeCode(ctx context.Context, department string) (string, error) {
+	prefix, err := s.codePrefix(ctx, department)
+	if err != nil {
+		return "", err
 	}
-
-	return s.repo.UpdateProfile(ctx, userID, input)
+	scope := s.codes.scope(prefix, time.Now())
+	n, err := s.repo.PeekCodeSequence(ctx, scope.key(), scope.regex())
+	if err != nil {
+		return "", err
+	}
+	return scope.format(n), nil
 }
@@ -0,0 +1,109 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrUnknownKey        = errors.New("crypto: unknown encryption key id")
+	ErrInvalidCiphertext = errors.New("crypto: invalid ciphertext")
+)
+
+// Keyring holds the AES-256 keys used for field-level encryption. Data baru
+// selalu dienkripsi dengan key aktif; key lama tetap disimpan supaya data
+// lama masih bisa dibaca sampai di-rotate.
+type Keyring struct {
+	keys   map[string]cipher.AEAD
+	active string
+}
+
+// ParseKeyring reads keys in the form "id1:base64key,id2:base64key". Setiap
+// key harus 32 byte (AES-256). active kosong = key terakhir di daftar.
+func ParseKeyring(spec, active string) (*Keyring, error) {
+	k := &Keyring{keys: map[string]cipher.AEAD{}}
+	last := ""
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, b64, ok := strings.Cut(part, ":")
+		id = strings.TrimSpace(id)
+		if !ok || id == "" {
+			return nil, fmt.Errorf("crypto: key %q must be in id:base64 form", part)
+		}
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(b64))
+		if err != nil {
+			return nil, fmt.Errorf("crypto: key %s: %w", id, err)
+		}
+		if len(raw) != 32 {
+			return nil, fmt.Errorf("crypto: key %s must be 32 bytes, got %d", id, len(raw))
+		}
+		if _, dup := k.keys[id]; dup {
+			return nil, fmt.Errorf("crypto: duplicate key id %s", id)
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, err
+		}
+		if k.keys[id], err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+		last = id
+	}
+	if len(k.keys) == 0 {
+		return nil, errors.New("crypto: no encryption keys configured")
+	}
+	k.active = strings.TrimSpace(active)
+	if k.active == "" {
+		k.active = last
+	}
+	if _, ok := k.keys[k.active]; !ok {
+		return nil, fmt.Errorf("crypto: active key %s is not configured", k.active)
+	}
+	return k, nil
+}
+
+// ActiveKey returns the id of the key used for new ciphertexts.
+func (k *Keyring) ActiveKey() string { return k.active }
+
+// Encrypt seals plaintext with the active key. aad mengikat ciphertext ke
+// konteksnya (mis. field + user id) supaya tidak bisa dipindah ke row lain.
+// Hasil: "<key id>:<base64(nonce||ciphertext)>".
+func (k *Keyring) Encrypt(plaintext, aad string) (string, error) {
+	aead := k.keys[k.active]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(aad))
+	return k.active + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt with whichever key wrote it.
+func (k *Keyring) Decrypt(ciphertext, aad string) (string, error) {
+	id, b64, ok := strings.Cut(ciphertext, ":")
+	if !ok {
+		return "", ErrInvalidCiphertext
+	}
+	aead, found := k.keys[id]
+	if !found {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	raw, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil || len(raw) < aead.NonceSize() {
+		return "", ErrInvalidCiphertext
+	}
+	nonce, sealed := raw[:aead.NonceSize()], raw[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, sealed, []byte(aad))
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	return string(plain), nil
+}
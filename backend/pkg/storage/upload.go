@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidFile = errors.New("invalid file")
+	ErrTooLarge    = errors.New("file too large")
+)
+
+// FileType is an allowed upload: MIME disimpan apa adanya, Sniff adalah
+// prefix yang harus cocok dengan hasil http.DetectContentType.
+type FileType struct {
+	MIME  string
+	Sniff string
+}
+
+// knownTypes lists every extension an upload endpoint may allow.
+// docx/xlsx terdeteksi sebagai zip container.
+var knownTypes = map[string]FileType{
+	".pdf":  {"application/pdf", "application/pdf"},
+	".jpg":  {"image/jpeg", "image/jpeg"},
+	".jpeg": {"image/jpeg", "image/jpeg"},
+	".png":  {"image/png", "image/png"},
+	".docx": {"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "application/zip"},
+	".xlsx": {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "application/zip"},
+}
+
+// Types returns the allow-list for the given extensions (mis. ".pdf").
+func Types(exts ...string) map[string]FileType {
+	out := make(map[string]FileType, len(exts))
+	for _, ext := range exts {
+		t, ok := knownTypes[ext]
+		if !ok {
+			panic("storage: unknown file type " + ext)
+		}
+		out[ext] = t
+	}
+	return out
+}
+
+// Upload describes a file stored by PutUpload.
+type Upload struct {
+	FileName  string
+	MimeType  string
+	SizeBytes int64
+	Checksum  string // sha256 hex
+	Key       string
+}
+
+// PutUpload validates fh (extension + sniffed content) against allowed and
+// streams it to store under prefix/yyyy/mm/<random><ext> while computing the
+// checksum. Ukuran dicek lagi saat streaming karena fh.Size dari klien.
+func PutUpload(ctx context.Context, store Storage, prefix string, fh *multipart.FileHeader, allowed map[string]FileType, maxBytes int64) (*Upload, error) {
+	if fh == nil {
+		return nil, fmt.Errorf("%w: file is required", ErrInvalidFile)
+	}
+	if fh.Size > maxBytes {
+		return nil, ErrTooLarge
+	}
+	ext := strings.ToLower(filepath.Ext(fh.Filename))
+	t, ok := allowed[ext]
+	if !ok {
+		return nil, fmt.Errorf("%w: file type %q is not allowed", ErrInvalidFile, ext)
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("%w: empty file", ErrInvalidFile)
+	}
+	if !strings.HasPrefix(http.DetectContentType(head[:n]), t.Sniff) {
+		return nil, fmt.Errorf("%w: content does not match %s", ErrInvalidFile, ext)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	key, err := newKey(prefix, ext)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	counter := &countingReader{r: io.LimitReader(f, maxBytes+1)}
+	if err := store.Put(ctx, key, io.TeeReader(counter, hash)); err != nil {
+		return nil, err
+	}
+	if counter.n > maxBytes {
+		_ = store.Delete(ctx, key)
+		return nil, ErrTooLarge
+	}
+	return &Upload{
+		FileName:  filepath.Base(fh.Filename),
+		MimeType:  t.MIME,
+		SizeBytes: counter.n,
+		Checksum:  hex.EncodeToString(hash.Sum(nil)),
+		Key:       key,
+	}, nil
+}
+
+// newKey returns e.g. attachments/2025/01/3f9a...c1.pdf
+func newKey(prefix, ext string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s/%s%s", prefix, time.Now().UTC().Format("2006/01"), hex.EncodeToString(b), ext), nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -79,6 +79,7 @@ INSERT INTO permissions (code, name, description, module) VALUES
     
     -- Inbox
     ('VIEW_INBOX', 'View Inbox', 'Lihat pesan masuk', 'inbox'),
+    ('SEND_BROADCAST', 'Send Broadcast', 'Kirim pesan ke grup/departemen/cabang/role', 'inbox'),
     
     -- Self Service
     ('REQUEST_LEAVE', 'Request Leave', 'Ajukan cuti', 'self_service'),
@@ -91,14 +92,19 @@ INSERT INTO permissions (code, name, description, module) VALUES
     -- Employee Management (HRD/Admin)
     ('VIEW_EMPLOYEES', 'View Employees', 'Lihat daftar karyawan', 'employees'),
     ('MANAGE_EMPLOYEES', 'Manage Employees', 'Tambah/edit/hapus karyawan', 'employees'),
+    ('ERASE_EMPLOYEES', 'Erase Employees', 'Hapus permanen / anonimkan data karyawan', 'employees'),
+    ('UNMASK_SENSITIVE_DATA', 'Unmask Sensitive Data', 'Lihat rekening bank, NPWP & NIK tanpa masking', 'employees'),
     
     -- Approvals (HRD)
     ('APPROVE_LEAVE', 'Approve Leave', 'Approve/reject cuti', 'approvals'),
     ('APPROVE_OVERTIME', 'Approve Overtime', 'Approve/reject lembur', 'approvals'),
+    ('APPROVE_PROFILE_CHANGES', 'Approve Profile Changes', 'Review perubahan data profil karyawan', 'approvals'),
     
     -- Admin
     ('MANAGE_PERMISSIONS', 'Manage Permissions', 'Kelola permission roles', 'admin'),
     ('MANAGE_USERS', 'Manage Users', 'Kelola user accounts', 'admin'),
+    ('VIEW_AUDIT_LOG', 'View Audit Log', 'Lihat audit log', 'admin'),
+    ('MANAGE_ENCRYPTION_KEYS', 'Manage Encryption Keys', 'Lihat status & jalankan rotasi key enkripsi', 'admin'),
     ('VIEW_REPORTS', 'View Reports', 'Lihat laporan', 'reports'),
     
     -- Announcements
@@ -136,7 +142,10 @@ INSERT INTO role_permissions (role_code, permission_code) VALUES
     ('HRD', 'MANAGE_EMPLOYEES'),
     ('HRD', 'APPROVE_LEAVE'),
     ('HRD', 'APPROVE_OVERTIME'),
+    ('HRD', 'APPROVE_PROFILE_CHANGES'),
+    ('HRD', 'UNMASK_SENSITIVE_DATA'),
     ('HRD', 'CREATE_ANNOUNCEMENTS'),
+    ('HRD', 'SEND_BROADCAST'),
     ('HRD', 'VIEW_REPORTS')
 ON CONFLICT DO NOTHING;
 
@@ -154,10 +163,15 @@ INSERT INTO role_permissions (role_code, permission_code) VALUES
     ('IT_ADMIN', 'MANAGE_EMPLOYEES'),
     ('IT_ADMIN', 'APPROVE_LEAVE'),
     ('IT_ADMIN', 'APPROVE_OVERTIME'),
+    ('IT_ADMIN', 'APPROVE_PROFILE_CHANGES'),
     ('IT_ADMIN', 'CREATE_ANNOUNCEMENTS'),
+    ('IT_ADMIN', 'SEND_BROADCAST'),
     ('IT_ADMIN', 'VIEW_REPORTS'),
     ('IT_ADMIN', 'MANAGE_PERMISSIONS'),
-    ('IT_ADMIN', 'MANAGE_USERS')
+    ('IT_ADMIN', 'MANAGE_USERS'),
+    ('IT_ADMIN', 'ERASE_EMPLOYEES'),
+    ('IT_ADMIN', 'VIEW_AUDIT_LOG'),
+    ('IT_ADMIN', 'MANAGE_ENCRYPTION_KEYS')
 ON CONFLICT DO NOTHING;
 
 -- =============================================
@@ -244,3 +258,631 @@ CREATE TABLE IF NOT EXISTS attendance (
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     UNIQUE (user_id, date)
 );
+
+-- =============================================
+-- Mail: transactional outbox & notification preferences
+-- =============================================
+CREATE TABLE IF NOT EXISTS mail_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event VARCHAR(50) NOT NULL,
+    user_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    to_address VARCHAR(200) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    text_body TEXT,
+    html_body TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING', -- PENDING, SENT, FAILED
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_mail_outbox_due ON mail_outbox (status, next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+    event VARCHAR(50) NOT NULL,
+    email_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    PRIMARY KEY (user_id, event)
+);
+
+-- =============================================
+-- Messaging: broadcasts (satu pesan ke banyak penerima)
+-- =============================================
+CREATE TABLE IF NOT EXISTS broadcasts (
+    id BIGSERIAL PRIMARY KEY,
+    sender_id BIGINT REFERENCES users(id),
+    subject VARCHAR(200),
+    body TEXT,
+    target_user_ids BIGINT[] DEFAULT '{}',
+    target_department VARCHAR(10),
+    target_branch VARCHAR(50),
+    target_role VARCHAR(50),
+    recipient_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS broadcast_id BIGINT REFERENCES broadcasts(id);
+CREATE INDEX IF NOT EXISTS idx_messages_broadcast ON messages (broadcast_id);
+
+-- Penerima broadcast saat dikirim; tetap ada walau pesan dihapus dari inbox,
+-- jadi statistik delivered/read tidak berkurang.
+CREATE TABLE IF NOT EXISTS broadcast_recipients (
+    broadcast_id BIGINT NOT NULL REFERENCES broadcasts(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    read_at TIMESTAMP,
+    PRIMARY KEY (broadcast_id, user_id)
+);
+
+INSERT INTO broadcast_recipients (broadcast_id, user_id, read_at)
+SELECT broadcast_id, receiver_id, CASE WHEN is_read THEN created_at END
+FROM messages
+WHERE broadcast_id IS NOT NULL AND receiver_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+-- =============================================
+-- Attachments (file untuk message, request, announcement)
+-- =============================================
+CREATE TABLE IF NOT EXISTS attachments (
+    id BIGSERIAL PRIMARY KEY,
+    owner_type VARCHAR(20) NOT NULL, -- MESSAGE, REQUEST, ANNOUNCEMENT
+    owner_id BIGINT NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    mime_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    checksum_sha256 CHAR(64) NOT NULL,
+    storage_key TEXT NOT NULL,
+    uploaded_by BIGINT REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_owner ON attachments (owner_type, owner_id);
+
+-- =============================================
+-- Inbox: archive/star flags & full-text search
+-- =============================================
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS is_archived BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS is_starred BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('simple', COALESCE(subject, '') || ' ' || COALESCE(body, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_messages_search ON messages USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_messages_inbox ON messages (receiver_id, is_archived, id DESC);
+
+-- =============================================
+-- Users: kolom profil & index untuk list/filter employees
+-- =============================================
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone VARCHAR(30);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS address TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS birth_date DATE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS gender VARCHAR(10);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS photo_url TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS join_date DATE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS emergency_contact VARCHAR(100);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS emergency_phone VARCHAR(30);
+
+CREATE INDEX IF NOT EXISTS idx_users_department ON users (department);
+CREATE INDEX IF NOT EXISTS idx_users_branch ON users (branch);
+CREATE INDEX IF NOT EXISTS idx_users_status ON users (status);
+CREATE INDEX IF NOT EXISTS idx_users_join_date ON users (join_date);
+CREATE INDEX IF NOT EXISTS idx_users_roles ON users USING GIN (roles);
+
+-- =============================================
+-- Reporting lines
+-- =============================================
+ALTER TABLE users ADD COLUMN IF NOT EXISTS manager_id BIGINT REFERENCES users(id) ON DELETE SET NULL;
+-- PROBATION / CONFIRMED, di-update dari hasil evaluasi masa percobaan
+ALTER TABLE users ADD COLUMN IF NOT EXISTS employment_status VARCHAR(20);
+CREATE INDEX IF NOT EXISTS idx_users_manager ON users (manager_id);
+
+-- =============================================
+-- Master data: departments, branches, job titles
+-- =============================================
+CREATE TABLE IF NOT EXISTS departments (
+    code VARCHAR(10) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    head_user_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    code_prefix VARCHAR(10) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS branches (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(50) UNIQUE NOT NULL,
+    address TEXT,
+    time_zone VARCHAR(50) NOT NULL DEFAULT 'Asia/Jakarta',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS job_titles (
+    id BIGSERIAL PRIMARY KEY,
+    title VARCHAR(100) UNIQUE NOT NULL,
+    grade VARCHAR(20),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Migrasi nilai free-text lama: rapikan spasi/huruf besar, lalu isi master
+-- table dari nilai yang sudah dipakai. Aman dijalankan berulang.
+UPDATE users SET department = UPPER(TRIM(department))
+WHERE department IS NOT NULL AND department <> UPPER(TRIM(department));
+UPDATE users SET branch = TRIM(branch)
+WHERE branch IS NOT NULL AND branch <> TRIM(branch);
+UPDATE users SET job_title = TRIM(job_title)
+WHERE job_title IS NOT NULL AND job_title <> TRIM(job_title);
+
+INSERT INTO departments (code, name, code_prefix)
+SELECT DISTINCT department, department, department
+FROM users WHERE COALESCE(department, '') <> ''
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO branches (name)
+SELECT DISTINCT branch FROM users WHERE COALESCE(branch, '') <> ''
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO job_titles (title)
+SELECT DISTINCT job_title FROM users WHERE COALESCE(job_title, '') <> ''
+ON CONFLICT (title) DO NOTHING;
+
+-- =============================================
+-- Employment history (mutasi/promosi, effective-dated)
+-- =============================================
+-- Satu baris = posisi karyawan mulai effective_date. Baris dengan
+-- applied_at NULL adalah perubahan terjadwal yang belum diterapkan;
+-- kolom NULL di baris itu berarti "tidak berubah".
+CREATE TABLE IF NOT EXISTS employment_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    department VARCHAR(10),
+    job_title VARCHAR(100),
+    branch VARCHAR(50),
+    status VARCHAR(20),
+    effective_date DATE NOT NULL,
+    reason TEXT,
+    changed_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    applied_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Perubahan terjadwal yang tidak lagi valid saat jatuh tempo (transisi status
+-- atau master data nonaktif) ditandai, tidak diterapkan.
+ALTER TABLE employment_history ADD COLUMN IF NOT EXISTS skipped_reason TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_employment_history_user ON employment_history (user_id, effective_date);
+CREATE INDEX IF NOT EXISTS idx_employment_history_pending ON employment_history (effective_date) WHERE applied_at IS NULL;
+
+-- Posisi awal untuk karyawan yang belum punya riwayat.
+INSERT INTO employment_history (user_id, department, job_title, branch, status, effective_date, reason, applied_at)
+SELECT u.id, u.department, u.job_title, u.branch, u.status, COALESCE(u.join_date, CURRENT_DATE), 'Initial record', NOW()
+FROM users u
+WHERE NOT EXISTS (SELECT 1 FROM employment_history h WHERE h.user_id = u.id);
+
+-- =============================================
+-- Employee code sequences
+-- =============================================
+-- scope = pattern yang sudah di-render tanpa nomor, mis. 'IT{SEQ}' atau 'IT25{SEQ}'.
+CREATE TABLE IF NOT EXISTS employee_code_sequences (
+    scope VARCHAR(60) PRIMARY KEY,
+    last_value BIGINT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- =============================================
+-- Profile change policies & requests
+-- =============================================
+-- mode IMMEDIATE = langsung tersimpan, APPROVAL = menunggu review HRD.
+CREATE TABLE IF NOT EXISTS profile_field_policies (
+    field VARCHAR(50) PRIMARY KEY,
+    mode VARCHAR(20) NOT NULL CHECK (mode IN ('IMMEDIATE', 'APPROVAL'))
+);
+
+INSERT INTO profile_field_policies (field, mode) VALUES
+    ('name', 'APPROVAL'),
+    ('birth_date', 'APPROVAL'),
+    ('emergency_contact', 'APPROVAL'),
+    ('emergency_phone', 'APPROVAL'),
+    ('phone', 'IMMEDIATE'),
+    ('address', 'IMMEDIATE'),
+    ('gender', 'IMMEDIATE')
+ON CONFLICT (field) DO NOTHING;
+
+-- changes: [{"field": "name", "old": "...", "new": "..."}]
+CREATE TABLE IF NOT EXISTS profile_change_requests (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    changes JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING', -- PENDING, APPROVED, REJECTED, CANCELLED
+    reviewed_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    review_note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_profile_changes_status ON profile_change_requests (status, created_at);
+CREATE INDEX IF NOT EXISTS idx_profile_changes_user ON profile_change_requests (user_id);
+
+-- =============================================
+-- Employee documents vault
+-- =============================================
+CREATE TABLE IF NOT EXISTS document_types (
+    code VARCHAR(20) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    requires_expiry BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+INSERT INTO document_types (code, name, requires_expiry) VALUES
+    ('KTP', 'KTP / ID Card', FALSE),
+    ('NPWP', 'NPWP', FALSE),
+    ('CONTRACT', 'Employment Contract', TRUE),
+    ('CERTIFICATE', 'Certificate', FALSE),
+    ('PASSPORT', 'Passport', TRUE),
+    ('OTHER', 'Other', FALSE)
+ON CONFLICT (code) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS employee_documents (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type_code VARCHAR(20) NOT NULL REFERENCES document_types(code),
+    title VARCHAR(200) NOT NULL,
+    expiry_date DATE,
+    current_version INT NOT NULL DEFAULT 0,
+    -- expiry_date yang sudah dikirim alert-nya; reset saat expiry berubah
+    expiry_alerted_for DATE,
+    created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_employee_documents_user ON employee_documents (user_id);
+CREATE INDEX IF NOT EXISTS idx_employee_documents_expiry ON employee_documents (expiry_date) WHERE expiry_date IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS employee_document_versions (
+    id BIGSERIAL PRIMARY KEY,
+    document_id BIGINT NOT NULL REFERENCES employee_documents(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    mime_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    checksum_sha256 CHAR(64) NOT NULL,
+    storage_key TEXT NOT NULL,
+    uploaded_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (document_id, version)
+);
+
+-- =============================================
+-- Employment contracts & probation
+-- =============================================
+-- Satu karyawan hanya punya satu kontrak ACTIVE. Pembaruan (renewal) membuat
+-- kontrak baru yang menunjuk ke kontrak lama; perpanjangan (extension)
+-- menggeser end_date kontrak yang sama dan dicatat di contract_extensions.
+CREATE TABLE IF NOT EXISTS employment_contracts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    contract_type VARCHAR(10) NOT NULL CHECK (contract_type IN ('PKWT', 'PKWTT', 'INTERN')),
+    contract_number VARCHAR(50),
+    start_date DATE NOT NULL,
+    end_date DATE,
+    probation_end_date DATE,
+    probation_status VARCHAR(20), -- PENDING, PASSED, FAILED; NULL = tanpa masa percobaan
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE', -- ACTIVE, RENEWED, ENDED, TERMINATED
+    previous_contract_id BIGINT REFERENCES employment_contracts(id) ON DELETE SET NULL,
+    notes TEXT,
+    -- tanggal yang sudah dikirim reminder-nya; reset otomatis saat tanggal berubah
+    end_alerted_for DATE,
+    probation_alerted_for DATE,
+    created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_contracts_user ON employment_contracts (user_id, start_date);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_contracts_one_active ON employment_contracts (user_id) WHERE status = 'ACTIVE';
+CREATE INDEX IF NOT EXISTS idx_contracts_end ON employment_contracts (end_date) WHERE status = 'ACTIVE';
+
+CREATE TABLE IF NOT EXISTS contract_extensions (
+    id BIGSERIAL PRIMARY KEY,
+    contract_id BIGINT NOT NULL REFERENCES employment_contracts(id) ON DELETE CASCADE,
+    old_end_date DATE NOT NULL,
+    new_end_date DATE NOT NULL,
+    reason TEXT,
+    created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_contract_extensions_contract ON contract_extensions (contract_id);
+
+-- result EXTENDED = masa percobaan diperpanjang sampai new_end_date
+CREATE TABLE IF NOT EXISTS probation_evaluations (
+    id BIGSERIAL PRIMARY KEY,
+    contract_id BIGINT NOT NULL REFERENCES employment_contracts(id) ON DELETE CASCADE,
+    result VARCHAR(20) NOT NULL CHECK (result IN ('PASSED', 'EXTENDED', 'FAILED')),
+    old_end_date DATE NOT NULL,
+    new_end_date DATE,
+    notes TEXT,
+    evaluated_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_probation_evaluations_contract ON probation_evaluations (contract_id);
+
+-- =============================================
+-- Onboarding / offboarding checklists
+-- =============================================
+-- department NULL = template berlaku untuk semua department.
+CREATE TABLE IF NOT EXISTS checklist_templates (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('ONBOARDING', 'OFFBOARDING')),
+    department VARCHAR(10) REFERENCES departments(code) ON UPDATE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- due_offset_days: tenggat = tanggal checklist dibuat + offset
+CREATE TABLE IF NOT EXISTS checklist_template_tasks (
+    id BIGSERIAL PRIMARY KEY,
+    template_id BIGINT NOT NULL REFERENCES checklist_templates(id) ON DELETE CASCADE,
+    title VARCHAR(200) NOT NULL,
+    description TEXT,
+    owner_role VARCHAR(50) NOT NULL REFERENCES roles(code),
+    due_offset_days INT NOT NULL DEFAULT 0,
+    sort_order INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_checklist_template_tasks_template ON checklist_template_tasks (template_id, sort_order);
+
+-- Task di-copy dari template saat checklist dibuat, jadi perubahan template
+-- tidak mengubah checklist yang sudah berjalan.
+CREATE TABLE IF NOT EXISTS employee_checklists (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    template_id BIGINT REFERENCES checklist_templates(id) ON DELETE SET NULL,
+    kind VARCHAR(20) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_employee_checklists_user ON employee_checklists (user_id);
+
+CREATE TABLE IF NOT EXISTS employee_checklist_tasks (
+    id BIGSERIAL PRIMARY KEY,
+    checklist_id BIGINT NOT NULL REFERENCES employee_checklists(id) ON DELETE CASCADE,
+    title VARCHAR(200) NOT NULL,
+    description TEXT,
+    owner_role VARCHAR(50) NOT NULL,
+    due_date DATE NOT NULL,
+    sort_order INT NOT NULL DEFAULT 0,
+    completed_at TIMESTAMP,
+    completed_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    note TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_employee_checklist_tasks_checklist ON employee_checklist_tasks (checklist_id, sort_order);
+CREATE INDEX IF NOT EXISTS idx_employee_checklist_tasks_open ON employee_checklist_tasks (due_date) WHERE completed_at IS NULL;
+
+-- Template default; hanya di-seed sekali (saat tabel masih kosong).
+INSERT INTO checklist_templates (kind, name)
+SELECT v.kind, v.name
+FROM (VALUES ('ONBOARDING', 'Standard onboarding'), ('OFFBOARDING', 'Standard offboarding')) AS v(kind, name)
+WHERE NOT EXISTS (SELECT 1 FROM checklist_templates);
+
+INSERT INTO checklist_template_tasks (template_id, title, owner_role, due_offset_days, sort_order)
+SELECT t.id, v.title, v.owner_role, v.offset_days, v.sort_order
+FROM checklist_templates t
+JOIN (VALUES
+    ('ONBOARDING', 'Create email & system accounts', 'IT_ADMIN', 0, 1),
+    ('ONBOARDING', 'Prepare laptop & equipment', 'IT_ADMIN', 1, 2),
+    ('ONBOARDING', 'Collect KTP, NPWP & bank account', 'HRD', 3, 3),
+    ('ONBOARDING', 'Register BPJS Kesehatan & Ketenagakerjaan', 'HRD', 14, 4),
+    ('OFFBOARDING', 'Disable email & system accounts', 'IT_ADMIN', 0, 1),
+    ('OFFBOARDING', 'Collect laptop, ID card & equipment', 'IT_ADMIN', 1, 2),
+    ('OFFBOARDING', 'Final payroll & BPJS deregistration', 'HRD', 7, 3),
+    ('OFFBOARDING', 'Issue paklaring (work certificate)', 'HRD', 14, 4)
+) AS v(kind, title, owner_role, offset_days, sort_order) ON v.kind = t.kind
+WHERE t.name IN ('Standard onboarding', 'Standard offboarding')
+  AND NOT EXISTS (SELECT 1 FROM checklist_template_tasks);
+
+-- =============================================
+-- Employee status lifecycle & session revocation
+-- =============================================
+-- Status: ACTIVE, SUSPENDED, RESIGNED, TERMINATED. INACTIVE lama (soft delete)
+-- dipetakan ke TERMINATED.
+UPDATE users SET status = 'TERMINATED' WHERE status = 'INACTIVE';
+
+-- Token JWT dengan iat sebelum waktu ini ditolak (logout paksa).
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tokens_valid_after TIMESTAMP;
+
+-- =============================================
+-- Audit log & hard delete (anonymize / cascade)
+-- =============================================
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    action VARCHAR(50) NOT NULL,
+    entity VARCHAR(50) NOT NULL,
+    entity_id VARCHAR(50),
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log (entity, entity_id);
+
+-- Diisi saat data pribadi karyawan dianonimkan.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMP;
+
+-- =============================================
+-- Export data pribadi karyawan (job async -> ZIP)
+-- =============================================
+CREATE TABLE IF NOT EXISTS data_export_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING', -- PENDING, RUNNING, DONE, FAILED, EXPIRED
+    storage_key TEXT,
+    file_size BIGINT,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    expires_at TIMESTAMP
+);
+
+-- Satu job aktif per karyawan.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_data_export_jobs_active ON data_export_jobs (user_id) WHERE status IN ('PENDING', 'RUNNING');
+CREATE INDEX IF NOT EXISTS idx_data_export_jobs_status ON data_export_jobs (status, id);
+
+-- =============================================
+-- Custom fields karyawan (didefinisikan admin, nilai di users.custom_fields)
+-- =============================================
+CREATE TABLE IF NOT EXISTS custom_field_definitions (
+    key VARCHAR(50) PRIMARY KEY,
+    label VARCHAR(100) NOT NULL,
+    field_type VARCHAR(20) NOT NULL, -- TEXT, NUMBER, DATE, BOOLEAN, SELECT
+    options TEXT[],
+    pattern TEXT,
+    min_value DOUBLE PRECISION,
+    max_value DOUBLE PRECISION,
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    department VARCHAR(10) REFERENCES departments(code) ON UPDATE CASCADE,
+    visible_roles TEXT[] NOT NULL DEFAULT '{}', -- kosong = semua role
+    sort_order INT NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS custom_fields JSONB NOT NULL DEFAULT '{}';
+CREATE INDEX IF NOT EXISTS idx_users_custom_fields ON users USING GIN (custom_fields);
+
+-- =============================================
+-- Tanggungan keluarga (pasangan, anak, orang tua)
+-- =============================================
+CREATE TABLE IF NOT EXISTS employee_dependents (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    full_name VARCHAR(100) NOT NULL,
+    relationship VARCHAR(10) NOT NULL, -- SPOUSE, CHILD, PARENT
+    gender VARCHAR(10),
+    birth_date DATE,
+    nik VARCHAR(16),
+    health_coverage BOOLEAN NOT NULL DEFAULT FALSE,
+    tax_dependent BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_employee_dependents_user ON employee_dependents (user_id);
+
+-- NIK tanggungan dienkripsi seperti employee_sensitive_data ("<key id>:<base64>").
+-- Kolom nik hanya berisi data lama sampai dienkripsi oleh job di background.
+ALTER TABLE employee_dependents ADD COLUMN IF NOT EXISTS nik_enc TEXT;
+
+-- Pengajuan perubahan tanggungan dari self-service, direview HRD.
+-- payload: nilai baru (CREATE/UPDATE), kosong untuk DELETE. payload.nik
+-- berisi ciphertext, sama dengan employee_dependents.nik_enc.
+CREATE TABLE IF NOT EXISTS dependent_change_requests (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    dependent_id BIGINT REFERENCES employee_dependents(id) ON DELETE SET NULL,
+    action VARCHAR(10) NOT NULL, -- CREATE, UPDATE, DELETE
+    payload JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING', -- PENDING, APPROVED, REJECTED, CANCELLED
+    reviewed_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    review_note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_dependent_changes_status ON dependent_change_requests (status, created_at);
+CREATE INDEX IF NOT EXISTS idx_dependent_changes_user ON dependent_change_requests (user_id);
+
+-- =============================================
+-- Data sensitif payroll (rekening bank, NPWP, NIK)
+-- =============================================
+-- *_enc berisi ciphertext AES-GCM "<key id>:<base64>"; key_id = key yang
+-- dipakai seluruh field di row ini, untuk rotasi.
+CREATE TABLE IF NOT EXISTS employee_sensitive_data (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    bank_name VARCHAR(100),
+    bank_account_holder VARCHAR(100),
+    bank_account_enc TEXT,
+    npwp_enc TEXT,
+    nik_enc TEXT,
+    key_id VARCHAR(50) NOT NULL,
+    updated_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_employee_sensitive_data_key ON employee_sensitive_data (key_id);
+
+-- =============================================
+-- Skills, sertifikasi & pelatihan
+-- =============================================
+CREATE TABLE IF NOT EXISTS skills (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    category VARCHAR(50),
+    description TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_skills_name ON skills (LOWER(name));
+
+-- level: 1 BEGINNER .. 5 EXPERT
+CREATE TABLE IF NOT EXISTS employee_skills (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    skill_id BIGINT NOT NULL REFERENCES skills(id) ON DELETE CASCADE,
+    level SMALLINT NOT NULL CHECK (level BETWEEN 1 AND 5),
+    years_experience NUMERIC(4, 1),
+    notes TEXT,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, skill_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_employee_skills_skill ON employee_skills (skill_id, level);
+
+CREATE TABLE IF NOT EXISTS employee_certifications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL DEFAULT 'CERTIFICATION', -- CERTIFICATION, TRAINING
+    name VARCHAR(150) NOT NULL,
+    issuer VARCHAR(150) NOT NULL,
+    credential_number VARCHAR(100),
+    skill_id BIGINT REFERENCES skills(id) ON DELETE SET NULL,
+    issue_date DATE,
+    expiry_date DATE,
+    expiry_alerted_for DATE, -- expiry_date yang sudah dikirim pengingatnya
+    created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_employee_certifications_user ON employee_certifications (user_id);
+CREATE INDEX IF NOT EXISTS idx_employee_certifications_expiry ON employee_certifications (expiry_date) WHERE expiry_date IS NOT NULL;
+
+-- =============================================
+-- Ulang tahun & anniversary kerja
+-- =============================================
+-- Opt-out per karyawan; tanpa row = tampil di feed.
+CREATE TABLE IF NOT EXISTS celebration_preferences (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    hide_birthday BOOLEAN NOT NULL DEFAULT FALSE,
+    hide_anniversary BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Pengumuman otomatis yang sudah dibuat, supaya tidak dobel per perayaan.
+CREATE TABLE IF NOT EXISTS celebration_announcements (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL, -- BIRTHDAY, ANNIVERSARY
+    occurs_on DATE NOT NULL,
+    announcement_id INT REFERENCES announcements(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, kind, occurs_on)
+);
+
+CREATE INDEX IF NOT EXISTS idx_celebration_announcements_date ON celebration_announcements (occurs_on);
@@ -2,3 +2,23 @@
 // e.g., if accessed via 127.0.0.1:5173, API will be 127.0.0.1:8080
 // if accessed via localhost:5173, API will be localhost:8080
 export const API_BASE = `http://${window.location.hostname}:8080`;
+
+// fetchAllEmployees follows next_cursor until every matching employee is
+// loaded. Untuk dropdown/lookup; halaman list sebaiknya pakai paging biasa.
+export async function fetchAllEmployees(params = {}) {
+  const items = [];
+  let cursor = "";
+  do {
+    const q = new URLSearchParams({ ...params, page_size: "500" });
+    if (cursor) q.set("cursor", cursor);
+    const res = await fetch(`${API_BASE}/api/employees?${q}`, { credentials: "include" });
+    if (!res.ok) {
+      const body = await res.json().catch(() => ({}));
+      throw new Error(body.message || "Failed to fetch employees");
+    }
+    const data = await res.json();
+    items.push(...(data.items || []));
+    cursor = data.next_cursor || "";
+  } while (cursor);
+  return items;
+}